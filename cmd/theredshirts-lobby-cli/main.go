@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/cli"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	err := cli.Run(ctx, os.Args[1:], os.Stdout)
+	stop()
+	if errors.Is(err, cli.ErrUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal("Error while running command: ", err)
+	}
+}
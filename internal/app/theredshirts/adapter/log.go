@@ -0,0 +1,22 @@
+package adapter
+
+import (
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+type LogSink struct {
+}
+
+func NewLogSink() *LogSink {
+	return &LogSink{}
+}
+
+func (sink *LogSink) SendMessage(context *util.Context, message *Message, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	context.Logger.Infof("Message with topic %s for lobby [%v] from player [%v]: %v", message.Topic, lobbyId, senderPlayerId, message.Message)
+	return nil
+}
+
+func (sink *LogSink) Ping(context *util.Context) error {
+	return nil
+}
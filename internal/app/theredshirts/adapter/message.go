@@ -24,6 +24,7 @@ type (
 const (
 	create_message_id_path = "%s/message/%s/msg"
 	create_message_path    = "%s/message/%s/msg/%s"
+	ping_path              = "%s/server/ping"
 	correlation_id         = "X-Correlation-ID"
 	content_typ_value      = "application/json; charset=utf-8"
 	content_typ            = "Content-Type"
@@ -36,6 +37,18 @@ func NewMessageAdapter() (*MessageAdapter, error) {
 	return &MessageAdapter{ServerUrl: serverUrl}, nil
 }
 
+func (adapter *MessageAdapter) SendMessage(context *util.Context, message *Message, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	msgId, err := adapter.CreateMessageId(context, lobbyId, senderPlayerId)
+	if err != nil {
+		return err
+	}
+	return adapter.CreateMessage(context, message, lobbyId, msgId, senderPlayerId)
+}
+
+func (adapter *MessageAdapter) Ping(context *util.Context) error {
+	return pingUrl(context, http.MethodGet, fmt.Sprintf(ping_path, adapter.ServerUrl))
+}
+
 func (adapter *MessageAdapter) CreateMessageId(context *util.Context, lobbyId uuid.UUID, senderPlayerId uuid.UUID) (string, error) {
 	response, err := adapter.sendCreateMessageId(context, lobbyId, senderPlayerId)
 	if err != nil {
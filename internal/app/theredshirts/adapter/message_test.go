@@ -0,0 +1,85 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContext() *util.Context {
+	correlationId := uuid.NewString()
+	return &util.Context{CorrelationId: correlationId, Logger: log.WithField("test", correlationId)}
+}
+
+func TestMessageAdapter_SendMessage(t *testing.T) {
+	lobbyId := uuid.New()
+	senderPlayerId := uuid.New()
+	msgId := uuid.NewString()
+	context := newTestContext()
+	var receivedMessage *Message
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, context.CorrelationId, r.Header.Get(correlation_id))
+		assert.Equal(t, senderPlayerId.String(), r.Header.Get(header_player_id))
+		switch r.Method {
+		case http.MethodPost:
+			assert.Equal(t, fmt.Sprintf("/message/%s/msg", lobbyId), r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(msgId))
+		case http.MethodPut:
+			assert.Equal(t, fmt.Sprintf("/message/%s/msg/%s", lobbyId, msgId), r.URL.Path)
+			receivedMessage = new(Message)
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(receivedMessage))
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	adapter := &MessageAdapter{ServerUrl: server.URL}
+	err := adapter.SendMessage(context, &Message{Topic: "SOME_TOPIC", Message: map[string]interface{}{"key": "value"}}, lobbyId, senderPlayerId)
+	assert.Nil(t, err)
+	assert.Equal(t, "SOME_TOPIC", receivedMessage.Topic)
+	assert.Equal(t, "value", receivedMessage.Message["key"])
+}
+
+func TestMessageAdapter_SendMessageWrongStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	adapter := &MessageAdapter{ServerUrl: server.URL}
+	err := adapter.SendMessage(newTestContext(), &Message{Topic: "SOME_TOPIC"}, uuid.New(), uuid.New())
+	assert.ErrorContains(t, err, "wrong status of response while creating message id")
+}
+
+func TestMessageAdapter_Ping(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/server/ping", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	adapter := &MessageAdapter{ServerUrl: server.URL}
+	assert.Nil(t, adapter.Ping(newTestContext()))
+}
+
+func TestMessageAdapter_PingUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	adapter := &MessageAdapter{ServerUrl: server.URL}
+	assert.ErrorContains(t, adapter.Ping(newTestContext()), "wrong status of response while pinging")
+
+	server.Close()
+	assert.ErrorContains(t, adapter.Ping(newTestContext()), "not possible")
+}
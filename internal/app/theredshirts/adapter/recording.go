@@ -0,0 +1,54 @@
+package adapter
+
+import (
+	"sync"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+type (
+	RecordingSink struct {
+		mutex    sync.Mutex
+		messages []*RecordedMessage
+	}
+	RecordedMessage struct {
+		CorrelationId  string
+		LobbyId        uuid.UUID
+		SenderPlayerId uuid.UUID
+		Topic          string
+		Message        map[string]interface{}
+	}
+)
+
+func NewRecordingSink() *RecordingSink {
+	return &RecordingSink{messages: make([]*RecordedMessage, 0)}
+}
+
+func (sink *RecordingSink) SendMessage(context *util.Context, message *Message, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.messages = append(sink.messages, &RecordedMessage{CorrelationId: context.CorrelationId, LobbyId: lobbyId, SenderPlayerId: senderPlayerId, Topic: message.Topic, Message: message.Message})
+	return nil
+}
+
+func (sink *RecordingSink) Ping(context *util.Context) error {
+	return nil
+}
+
+// Messages returns all recorded messages in the order they were sent
+func (sink *RecordingSink) Messages() []*RecordedMessage {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return append([]*RecordedMessage{}, sink.messages...)
+}
+
+// Topics returns the topics of all recorded messages in the order they were sent
+func (sink *RecordingSink) Topics() []string {
+	messages := sink.Messages()
+	topics := make([]string, len(messages))
+	for index, message := range messages {
+		topics[index] = message.Topic
+	}
+	return topics
+}
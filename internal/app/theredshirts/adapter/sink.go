@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+type (
+	MessageSink interface {
+		SendMessage(context *util.Context, message *Message, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error
+		// Ping checks that the receiver of the messages can be reached
+		Ping(context *util.Context) error
+	}
+)
+
+const (
+	ping_timeout_sec = 5
+)
+
+func NewMessageSink() (MessageSink, error) {
+	switch sink := strings.ToLower(util.GetEnvWithFallback("MESSAGE_SINK", "message-service")); sink {
+	case "message-service":
+		return NewMessageAdapter()
+	case "webhook":
+		return NewWebhookSink()
+	case "log":
+		return NewLogSink(), nil
+	case "inmemory":
+		return NewRecordingSink(), nil
+	default:
+		return nil, fmt.Errorf("no message sink %s found", sink)
+	}
+}
+
+// pingUrl counts every answer as reachable, except server errors. Receivers may answer a request without message with a client error
+func pingUrl(context *util.Context, method string, url string) error {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return fmt.Errorf("request to ping %s could not be build: %v", url, err)
+	}
+	req.Header.Set(correlation_id, context.CorrelationId)
+
+	client := &http.Client{Timeout: ping_timeout_sec * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to ping %s not possible: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("wrong status of response while pinging %s: %v", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMessageSink_Default(t *testing.T) {
+	sink, err := NewMessageSink()
+	assert.Nil(t, err)
+	assert.IsType(t, &MessageAdapter{}, sink)
+}
+
+func TestNewMessageSink_Log(t *testing.T) {
+	t.Setenv("MESSAGE_SINK", "log")
+	sink, err := NewMessageSink()
+	assert.Nil(t, err)
+	assert.IsType(t, &LogSink{}, sink)
+}
+
+func TestNewMessageSink_Inmemory(t *testing.T) {
+	t.Setenv("MESSAGE_SINK", "inmemory")
+	sink, err := NewMessageSink()
+	assert.Nil(t, err)
+	assert.IsType(t, &RecordingSink{}, sink)
+}
+
+func TestNewMessageSink_WebhookWithoutUrl(t *testing.T) {
+	t.Setenv("MESSAGE_SINK", "webhook")
+	sink, err := NewMessageSink()
+	assert.Nil(t, sink)
+	assert.ErrorContains(t, err, "webhook url has to be set")
+}
+
+func TestNewMessageSink_Unknown(t *testing.T) {
+	t.Setenv("MESSAGE_SINK", "unknown")
+	sink, err := NewMessageSink()
+	assert.Nil(t, sink)
+	assert.ErrorContains(t, err, "no message sink unknown found")
+}
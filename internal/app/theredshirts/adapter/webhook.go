@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+type (
+	WebhookSink struct {
+		Url    string
+		Secret string
+	}
+	WebhookMessage struct {
+		LobbyId        uuid.UUID              `json:"lobby_id"`
+		SenderPlayerId uuid.UUID              `json:"sender_player_id"`
+		Topic          string                 `json:"topic"`
+		Message        map[string]interface{} `json:"message"`
+	}
+)
+
+const (
+	header_signature    = "X-Signature"
+	header_timestamp    = "X-Timestamp"
+	signature_prefix    = "sha256="
+	webhook_timeout_sec = 10
+)
+
+func NewWebhookSink() (*WebhookSink, error) {
+	url, err := util.GetEnv("WEBHOOK_URL")
+	if err != nil {
+		return nil, fmt.Errorf("webhook url has to be set: %v", err)
+	}
+	secret, err := util.GetEnv("WEBHOOK_SECRET")
+	if err != nil {
+		return nil, fmt.Errorf("webhook secret has to be set: %v", err)
+	}
+	return &WebhookSink{Url: url, Secret: secret}, nil
+}
+
+func (sink *WebhookSink) SendMessage(context *util.Context, message *Message, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	body, err := json.Marshal(&WebhookMessage{LobbyId: lobbyId, SenderPlayerId: senderPlayerId, Topic: message.Topic, Message: message.Message})
+	if err != nil {
+		return fmt.Errorf("error while marshal webhook message: %v", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, sink.Url, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("request to send webhook could not be build: %v", err)
+	}
+	req.Header.Set(correlation_id, context.CorrelationId)
+	req.Header.Set(content_typ, content_typ_value)
+	req.Header.Set(header_timestamp, timestamp)
+	req.Header.Set(header_signature, signature_prefix+SignWebhook(sink.Secret, timestamp, body))
+
+	client := &http.Client{Timeout: webhook_timeout_sec * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to send webhook not possible: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("wrong status of response while sending webhook: %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// Ping only checks that the receiver answers, no webhook is sent
+func (sink *WebhookSink) Ping(context *util.Context) error {
+	return pingUrl(context, http.MethodHead, sink.Url)
+}
+
+// SignWebhook calculates the hex encoded HMAC-SHA256 of timestamp and body, which receivers use to verify the sender
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
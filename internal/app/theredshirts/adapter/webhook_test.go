@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSink_SendMessage(t *testing.T) {
+	secret := "some secret"
+	lobbyId := uuid.New()
+	var body []byte
+	var signature, timestamp string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(header_signature)
+		timestamp = r.Header.Get(header_timestamp)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	t.Setenv("WEBHOOK_URL", server.URL)
+	t.Setenv("WEBHOOK_SECRET", secret)
+
+	sink, err := NewWebhookSink()
+	assert.Nil(t, err)
+	err = sink.SendMessage(newTestContext(), &Message{Topic: "SOME_TOPIC", Message: map[string]interface{}{}}, lobbyId, uuid.New())
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), lobbyId.String())
+	assert.NotEmpty(t, timestamp)
+	assert.Equal(t, signature_prefix+SignWebhook(secret, timestamp, body), signature)
+}
+
+func TestWebhookSink_SendMessageWrongStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{Url: server.URL, Secret: "some secret"}
+	err := sink.SendMessage(newTestContext(), &Message{Topic: "SOME_TOPIC"}, uuid.New(), uuid.New())
+	assert.ErrorContains(t, err, "wrong status of response while sending webhook")
+}
+
+func TestWebhookSink_PingSendsNoWebhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	sink := &WebhookSink{Url: server.URL, Secret: "some secret"}
+	assert.Nil(t, sink.Ping(newTestContext()))
+}
+
+func TestSignWebhook_DependsOnSecret(t *testing.T) {
+	body := []byte("{}")
+	assert.NotEqual(t, SignWebhook("first", "1", body), SignWebhook("second", "1", body))
+	assert.Equal(t, SignWebhook("first", "1", body), SignWebhook("first", "1", body))
+}
@@ -1,10 +1,19 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/authi/pkg/parser"
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo-contrib/jaegertracing"
 	"github.com/labstack/echo-contrib/prometheus"
@@ -24,7 +33,12 @@ type (
 		validator *validator.Validate
 	}
 	EchoApi struct {
-		core core.Core
+		core        core.Core
+		tokenParser parser.Parser
+		// allowedOrigins can open websockets in addition to the own host
+		allowedOrigins []string
+		// shutdown is closed when the server shuts down, so open event streams end and the server is no longer ready
+		shutdown chan struct{}
 	}
 	Api interface {
 	}
@@ -37,25 +51,17 @@ func NewApi() (Api, error) {
 		return nil, fmt.Errorf("error while creating core layer: %v", err)
 	}
 
-	echoApi := &EchoApi{core: core}
-	e := echo.New()
-	e.HideBanner = true
-	e.AutoTLSManager.Cache = autocert.DirCache("/var/www/.cache")
-	e.Use(middleware.CORS(), middleware.Recover())
-	e.Validator = &CustomValidator{validator: validator.New()}
+	tokenParser, err := parser.NewJWTParser()
+	if err != nil {
+		return nil, fmt.Errorf("error while creating token parser: %v", err)
+	}
+
+	echoApi := &EchoApi{core: core, tokenParser: tokenParser, allowedOrigins: loadAllowedOrigins(), shutdown: make(chan struct{})}
+	e := newEchoServer(echoApi)
 
 	c := jaegertracing.New(e, nil)
 	defer c.Close()
 
-	serverGroup := e.Group(server_root_path, setContextMiddleware)
-	initServerInterface(serverGroup, echoApi)
-
-	lobbyGroup := e.Group(lobby_root_path, setContextMiddleware)
-	initLobbyInterface(lobbyGroup, echoApi)
-
-	playerGroup := e.Group(player_root_path, setContextMiddleware)
-	initPlayerInterface(playerGroup, echoApi)
-
 	prom := prometheus.NewPrometheus("lobby", nil)
 	prom.Use(e)
 
@@ -64,12 +70,84 @@ func NewApi() (Api, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error while loading port from environment variable: %w", err)
 	}
+	shutdownTimeout, err := util.GetEnvIntWithFallback("SHUTDOWN_TIMEOUT_SECONDS", 30)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading shutdown timeout from environment variable: %w", err)
+	}
+	readinessDelay, err := util.GetEnvIntWithFallback("SHUTDOWN_READINESS_DELAY_SECONDS", 5)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading shutdown readiness delay from environment variable: %w", err)
+	}
 	url := fmt.Sprintf("%s:%d", address, port)
-	e.Logger.Fatal(e.Start(url))
+
+	signalContext, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	go func() {
+		if err := e.Start(url); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.Logger.Fatal(err)
+		}
+	}()
+	<-signalContext.Done()
+
+	log.Info("Shutdown server")
+	close(echoApi.shutdown)
+	// the server keeps accepting requests until the load balancer noticed that it is no longer ready
+	time.Sleep(time.Duration(readinessDelay) * time.Second)
+	shutdownContext, cancel := context.WithTimeout(context.Background(), time.Duration(shutdownTimeout)*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownContext); err != nil {
+		log.Warnf("Error while draining requests: %v", err)
+	}
+	core.Shutdown(&util.Context{CorrelationId: "shutdown", Logger: log.WithField(correlation_id_header, "shutdown")})
 
 	return echoApi, nil
 }
 
+func loadAllowedOrigins() []string {
+	allowedOrigins := make([]string, 0)
+	for _, origin := range strings.Split(util.GetEnvWithFallback("ALLOWED_ORIGINS", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			allowedOrigins = append(allowedOrigins, origin)
+		}
+	}
+	return allowedOrigins
+}
+
+func (api *EchoApi) isShuttingDown() bool {
+	select {
+	case <-api.shutdown:
+		return true
+	default:
+		return false
+	}
+}
+
+func newEchoServer(echoApi *EchoApi) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.AutoTLSManager.Cache = autocert.DirCache("/var/www/.cache")
+	e.Use(middleware.CORS(), middleware.Recover())
+	e.Validator = &CustomValidator{validator: validator.New()}
+	e.HTTPErrorHandler = problemErrorHandler
+
+	serverGroup := e.Group(server_root_path, setContextMiddleware)
+	initServerInterface(serverGroup, echoApi)
+
+	lobbyGroup := e.Group(lobby_root_path, setContextMiddleware, echoApi.checkTokenMiddleware, echoApi.idempotencyMiddleware)
+	initLobbyInterface(lobbyGroup, echoApi)
+	initEventInterface(lobbyGroup, echoApi)
+	initInviteInterface(lobbyGroup, echoApi)
+	initHistoryInterface(lobbyGroup, echoApi)
+
+	playerGroup := e.Group(player_root_path, setContextMiddleware, echoApi.checkTokenMiddleware, echoApi.idempotencyMiddleware)
+	initPlayerInterface(playerGroup, echoApi)
+
+	joinGroup := e.Group(join_root_path, setContextMiddleware, echoApi.checkTokenMiddleware, echoApi.idempotencyMiddleware)
+	initJoinInterface(joinGroup, echoApi)
+
+	return e
+}
+
 func (cv *CustomValidator) Validate(i interface{}) error {
 	return cv.validator.Struct(i)
 }
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+type testServer struct {
+	api        *EchoApi
+	core       core.Core
+	server     *httptest.Server
+	privateKey *rsa.PrivateKey
+}
+
+// newTestServer starts the api with an in-memory database and message sink
+func newTestServer(t *testing.T) *testServer {
+	t.Setenv("DATABASE", "inmemory")
+	t.Setenv("MESSAGE_SINK", "inmemory")
+	t.Setenv("LOBBY_USER", uuid.NewString())
+	t.Setenv("PASSWORD_HASH_COST", "4")
+	testCore, err := core.NewCore()
+	if err != nil {
+		t.Fatalf("error while creating core: %v", err)
+	}
+	privateKey := generateKey(t)
+	echoApi := &EchoApi{core: testCore, tokenParser: newTestTokenParser(t, &privateKey.PublicKey), shutdown: make(chan struct{})}
+	server := httptest.NewServer(newEchoServer(echoApi))
+	t.Cleanup(server.Close)
+	return &testServer{api: echoApi, core: testCore, server: server, privateKey: privateKey}
+}
+
+func (server *testServer) newRequest(t *testing.T, method string, path string, playerId uuid.UUID) *http.Request {
+	req, err := http.NewRequest(method, server.server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("error while creating request: %v", err)
+	}
+	req.Header.Set(adapter.AuthorizationHeaderName, bearer_prefix+signToken(t, server.privateKey, playerId, time.Now().Add(time.Minute)))
+	return req
+}
+
+func (server *testServer) newJSONRequest(t *testing.T, method string, path string, playerId uuid.UUID, body string) *http.Request {
+	req := server.newRequest(t, method, path, playerId)
+	req.Body = io.NopCloser(strings.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	return req
+}
+
+func (server *testServer) do(t *testing.T, req *http.Request) int {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error while sending request: %v", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func newTestContext() *util.Context {
+	correlationId := uuid.NewString()
+	return &util.Context{CorrelationId: correlationId, Logger: log.WithField("test", correlationId)}
+}
+
+func createTestLobby(t *testing.T, server *testServer) *core.Lobby {
+	lobby := &core.Lobby{ID: uuid.New(), Name: "Some Lobby", Owner: &core.Player{ID: uuid.New(), Name: "Owner"}, Difficulty: 1, MissionLength: 1, NumberOfCrewMembers: 1, MaxPlayers: 4}
+	if err := server.core.CreateLobby(newTestContext(), lobby); err != nil {
+		t.Fatalf("error while creating lobby: %v", err)
+	}
+	return lobby
+}
+
+func joinTestPlayer(t *testing.T, server *testServer, lobbyId uuid.UUID) *core.Player {
+	player := &core.Player{ID: uuid.New(), Name: "Player", LobbyId: lobbyId}
+	if _, err := server.core.CreatePlayer(newTestContext(), player, ""); err != nil {
+		t.Fatalf("error while joining lobby: %v", err)
+	}
+	return player
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	player_id_log_field = "playerId"
+	bearer_prefix       = "Bearer "
+	// Browsers can't set headers on websockets, they offer the protocol bearer followed by the token as second protocol instead
+	websocket_protocol_header = "Sec-WebSocket-Protocol"
+	websocket_token_protocol  = "bearer"
+)
+
+func (api *EchoApi) checkTokenMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		customContext := c.Get(context_key).(*util.Context)
+		claims, err := api.tokenParser.ParseToken(getAuthorization(c))
+		if err != nil {
+			customContext.Logger.Warnf("Error while parsing token: %v", err)
+			return echo.ErrUnauthorized
+		}
+		if claims.UserId == uuid.Nil {
+			customContext.Logger.Warn("Token does not contain a player id")
+			return echo.ErrUnauthorized
+		}
+		customContext.PlayerId = claims.UserId
+		customContext.Logger = customContext.Logger.WithField(player_id_log_field, claims.UserId)
+		return next(c)
+	}
+}
+
+// getAuthorization reads the bearer token from the authorization header or from the protocols of a websocket.
+// Tokens are never read from the url, which ends up in access logs
+func getAuthorization(context echo.Context) string {
+	if authorization := context.Request().Header.Get(adapter.AuthorizationHeaderName); authorization != "" {
+		return authorization
+	}
+	protocols := strings.Split(context.Request().Header.Get(websocket_protocol_header), ",")
+	if len(protocols) == 2 && strings.TrimSpace(protocols[0]) == websocket_token_protocol {
+		return bearer_prefix + strings.TrimSpace(protocols[1])
+	}
+	return ""
+}
+
+func checkAuthenticatedPlayer(context *util.Context, playerId uuid.UUID) error {
+	if context.PlayerId != playerId {
+		return fmt.Errorf("authenticated player [%v] is not player [%v]", context.PlayerId, playerId)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/BeanCodeDe/authi/pkg/parser"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error while generating key: %v", err)
+	}
+	return privateKey
+}
+
+func newTestTokenParser(t *testing.T, publicKey *rsa.PublicKey) parser.Parser {
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("error while marshal public key: %v", err)
+	}
+	publicKeyPath := filepath.Join(t.TempDir(), "jwtRS256.key.pub")
+	if err := os.WriteFile(publicKeyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}), 0600); err != nil {
+		t.Fatalf("error while writing public key: %v", err)
+	}
+	t.Setenv(parser.EnvPublicKeyPath, publicKeyPath)
+
+	tokenParser, err := parser.NewJWTParser()
+	if err != nil {
+		t.Fatalf("error while creating token parser: %v", err)
+	}
+	return tokenParser
+}
+
+func signToken(t *testing.T, privateKey *rsa.PrivateKey, playerId uuid.UUID, expiresAt time.Time) string {
+	claims := &adapter.Claims{UserId: playerId, StandardClaims: jwt.StandardClaims{ExpiresAt: expiresAt.Unix()}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("error while signing token: %v", err)
+	}
+	return token
+}
+
+func callCheckTokenMiddleware(api *EchoApi, authorization string) (*util.Context, bool, error) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set(adapter.AuthorizationHeaderName, authorization)
+	}
+	context := e.NewContext(req, httptest.NewRecorder())
+	customContext := &util.Context{Logger: log.WithField("test", true)}
+	context.Set(context_key, customContext)
+
+	called := false
+	err := api.checkTokenMiddleware(func(c echo.Context) error {
+		called = true
+		return nil
+	})(context)
+	return customContext, called, err
+}
+
+func TestCheckTokenMiddleware_Successfully(t *testing.T) {
+	privateKey := generateKey(t)
+	api := &EchoApi{tokenParser: newTestTokenParser(t, &privateKey.PublicKey)}
+	playerId := uuid.New()
+
+	customContext, called, err := callCheckTokenMiddleware(api, "Bearer "+signToken(t, privateKey, playerId, time.Now().Add(time.Minute)))
+	assert.Nil(t, err)
+	assert.True(t, called)
+	assert.Equal(t, playerId, customContext.PlayerId)
+}
+
+func TestCheckTokenMiddleware_MissingToken(t *testing.T) {
+	privateKey := generateKey(t)
+	api := &EchoApi{tokenParser: newTestTokenParser(t, &privateKey.PublicKey)}
+
+	customContext, called, err := callCheckTokenMiddleware(api, "")
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.False(t, called)
+	assert.Equal(t, uuid.Nil, customContext.PlayerId)
+}
+
+func TestCheckTokenMiddleware_WrongKey(t *testing.T) {
+	privateKey := generateKey(t)
+	wrongKey := generateKey(t)
+	api := &EchoApi{tokenParser: newTestTokenParser(t, &privateKey.PublicKey)}
+
+	_, called, err := callCheckTokenMiddleware(api, "Bearer "+signToken(t, wrongKey, uuid.New(), time.Now().Add(time.Minute)))
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.False(t, called)
+}
+
+func TestCheckTokenMiddleware_Expired(t *testing.T) {
+	privateKey := generateKey(t)
+	api := &EchoApi{tokenParser: newTestTokenParser(t, &privateKey.PublicKey)}
+
+	_, called, err := callCheckTokenMiddleware(api, "Bearer "+signToken(t, privateKey, uuid.New(), time.Now().Add(-time.Minute)))
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.False(t, called)
+}
+
+func TestCheckTokenMiddleware_NoPlayerId(t *testing.T) {
+	privateKey := generateKey(t)
+	api := &EchoApi{tokenParser: newTestTokenParser(t, &privateKey.PublicKey)}
+
+	_, called, err := callCheckTokenMiddleware(api, "Bearer "+signToken(t, privateKey, uuid.Nil, time.Now().Add(time.Minute)))
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.False(t, called)
+}
+
+func TestCheckAuthenticatedPlayer(t *testing.T) {
+	playerId := uuid.New()
+	context := &util.Context{PlayerId: playerId}
+
+	assert.Nil(t, checkAuthenticatedPlayer(context, playerId))
+	assert.ErrorContains(t, checkAuthenticatedPlayer(context, uuid.New()), "is not player")
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/client"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestClient calls the test server as the player
+func (server *testServer) newTestClient(t *testing.T, playerId uuid.UUID) *client.Client {
+	return client.NewClient(server.server.URL, signToken(t, server.privateKey, playerId, time.Now().Add(time.Minute)))
+}
+
+func TestClient_LobbyLifecycle(t *testing.T) {
+	server := newTestServer(t)
+	ownerId := uuid.New()
+	ownerClient := server.newTestClient(t, ownerId)
+	ctx := context.Background()
+
+	lobbyId, err := ownerClient.CreateLobbyId(ctx)
+	assert.Nil(t, err)
+	lobby := &client.LobbyCreate{ID: lobbyId, Name: "Some Lobby", Owner: &client.Player{ID: ownerId, Name: "Owner"}, Difficulty: 1, MissionLength: 1, NumberOfCrewMembers: 1, MaxPlayers: 4}
+	assert.Nil(t, ownerClient.CreateLobby(ctx, lobby))
+
+	playerId := uuid.New()
+	playerClient := server.newTestClient(t, playerId)
+	reconnectToken, err := playerClient.JoinLobby(ctx, &client.PlayerCreate{ID: playerId, Name: "Player", LobbyId: lobbyId})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, reconnectToken)
+
+	foundLobby, version, err := playerClient.GetLobby(ctx, lobbyId)
+	assert.Nil(t, err)
+	assert.Equal(t, "Some Lobby", foundLobby.Name)
+	assert.Len(t, foundLobby.Players, 2)
+	assert.Positive(t, version)
+
+	update := &client.LobbyUpdate{ID: lobbyId, Name: "Renamed Lobby", Difficulty: 1, MissionLength: 1, NumberOfCrewMembers: 1, MaxPlayers: 4}
+	assert.Nil(t, ownerClient.UpdateLobby(ctx, update, version))
+	err = ownerClient.UpdateLobby(ctx, update, version)
+	assert.True(t, errors.Is(err, client.ErrPreconditionFailed))
+
+	entries, nextCursor, err := playerClient.GetLobbyHistory(ctx, lobbyId, "", 2)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.NotEmpty(t, nextCursor)
+	entries, _, err = playerClient.GetLobbyHistory(ctx, lobbyId, nextCursor, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "PLAYER_UPDATES_LOBBY", entries[len(entries)-1].Topic)
+}
+
+func TestClient_Problem(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	_, _, err := server.newTestClient(t, uuid.New()).GetLobbyHistory(context.Background(), lobby.ID, "", 0)
+
+	var clientError *client.Error
+	assert.True(t, errors.As(err, &clientError))
+	assert.True(t, errors.Is(err, client.ErrForbidden))
+	assert.Equal(t, http.StatusForbidden, clientError.Problem.Status)
+}
+
+func TestClient_CheckLiveness(t *testing.T) {
+	server := newTestServer(t)
+
+	health, err := server.newTestClient(t, uuid.New()).CheckLiveness(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, health_up, health.Status)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	etag_header     = "ETag"
+	if_match_header = "If-Match"
+)
+
+func setETag(context echo.Context, version int) {
+	context.Response().Header().Set(etag_header, strconv.Quote(strconv.Itoa(version)))
+}
+
+// getIfMatchVersion returns the version of the If-Match header. Without header or with * any version matches, which is returned as 0.
+// If-Match compares strongly, so a weak tag never matches
+func getIfMatchVersion(context echo.Context) (int, error) {
+	ifMatch := strings.TrimSpace(context.Request().Header.Get(if_match_header))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(ifMatch, "W/") {
+		return 0, fmt.Errorf("if match header %s is a weak entity tag: %w", ifMatch, core.ErrVersionMismatch)
+	}
+	tag, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, fmt.Errorf("if match header %s is no entity tag: %v", ifMatch, err)
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("if match header %s is no version", ifMatch)
+	}
+	return version, nil
+}
+
+func ifMatchError(logger *log.Entry, err error) error {
+	if errors.Is(err, core.ErrVersionMismatch) {
+		return coreError(logger, err, "binding version")
+	}
+	logger.Warnf("Error while binding version: %v", err)
+	return echo.ErrBadRequest
+}
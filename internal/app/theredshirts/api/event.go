@@ -0,0 +1,275 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	lobby_events_path    = "/events"
+	lobby_websocket_path = "/ws"
+	last_event_id_header = "Last-Event-ID"
+	last_event_id_param  = "last_event_id"
+	event_stream_type    = "text/event-stream"
+	event_poll_interval  = 2 * time.Second
+	keep_alive_interval  = 15 * time.Second
+	origin_header        = "Origin"
+)
+
+// pingCodec sends an empty ping frame, which is answered by the client with a pong
+var pingCodec = websocket.Codec{Marshal: func(v interface{}) ([]byte, byte, error) {
+	return []byte{}, websocket.PingFrame, nil
+}}
+
+type (
+	LobbyEvent = lobbyapi.LobbyEvent
+
+	eventStream interface {
+		send(event *LobbyEvent) error
+		keepAlive() error
+	}
+
+	sseStream struct {
+		response *echo.Response
+	}
+
+	websocketStream struct {
+		conn *websocket.Conn
+	}
+)
+
+func initEventInterface(group *echo.Group, api *EchoApi) {
+	group.GET("/:"+lobby_id_param+lobby_events_path, api.streamLobbyEventsSSE)
+	group.GET("/:"+lobby_id_param+lobby_websocket_path, api.streamLobbyEventsWebsocket)
+}
+
+func (api *EchoApi) streamLobbyEventsSSE(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Stream lobby events")
+
+	lobbyId, lastEventId, memberEventId, err := api.bindEventStream(customContext, context)
+	if err != nil {
+		return err
+	}
+
+	response := context.Response()
+	response.Header().Set(echo.HeaderContentType, event_stream_type)
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	if err := api.streamLobbyEvents(customContext, lobbyId, lastEventId, memberEventId, context.Request().Context().Done(), &sseStream{response: response}); err != nil {
+		logger.Warnf("Error while streaming lobby events: %v", err)
+	}
+	return nil
+}
+
+func (api *EchoApi) streamLobbyEventsWebsocket(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Stream lobby events over websocket")
+
+	lobbyId, lastEventId, memberEventId, err := api.bindEventStream(customContext, context)
+	if err != nil {
+		return err
+	}
+
+	server := websocket.Server{Handshake: func(config *websocket.Config, request *http.Request) error {
+		if err := checkOrigin(request, api.allowedOrigins); err != nil {
+			logger.Warnf("Websocket rejected: %v", err)
+			return err
+		}
+		acceptTokenProtocol(config)
+		return nil
+	}, Handler: func(conn *websocket.Conn) {
+		defer conn.Close()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			var ignored []byte
+			for {
+				if err := websocket.Message.Receive(conn, &ignored); err != nil {
+					return
+				}
+			}
+		}()
+		if err := api.streamLobbyEvents(customContext, lobbyId, lastEventId, memberEventId, done, &websocketStream{conn: conn}); err != nil {
+			logger.Warnf("Error while streaming lobby events over websocket: %v", err)
+		}
+	}}
+	server.ServeHTTP(context.Response(), context.Request())
+	return nil
+}
+
+// bindEventStream reads the lobby and the last event the client received. Without a last event the stream starts with the next event.
+// Only members of the lobby can open a stream and they can't replay events from before they joined, memberEventId is the last event before the membership was checked
+func (api *EchoApi) bindEventStream(customContext *util.Context, context echo.Context) (lobbyId uuid.UUID, lastEventId int64, memberEventId int64, err error) {
+	logger := customContext.Logger
+	lobbyId, err = getLobbyId(context)
+	if err != nil {
+		logger.Warnf("Error while binding lobby id: %v", err)
+		return uuid.Nil, 0, 0, echo.ErrBadRequest
+	}
+
+	lastEventId = -1
+	lastEventIdString := context.Request().Header.Get(last_event_id_header)
+	if lastEventIdString == "" {
+		lastEventIdString = context.QueryParam(last_event_id_param)
+	}
+	if lastEventIdString != "" {
+		lastEventId, err = strconv.ParseInt(lastEventIdString, 10, 64)
+		if err != nil {
+			logger.Warnf("Error while binding last event id: %v", err)
+			return uuid.Nil, 0, 0, echo.ErrBadRequest
+		}
+	}
+
+	memberEventId, err = api.core.GetLastLobbyEventId(customContext, lobbyId)
+	if err != nil {
+		logger.Warnf("Error while loading last event id: %v", err)
+		return uuid.Nil, 0, 0, echo.ErrInternalServerError
+	}
+	joinEventId, err := api.core.GetLobbyJoinEventId(customContext, lobbyId, customContext.PlayerId)
+	if err != nil {
+		return uuid.Nil, 0, 0, coreError(logger, err, "checking member of lobby")
+	}
+	if lastEventId < 0 {
+		lastEventId = memberEventId
+	}
+	if lastEventId < joinEventId-1 {
+		lastEventId = joinEventId - 1
+	}
+	return lobbyId, lastEventId, memberEventId, nil
+}
+
+// streamLobbyEvents sends all events after lastEventId until done is closed, the server shuts down, the lobby was deleted
+// or the authenticated player is no longer member of the lobby after an event newer than memberEventId.
+// New events are picked up when this instance commits them or at latest after the poll interval.
+// While the stream is open the authenticated player counts as present in the lobby
+func (api *EchoApi) streamLobbyEvents(customContext *util.Context, lobbyId uuid.UUID, lastEventId int64, memberEventId int64, done <-chan struct{}, stream eventStream) error {
+	disconnect, err := api.core.ConnectPlayer(customContext, lobbyId, customContext.PlayerId)
+	if err != nil {
+		return fmt.Errorf("error while connecting player: %v", err)
+	}
+	defer disconnect()
+
+	notification, unsubscribe := api.core.SubscribeLobbyEvents(lobbyId)
+	defer unsubscribe()
+	poll := time.NewTicker(event_poll_interval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(keep_alive_interval)
+	defer keepAlive.Stop()
+
+	for {
+		events, err := api.core.GetLobbyEvents(customContext, lobbyId, lastEventId)
+		if err != nil {
+			return fmt.Errorf("error while loading events of lobby [%v]: %v", lobbyId, err)
+		}
+		for _, event := range events {
+			if err := stream.send(mapToLobbyEvent(event)); err != nil {
+				return fmt.Errorf("error while sending event [%d]: %v", event.ID, err)
+			}
+			lastEventId = event.ID
+			if event.Lobby == nil {
+				return nil
+			}
+			if event.ID > memberEventId && !core.IsLobbyMember(event.Lobby, customContext.PlayerId) {
+				return nil
+			}
+		}
+		if len(events) > 0 {
+			continue
+		}
+
+		select {
+		case <-done:
+			return nil
+		case <-api.shutdown:
+			return nil
+		case <-notification:
+		case <-poll.C:
+		case <-keepAlive.C:
+			if err := stream.keepAlive(); err != nil {
+				return fmt.Errorf("error while sending keep alive: %v", err)
+			}
+		}
+	}
+}
+
+// checkOrigin accepts websockets from the own host and the allowed origins. Requests without origin are not sent by browsers
+func checkOrigin(request *http.Request, allowedOrigins []string) error {
+	origin := request.Header.Get(origin_header)
+	if origin == "" {
+		return nil
+	}
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("origin %s is no url: %v", origin, err)
+	}
+	if strings.EqualFold(originUrl.Host, request.Host) {
+		return nil
+	}
+	for _, allowedOrigin := range allowedOrigins {
+		if strings.EqualFold(origin, allowedOrigin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s is not allowed", origin)
+}
+
+// acceptTokenProtocol answers with the token protocol when the client sent its token as websocket protocol
+func acceptTokenProtocol(config *websocket.Config) {
+	for _, protocol := range config.Protocol {
+		if protocol == websocket_token_protocol {
+			config.Protocol = []string{websocket_token_protocol}
+			return
+		}
+	}
+	config.Protocol = nil
+}
+
+func (stream *sseStream) send(event *LobbyEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error while marshal event: %v", err)
+	}
+	if _, err := fmt.Fprintf(stream.response, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Topic, data); err != nil {
+		return err
+	}
+	stream.response.Flush()
+	return nil
+}
+
+func (stream *sseStream) keepAlive() error {
+	if _, err := fmt.Fprint(stream.response, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	stream.response.Flush()
+	return nil
+}
+
+func (stream *websocketStream) send(event *LobbyEvent) error {
+	return websocket.JSON.Send(stream.conn, event)
+}
+
+func (stream *websocketStream) keepAlive() error {
+	return pingCodec.Send(stream.conn, nil)
+}
+
+func mapToLobbyEvent(event *core.Event) *LobbyEvent {
+	return &LobbyEvent{ID: event.ID, LobbyId: event.LobbyId, Topic: event.Topic, Payload: event.Payload, Lobby: mapToLobby(event.Lobby), CreatedAt: event.CreatedAt}
+}
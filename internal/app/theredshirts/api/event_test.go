@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+type sseEvent struct {
+	id    string
+	topic string
+	data  *LobbyEvent
+}
+
+func readSSEEvent(t *testing.T, reader *bufio.Reader) *sseEvent {
+	event := &sseEvent{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("error while reading event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.data != nil:
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.topic = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = new(LobbyEvent)
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), event.data); err != nil {
+				t.Fatalf("error while parsing event: %v", err)
+			}
+		}
+	}
+}
+
+func openSSEStream(t *testing.T, server *testServer, req *http.Request) *bufio.Reader {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error while opening event stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, event_stream_type, resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+func TestStreamLobbyEventsSSE_FromBeginning(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), lobby.Owner.ID)
+	req.Header.Set(last_event_id_header, "0")
+	reader := openSSEStream(t, server, req)
+
+	first := readSSEEvent(t, reader)
+	assert.Equal(t, core.PLAYER_JOINS_LOBBY, first.topic)
+	assert.Len(t, first.data.Lobby.Players, 1)
+	second := readSSEEvent(t, reader)
+	assert.Equal(t, core.PLAYER_JOINS_LOBBY, second.topic)
+	assert.Equal(t, player.ID.String(), fmt.Sprint(second.data.Payload["player_id"]))
+	assert.Len(t, second.data.Lobby.Players, 2)
+	assert.Equal(t, fmt.Sprint(second.data.ID), second.id)
+}
+
+func TestStreamLobbyEventsSSE_NotBeforeJoin(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	joinTestPlayer(t, server, lobby.ID)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), player.ID)
+	req.Header.Set(last_event_id_header, "0")
+	reader := openSSEStream(t, server, req)
+
+	first := readSSEEvent(t, reader)
+	assert.Equal(t, core.PLAYER_JOINS_LOBBY, first.topic)
+	assert.Equal(t, player.ID.String(), fmt.Sprint(first.data.Payload["player_id"]))
+	assert.Equal(t, "3", first.id)
+}
+
+func TestStreamLobbyEventsSSE_OnlyNewEvents(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	reader := openSSEStream(t, server, server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), lobby.Owner.ID))
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	event := readSSEEvent(t, reader)
+	assert.Equal(t, core.PLAYER_JOINS_LOBBY, event.topic)
+	assert.Equal(t, player.ID.String(), fmt.Sprint(event.data.Payload["player_id"]))
+}
+
+func TestStreamLobbyEventsSSE_EndsOnShutdown(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	reader := openSSEStream(t, server, server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), lobby.Owner.ID))
+	close(server.api.shutdown)
+
+	_, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+}
+
+func TestStreamLobbyEventsSSE_Unauthorized(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), lobby.Owner.ID)
+	req.Header.Del(adapter.AuthorizationHeaderName)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestStreamLobbyEventsSSE_WrongLastEventId(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), lobby.Owner.ID)
+	req.Header.Set(last_event_id_header, "abc")
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStreamLobbyEventsSSE_NotMember(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), uuid.New())
+	req.Header.Set(last_event_id_header, "0")
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+}
+
+func TestStreamLobbyEventsSSE_LobbyNotFound(t *testing.T) {
+	server := newTestServer(t)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", uuid.New()), uuid.New())
+	req.Header.Set(last_event_id_header, "0")
+	assert.Equal(t, http.StatusNotFound, server.do(t, req))
+}
+
+func TestStreamLobbyEventsSSE_TokenInUrlIsIgnored(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events?access_token=%s", lobby.ID, signToken(t, server.privateKey, lobby.Owner.ID, time.Now().Add(time.Minute))), lobby.Owner.ID)
+	req.Header.Del(adapter.AuthorizationHeaderName)
+	assert.Equal(t, http.StatusUnauthorized, server.do(t, req))
+}
+
+func TestStreamLobbyEventsSSE_EndsWhenPlayerIsKicked(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	reader := openSSEStream(t, server, server.newRequest(t, http.MethodGet, fmt.Sprintf("/lobby/%s/events", lobby.ID), player.ID))
+	assert.Nil(t, server.core.KickPlayer(newTestContext(), lobby.ID, player.ID, lobby.Owner.ID, "", false))
+
+	event := readSSEEvent(t, reader)
+	assert.Equal(t, core.PLAYER_KICKED, event.topic)
+	_, err := io.ReadAll(reader)
+	assert.Nil(t, err)
+}
+
+func dialTestWebsocket(t *testing.T, server *testServer, path string, playerId uuid.UUID, origin string) (*websocket.Conn, error) {
+	config, err := websocket.NewConfig(fmt.Sprintf("ws%s%s", strings.TrimPrefix(server.server.URL, "http"), path), origin)
+	if err != nil {
+		t.Fatalf("error while creating websocket config: %v", err)
+	}
+	config.Protocol = []string{websocket_token_protocol, signToken(t, server.privateKey, playerId, time.Now().Add(time.Minute))}
+	return websocket.DialConfig(config)
+}
+
+func TestStreamLobbyEventsWebsocket_Resume(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	conn, err := dialTestWebsocket(t, server, fmt.Sprintf("/lobby/%s/ws?%s=0", lobby.ID, last_event_id_param), lobby.Owner.ID, server.server.URL)
+	if err != nil {
+		t.Fatalf("error while opening websocket: %v", err)
+	}
+	defer conn.Close()
+	assert.Equal(t, []string{websocket_token_protocol}, conn.Config().Protocol)
+
+	event := new(LobbyEvent)
+	assert.Nil(t, websocket.JSON.Receive(conn, event))
+	assert.Equal(t, core.PLAYER_JOINS_LOBBY, event.Topic)
+	assert.Equal(t, lobby.ID, event.Lobby.ID)
+
+	assert.Nil(t, server.core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+	assert.Nil(t, websocket.JSON.Receive(conn, event))
+	assert.Equal(t, core.PLAYER_LEAVES_LOBBY, event.Topic)
+	assert.Nil(t, event.Lobby)
+}
+
+func TestStreamLobbyEventsWebsocket_LobbyNotFound(t *testing.T) {
+	server := newTestServer(t)
+
+	_, err := dialTestWebsocket(t, server, fmt.Sprintf("/lobby/%s/ws", uuid.New()), uuid.New(), server.server.URL)
+	assert.NotNil(t, err)
+}
+
+func TestStreamLobbyEventsWebsocket_ForeignOrigin(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	_, err := dialTestWebsocket(t, server, fmt.Sprintf("/lobby/%s/ws", lobby.ID), lobby.Owner.ID, "https://evil.example")
+	assert.NotNil(t, err)
+
+	server.api.allowedOrigins = []string{"https://evil.example"}
+	conn, err := dialTestWebsocket(t, server, fmt.Sprintf("/lobby/%s/ws", lobby.ID), lobby.Owner.ID, "https://evil.example")
+	assert.Nil(t, err)
+	if conn != nil {
+		conn.Close()
+	}
+}
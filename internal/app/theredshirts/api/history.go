@@ -0,0 +1,75 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	lobby_history_path = "/history"
+)
+
+type (
+	AuditEntry = lobbyapi.AuditEntry
+
+	LobbyHistory struct {
+		LobbyId uuid.UUID `param:"lobbyId" validate:"required"`
+		Limit   int       `query:"limit" validate:"min=0,max=100"`
+		Cursor  string    `query:"cursor"`
+	}
+)
+
+func initHistoryInterface(group *echo.Group, api *EchoApi) {
+	group.GET("/:"+lobby_id_param+lobby_history_path, api.getLobbyHistory)
+}
+
+func (api *EchoApi) getLobbyHistory(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Get lobby history")
+
+	lobbyHistory, err := bindLobbyHistoryDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding lobby history: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	entries, nextCursor, err := api.core.GetLobbyHistory(customContext, lobbyHistory.LobbyId, customContext.PlayerId, lobbyHistory.Cursor, lobbyHistory.Limit)
+	if err != nil {
+		return coreError(logger, err, "loading lobby history")
+	}
+	if nextCursor != "" {
+		context.Response().Header().Set(next_cursor_header, nextCursor)
+	}
+	return context.JSON(http.StatusOK, mapToAuditEntries(entries))
+}
+
+func bindLobbyHistoryDTO(context echo.Context) (*LobbyHistory, error) {
+	lobbyHistory := new(LobbyHistory)
+	if err := context.Bind(lobbyHistory); err != nil {
+		return nil, fmt.Errorf("could not bind lobby history, %v", err)
+	}
+	if err := context.Validate(lobbyHistory); err != nil {
+		return nil, fmt.Errorf("could not validate lobby history, %v", err)
+	}
+	return lobbyHistory, nil
+}
+
+// mapToAuditEntries leaves out the actor of entries which were created by the server itself
+func mapToAuditEntries(coreEntries []*core.AuditEntry) []*AuditEntry {
+	entries := make([]*AuditEntry, len(coreEntries))
+	for index, entry := range coreEntries {
+		entries[index] = &AuditEntry{ID: entry.ID, Topic: entry.Topic, CorrelationId: entry.CorrelationId, Payload: entry.Payload, CreatedAt: entry.CreatedAt}
+		if entry.ActorId != uuid.Nil {
+			actorId := entry.ActorId
+			entries[index].ActorId = &actorId
+		}
+	}
+	return entries
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLobbyHistory_Pagination(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodDelete, "/lobby/"+lobby.ID.String()+"/player/"+player.ID.String(), lobby.Owner.ID, `{"reason":"afk"}`)
+	assert.Equal(t, http.StatusNoContent, server.do(t, req))
+
+	req = server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String()+"/history?limit=3", lobby.Owner.ID)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	var entries []*AuditEntry
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&entries))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, entries, 3)
+	assert.Nil(t, entries[0].ActorId)
+
+	req = server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String()+"/history?cursor="+resp.Header.Get("X-Next-Cursor"), lobby.Owner.ID)
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	entries = nil
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&entries))
+	resp.Body.Close()
+	assert.Empty(t, resp.Header.Get("X-Next-Cursor"))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "PLAYER_KICKED", entries[0].Topic)
+	assert.Equal(t, lobby.Owner.ID, *entries[0].ActorId)
+	assert.Equal(t, "afk", entries[0].Payload["reason"])
+
+	req = server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String()+"/history?cursor=no-id", lobby.Owner.ID)
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	req = server.newRequest(t, http.MethodGet, "/lobby/"+uuid.NewString()+"/history", player.ID)
+	assert.Equal(t, http.StatusNotFound, server.do(t, req))
+}
+
+func TestGetLobbyHistory_NotMember(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String()+"/history", uuid.New())
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	idempotency_key_header     = "Idempotency-Key"
+	idempotent_replayed_header = "Idempotent-Replayed"
+	idempotency_key_max_length = 255
+)
+
+// responseRecorder keeps a copy of the body written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	recorder.body.Write(b)
+	return recorder.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware replays the stored response, if a mutating request is retried with the same Idempotency-Key.
+// Responses with a server error are not stored, so the request can be retried. Once the request was executed the
+// reservation is kept, even if its response can't be stored, so a retry is rejected until the key expires instead of executed again
+func (api *EchoApi) idempotencyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		key := c.Request().Header.Get(idempotency_key_header)
+		if key == "" || !isMutatingMethod(c.Request().Method) {
+			return next(c)
+		}
+		customContext := c.Get(context_key).(*util.Context)
+		logger := customContext.Logger.WithField(idempotency_key_header, key)
+
+		if len(key) > idempotency_key_max_length {
+			logger.Warnf("Idempotency key is longer than %d characters", idempotency_key_max_length)
+			return echo.ErrBadRequest
+		}
+
+		requestHash, err := hashRequest(c)
+		if err != nil {
+			logger.Warnf("Error while hashing request: %v", err)
+			return echo.ErrBadRequest
+		}
+
+		response, err := api.core.StartIdempotentRequest(customContext, customContext.PlayerId, key, requestHash)
+		if err != nil {
+			return coreError(logger, err, "starting idempotent request")
+		}
+
+		if response != nil {
+			logger.Debugf("Replaying response of idempotent request")
+			c.Response().Header().Set(idempotent_replayed_header, "true")
+			if len(response.Body) == 0 {
+				return c.NoContent(response.StatusCode)
+			}
+			return c.Blob(response.StatusCode, response.ContentType, response.Body)
+		}
+
+		executed := false
+		defer func() {
+			if !executed {
+				if err := api.core.AbortIdempotentRequest(customContext, customContext.PlayerId, key); err != nil {
+					logger.Warnf("Error while aborting idempotent request: %v", err)
+				}
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+		c.Response().Writer = recorder
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		if c.Response().Status >= http.StatusInternalServerError {
+			return nil
+		}
+		executed = true
+		response = &core.IdempotentResponse{StatusCode: c.Response().Status, ContentType: c.Response().Header().Get(echo.HeaderContentType), Body: recorder.body.Bytes()}
+		if err := api.core.FinishIdempotentRequest(customContext, customContext.PlayerId, key, response); err != nil {
+			logger.Warnf("Error while storing response of idempotent request: %v", err)
+		}
+		return nil
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hashRequest identifies the request by method, uri and body. The body is restored for the handler
+func hashRequest(c echo.Context) (string, error) {
+	request := c.Request()
+	body := []byte{}
+	if request.Body != nil {
+		var err error
+		body, err = io.ReadAll(request.Body)
+		if err != nil {
+			return "", fmt.Errorf("error while reading body: %v", err)
+		}
+	}
+	request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", request.Method, request.URL.RequestURI())
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
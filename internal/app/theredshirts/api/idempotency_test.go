@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyMiddleware_ReplaysRetry(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	body := `{"name":"Renamed Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4}`
+
+	for i := 0; i < 2; i++ {
+		req := server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+		req.Header.Set("Idempotency-Key", "rename")
+		req.Header.Set("If-Match", `"2"`)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, i == 1, resp.Header.Get("Idempotent-Replayed") == "true")
+	}
+
+	events, err := server.core.GetLobbyEvents(newTestContext(), lobby.ID, 0)
+	assert.Nil(t, err)
+	updates := 0
+	for _, event := range events {
+		if event.Topic == core.PLAYER_UPDATES_LOBBY {
+			updates++
+		}
+	}
+	assert.Equal(t, 1, updates)
+
+	req := server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, `{"name":"Other Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4}`)
+	req.Header.Set("Idempotency-Key", "rename")
+	assert.Equal(t, http.StatusUnprocessableEntity, server.do(t, req))
+}
+
+func TestIdempotencyMiddleware_ReplaysBody(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	bodies := make([]string, 2)
+	for i := range bodies {
+		req := server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/invite", lobby.Owner.ID, `{}`)
+		req.Header.Set("Idempotency-Key", "invite")
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		body, err := io.ReadAll(resp.Body)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "application/json; charset=UTF-8", resp.Header.Get("Content-Type"))
+		bodies[i] = string(body)
+	}
+	assert.Equal(t, bodies[0], bodies[1])
+
+	invites, err := server.core.GetInvites(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Len(t, invites, 1)
+}
+
+// finishFailingCore can't store the responses of idempotent requests
+type finishFailingCore struct {
+	core.Core
+}
+
+func (failingCore *finishFailingCore) FinishIdempotentRequest(context *util.Context, playerId uuid.UUID, key string, response *core.IdempotentResponse) error {
+	return errors.New("database not reachable")
+}
+
+func TestIdempotencyMiddleware_KeepsReservationOfExecutedRequest(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	server.api.core = &finishFailingCore{Core: server.core}
+
+	req := server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/invite", lobby.Owner.ID, `{}`)
+	req.Header.Set("Idempotency-Key", "invite")
+	assert.Equal(t, http.StatusCreated, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/invite", lobby.Owner.ID, `{}`)
+	req.Header.Set("Idempotency-Key", "invite")
+	assert.Equal(t, http.StatusConflict, server.do(t, req))
+
+	invites, err := server.core.GetInvites(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Len(t, invites, 1)
+}
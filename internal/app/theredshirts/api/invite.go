@@ -0,0 +1,168 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	join_root_path    = "/join"
+	lobby_invite_path = "/invite"
+	invite_code_param = "code"
+)
+
+type (
+	InviteCreate = lobbyapi.InviteCreate
+	InviteJoin   = lobbyapi.InviteJoin
+	Invite       = lobbyapi.Invite
+
+	InviteRevoke struct {
+		LobbyId uuid.UUID `param:"lobbyId" validate:"required"`
+		Code    string    `param:"code" validate:"required"`
+	}
+)
+
+func initInviteInterface(group *echo.Group, api *EchoApi) {
+	group.POST("/:"+lobby_id_param+lobby_invite_path, api.createInvite)
+	group.GET("/:"+lobby_id_param+lobby_invite_path, api.getInvites)
+	group.DELETE("/:"+lobby_id_param+lobby_invite_path+"/:"+invite_code_param, api.revokeInvite)
+}
+
+func initJoinInterface(group *echo.Group, api *EchoApi) {
+	group.POST("/:"+invite_code_param, api.joinWithInvite)
+}
+
+func (api *EchoApi) createInvite(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Create invite")
+
+	createInvite, err := bindInviteCreateDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding invite: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	var expiresAt *time.Time
+	if createInvite.ExpiresInSeconds > 0 {
+		expiration := time.Now().Add(time.Duration(createInvite.ExpiresInSeconds) * time.Second)
+		expiresAt = &expiration
+	}
+
+	invite, err := api.core.CreateInvite(customContext, createInvite.LobbyId, customContext.PlayerId, expiresAt, createInvite.MaxUses)
+	if err != nil {
+		return coreError(logger, err, "creating invite")
+	}
+	return context.JSON(http.StatusCreated, mapToInvite(invite))
+}
+
+func (api *EchoApi) getInvites(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Get invites of lobby")
+
+	lobbyId, err := getLobbyId(context)
+	if err != nil {
+		logger.Warnf("Error while binding lobby id: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	invites, err := api.core.GetInvites(customContext, lobbyId, customContext.PlayerId)
+	if err != nil {
+		return coreError(logger, err, "loading invites")
+	}
+	return context.JSON(http.StatusOK, mapToInvites(invites))
+}
+
+func (api *EchoApi) revokeInvite(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Revoke invite")
+
+	revokeInvite, err := bindInviteRevokeDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding invite: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := api.core.RevokeInvite(customContext, revokeInvite.LobbyId, revokeInvite.Code, customContext.PlayerId); err != nil {
+		return coreError(logger, err, "revoking invite")
+	}
+	return context.NoContent(http.StatusNoContent)
+}
+
+func (api *EchoApi) joinWithInvite(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Join with invite")
+
+	join, err := bindInviteJoinDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding join: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := checkAuthenticatedPlayer(customContext, join.PlayerId); err != nil {
+		logger.Warnf("Player is not allowed to join as other player: %v", err)
+		return echo.ErrForbidden
+	}
+
+	player := &core.Player{ID: join.PlayerId, Name: join.Name, Spectator: join.Spectator, Payload: join.Payload}
+	if err := api.core.JoinWithInvite(customContext, join.Code, player); err != nil {
+		return coreError(logger, err, "joining lobby with invite")
+	}
+
+	return context.JSON(http.StatusCreated, &SimplePlayer{ID: player.ID, Name: player.Name, Spectator: player.Spectator, LobbyId: player.LobbyId})
+}
+
+func bindInviteCreateDTO(context echo.Context) (*InviteCreate, error) {
+	var invite = new(InviteCreate)
+	if err := context.Bind(invite); err != nil {
+		return nil, fmt.Errorf("could not bind invite, %v", err)
+	}
+	if err := context.Validate(invite); err != nil {
+		return nil, fmt.Errorf("could not validate invite, %v", err)
+	}
+	return invite, nil
+}
+
+func bindInviteRevokeDTO(context echo.Context) (*InviteRevoke, error) {
+	var invite = new(InviteRevoke)
+	if err := context.Bind(invite); err != nil {
+		return nil, fmt.Errorf("could not bind invite, %v", err)
+	}
+	if err := context.Validate(invite); err != nil {
+		return nil, fmt.Errorf("could not validate invite, %v", err)
+	}
+	return invite, nil
+}
+
+func bindInviteJoinDTO(context echo.Context) (*InviteJoin, error) {
+	var join = new(InviteJoin)
+	if err := context.Bind(join); err != nil {
+		return nil, fmt.Errorf("could not bind join, %v", err)
+	}
+	if err := context.Validate(join); err != nil {
+		return nil, fmt.Errorf("could not validate join, %v", err)
+	}
+	return join, nil
+}
+
+func mapToInvite(invite *core.Invite) *Invite {
+	return &Invite{Code: invite.Code, LobbyId: invite.LobbyId, CreatedAt: invite.CreatedAt, ExpiresAt: invite.ExpiresAt, MaxUses: invite.MaxUses, Uses: invite.Uses}
+}
+
+func mapToInvites(coreInvites []*core.Invite) []*Invite {
+	invites := make([]*Invite, len(coreInvites))
+	for index, invite := range coreInvites {
+		invites[index] = mapToInvite(invite)
+	}
+	return invites
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinWithInvite_Successfully(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	invite, err := server.core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 1)
+	assert.Nil(t, err)
+
+	playerId := uuid.New()
+	req := server.newJSONRequest(t, http.MethodPost, "/join/"+invite.Code, playerId, `{"player_id":"`+playerId.String()+`","name":"Invited"}`)
+	assert.Equal(t, http.StatusCreated, server.do(t, req))
+
+	player, err := server.core.GetPlayer(newTestContext(), playerId)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.ID, player.LobbyId)
+
+	otherId := uuid.New()
+	req = server.newJSONRequest(t, http.MethodPost, "/join/"+invite.Code, otherId, `{"player_id":"`+otherId.String()+`","name":"Other"}`)
+	assert.Equal(t, http.StatusGone, server.do(t, req))
+}
+
+func TestJoinWithInvite_AsOtherPlayer(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	invite, err := server.core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 0)
+	assert.Nil(t, err)
+
+	req := server.newJSONRequest(t, http.MethodPost, "/join/"+invite.Code, uuid.New(), `{"player_id":"`+uuid.NewString()+`","name":"Invited"}`)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+}
+
+func TestRevokeInvite_Successfully(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/invite", player.ID, `{}`)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/invite", lobby.Owner.ID, `{"expires_in_seconds":60}`)
+	assert.Equal(t, http.StatusCreated, server.do(t, req))
+
+	invites, err := server.core.GetInvites(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Len(t, invites, 1)
+	assert.NotNil(t, invites[0].ExpiresAt)
+
+	req = server.newRequest(t, http.MethodDelete, "/lobby/"+lobby.ID.String()+"/invite/"+invites[0].Code, lobby.Owner.ID)
+	assert.Equal(t, http.StatusNoContent, server.do(t, req))
+
+	playerId := uuid.New()
+	req = server.newJSONRequest(t, http.MethodPost, "/join/"+invites[0].Code, playerId, `{"player_id":"`+playerId.String()+`","name":"Invited"}`)
+	assert.Equal(t, http.StatusNotFound, server.do(t, req))
+}
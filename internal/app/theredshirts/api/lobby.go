@@ -6,6 +6,7 @@ import (
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -13,59 +14,27 @@ import (
 const (
 	lobby_root_path          = "/lobby"
 	lobby_update_status_path = "/status"
+	lobby_player_path        = "/player"
+	lobby_ban_path           = "/ban"
+	lobby_owner_path         = "/owner"
 	lobby_id_param           = "lobbyId"
-	lobby_owner_id_header    = "owner"
+	next_cursor_header       = "X-Next-Cursor"
 )
 
 type (
-	LobbyCreate struct {
-		ID                  uuid.UUID              `param:"lobbyId" validate:"required"`
-		Name                string                 `json:"name" validate:"required"`
-		Owner               *Player                `json:"owner" validate:"required"`
-		Password            string                 `json:"password"`
-		Difficulty          int                    `json:"difficulty" validate:"required"`
-		MissionLength       int                    `json:"mission_length" validate:"required"`
-		NumberOfCrewMembers int                    `json:"number_of_crew_members" validate:"required"`
-		MaxPlayers          int                    `json:"max_players" validate:"required"`
-		ExpansionPacks      []string               `json:"expansion_packs"`
-		Payload             map[string]interface{} `json:"payload"`
-	}
-
-	LobbyUpdate struct {
-		ID                  uuid.UUID              `param:"lobbyId" validate:"required"`
-		Name                string                 `json:"name" validate:"required"`
-		Status              string                 `json:"status" validate:"required"`
-		Password            string                 `json:"password"`
-		Difficulty          int                    `json:"difficulty" validate:"required"`
-		MissionLength       int                    `json:"mission_length" validate:"required"`
-		NumberOfCrewMembers int                    `json:"number_of_crew_members" validate:"required"`
-		MaxPlayers          int                    `json:"max_players" validate:"required"`
-		ExpansionPacks      []string               `json:"expansion_packs"`
-		Payload             map[string]interface{} `json:"payload"`
-	}
-
-	LobbyUpdateStatus struct {
-		ID     uuid.UUID `param:"lobbyId" validate:"required"`
-		Status string    `json:"status" validate:"required"`
-	}
+	LobbyCreate       = lobbyapi.LobbyCreate
+	LobbyUpdate       = lobbyapi.LobbyUpdate
+	AfkPolicy         = lobbyapi.AfkPolicy
+	LobbyUpdateStatus = lobbyapi.LobbyUpdateStatus
+	LobbyList         = lobbyapi.LobbyList
+	PlayerKick        = lobbyapi.PlayerKick
+	OwnerTransfer     = lobbyapi.OwnerTransfer
+	Ban               = lobbyapi.Ban
+	Lobby             = lobbyapi.Lobby
 
 	LobbyDelete struct {
 		ID uuid.UUID `param:"lobbyId" validate:"required"`
 	}
-
-	Lobby struct {
-		ID                  uuid.UUID              `json:"id"`
-		Name                string                 `json:"name"`
-		Status              string                 `json:"status"`
-		Owner               *Player                `json:"owner"`
-		Difficulty          int                    `json:"difficulty"`
-		MissionLength       int                    `json:"mission_length"`
-		NumberOfCrewMembers int                    `json:"number_of_crew_members" `
-		MaxPlayers          int                    `json:"max_players" `
-		ExpansionPacks      []string               `json:"expansion_packs" `
-		Players             []*Player              `json:"players"`
-		Payload             map[string]interface{} `json:"payload"`
-	}
 )
 
 func initLobbyInterface(group *echo.Group, api *EchoApi) {
@@ -76,6 +45,9 @@ func initLobbyInterface(group *echo.Group, api *EchoApi) {
 	group.PATCH("/:"+lobby_id_param, api.updateLobby)
 	group.DELETE("/:"+lobby_id_param, api.deleteLobby)
 	group.PATCH("/:"+lobby_id_param+lobby_update_status_path, api.updateStatusLobby)
+	group.DELETE("/:"+lobby_id_param+lobby_player_path+"/:"+player_id_param, api.kickPlayer)
+	group.GET("/:"+lobby_id_param+lobby_ban_path, api.getBans)
+	group.POST("/:"+lobby_id_param+lobby_owner_path, api.transferOwnership)
 }
 
 func (api *EchoApi) createLobbyId(context echo.Context) error {
@@ -96,12 +68,17 @@ func (api *EchoApi) createLobby(context echo.Context) error {
 		logger.Warnf("Error while binding lobby: %v", err)
 		return echo.ErrBadRequest
 	}
+
+	if err := checkAuthenticatedPlayer(customContext, lobby.Owner.ID); err != nil {
+		logger.Warnf("Player is not allowed to create lobby for owner: %v", err)
+		return echo.ErrForbidden
+	}
+
 	coreLobby := mapLobbyCreateToCoreLobby(lobby)
 	err = api.core.CreateLobby(customContext, coreLobby)
 
 	if err != nil {
-		logger.Warnf("Error while creating lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "creating lobby")
 	}
 
 	return context.NoContent(http.StatusCreated)
@@ -112,18 +89,23 @@ func (api *EchoApi) updateLobby(context echo.Context) error {
 	logger := customContext.Logger
 	logger.Debug("Update lobby")
 
-	lobby, ownerId, err := bindLobbyUpdateDTO(context)
+	lobby, err := bindLobbyUpdateDTO(context)
 	if err != nil {
 		logger.Warnf("Error while binding lobby: %v", err)
 		return echo.ErrBadRequest
 	}
 
-	coreLobby := mapLobbyUpdateToCoreLobby(lobby, ownerId)
-	err = api.core.UpdateLobby(customContext, coreLobby, ownerId)
+	version, err := getIfMatchVersion(context)
+	if err != nil {
+		return ifMatchError(logger, err)
+	}
+
+	coreLobby := mapLobbyUpdateToCoreLobby(lobby, customContext.PlayerId)
+	coreLobby.Version = version
+	err = api.core.UpdateLobby(customContext, coreLobby, customContext.PlayerId)
 
 	if err != nil {
-		logger.Warnf("Error while creating lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "updating lobby")
 	}
 
 	return context.NoContent(http.StatusOK)
@@ -134,19 +116,24 @@ func (api *EchoApi) updateStatusLobby(context echo.Context) error {
 	logger := customContext.Logger
 	logger.Debug("Update status of lobby")
 
-	lobby, ownerId, err := bindLobbyUpdateStatusDTO(context)
+	lobby, err := bindLobbyUpdateStatusDTO(context)
 
 	if err != nil {
 		logger.Warnf("Error while binding lobby: %v", err)
 		return echo.ErrBadRequest
 	}
 
-	coreLobby := mapLobbyUpdateStatusToCoreLobby(lobby, ownerId)
-	err = api.core.UpdateLobbyStatus(customContext, coreLobby, ownerId)
+	version, err := getIfMatchVersion(context)
+	if err != nil {
+		return ifMatchError(logger, err)
+	}
+
+	coreLobby := mapLobbyUpdateStatusToCoreLobby(lobby, customContext.PlayerId)
+	coreLobby.Version = version
+	err = api.core.UpdateLobbyStatus(customContext, coreLobby, customContext.PlayerId)
 
 	if err != nil {
-		logger.Warnf("Error while creating lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "updating status of lobby")
 	}
 
 	return context.NoContent(http.StatusOK)
@@ -157,15 +144,68 @@ func (api *EchoApi) deleteLobby(context echo.Context) error {
 	logger := customContext.Logger
 	logger.Debug("Delete lobby")
 
-	lobby, ownerId, err := bindLobbyDeleteDTO(context)
+	lobby, err := bindLobbyDeleteDTO(context)
 	if err != nil {
 		logger.Warnf("Error while binding lobby id: %v", err)
 		return echo.ErrBadRequest
 	}
 
-	if err := api.core.DeleteLobby(customContext, lobby.ID, ownerId); err != nil {
-		logger.Warnf("Error while loading lobby: %v", err)
-		return echo.ErrInternalServerError
+	if err := api.core.DeleteLobby(customContext, lobby.ID, customContext.PlayerId); err != nil {
+		return coreError(logger, err, "deleting lobby")
+	}
+
+	return context.NoContent(http.StatusOK)
+}
+
+func (api *EchoApi) kickPlayer(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Kick player")
+
+	kick, err := bindPlayerKickDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding player to kick: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := api.core.KickPlayer(customContext, kick.LobbyId, kick.ID, customContext.PlayerId, kick.Reason, kick.Ban); err != nil {
+		return coreError(logger, err, "kicking player")
+	}
+
+	return context.NoContent(http.StatusNoContent)
+}
+
+func (api *EchoApi) getBans(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Get bans of lobby")
+
+	lobbyId, err := getLobbyId(context)
+	if err != nil {
+		logger.Warnf("Error while binding lobby id: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	bans, err := api.core.GetBans(customContext, lobbyId, customContext.PlayerId)
+	if err != nil {
+		return coreError(logger, err, "loading bans")
+	}
+	return context.JSON(http.StatusOK, mapToBans(bans))
+}
+
+func (api *EchoApi) transferOwnership(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Transfer ownership of lobby")
+
+	transfer, err := bindOwnerTransferDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding owner transfer: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := api.core.TransferOwnership(customContext, transfer.LobbyId, transfer.PlayerId, customContext.PlayerId); err != nil {
+		return coreError(logger, err, "transfering ownership")
 	}
 
 	return context.NoContent(http.StatusOK)
@@ -176,10 +216,18 @@ func (api *EchoApi) getAllLobbies(context echo.Context) error {
 	logger := customContext.Logger
 	logger.Debug("Get all lobbies")
 
-	lobbies, err := api.core.GetLobbies(customContext)
+	lobbyList, err := bindLobbyListDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding lobby filter: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	lobbies, nextCursor, err := api.core.GetLobbies(customContext, mapLobbyListToLobbyFilter(lobbyList))
 	if err != nil {
-		logger.Warnf("Error while loading lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "loading lobbies")
+	}
+	if nextCursor != "" {
+		context.Response().Header().Set(next_cursor_header, nextCursor)
 	}
 	return context.JSON(http.StatusOK, mapToLobbies(lobbies))
 }
@@ -197,12 +245,9 @@ func (api *EchoApi) getLobby(context echo.Context) error {
 
 	lobby, err := api.core.GetLobby(customContext, lobbyId)
 	if err != nil {
-		logger.Warnf("Error while loading lobby: %v", err)
-		return echo.ErrInternalServerError
-	}
-	if lobby == nil {
-		return context.NoContent(http.StatusNoContent)
+		return coreError(logger, err, "loading lobby")
 	}
+	setETag(context, lobby.Version)
 	return context.JSON(http.StatusOK, mapToLobby(lobby))
 }
 
@@ -217,49 +262,70 @@ func bindLobbyCreationDTO(context echo.Context) (*LobbyCreate, error) {
 	return lobby, nil
 }
 
-func bindLobbyUpdateDTO(context echo.Context) (*LobbyUpdate, uuid.UUID, error) {
+func bindLobbyUpdateDTO(context echo.Context) (*LobbyUpdate, error) {
 	var lobby = new(LobbyUpdate)
 	if err := context.Bind(lobby); err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not bind lobby, %v", err)
+		return nil, fmt.Errorf("could not bind lobby, %v", err)
 	}
 	if err := context.Validate(lobby); err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not validate lobby, %v", err)
-	}
-	ownerId, err := getOwnerId(context)
-	if err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not bind ownerId, %v", err)
+		return nil, fmt.Errorf("could not validate lobby, %v", err)
 	}
-	return lobby, ownerId, nil
+	return lobby, nil
 }
 
-func bindLobbyUpdateStatusDTO(context echo.Context) (*LobbyUpdateStatus, uuid.UUID, error) {
+func bindLobbyUpdateStatusDTO(context echo.Context) (*LobbyUpdateStatus, error) {
 	var lobby = new(LobbyUpdateStatus)
 	if err := context.Bind(lobby); err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not bind lobby, %v", err)
+		return nil, fmt.Errorf("could not bind lobby, %v", err)
 	}
 	if err := context.Validate(lobby); err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not validate lobby, %v", err)
+		return nil, fmt.Errorf("could not validate lobby, %v", err)
 	}
-	ownerId, err := getOwnerId(context)
-	if err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not bind ownerId, %v", err)
+	return lobby, nil
+}
+
+func bindLobbyListDTO(context echo.Context) (*LobbyList, error) {
+	var lobbyList = new(LobbyList)
+	if err := context.Bind(lobbyList); err != nil {
+		return nil, fmt.Errorf("could not bind lobby filter, %v", err)
+	}
+	if err := context.Validate(lobbyList); err != nil {
+		return nil, fmt.Errorf("could not validate lobby filter, %v", err)
 	}
-	return lobby, ownerId, nil
+	return lobbyList, nil
 }
 
-func bindLobbyDeleteDTO(context echo.Context) (*LobbyDelete, uuid.UUID, error) {
+func bindLobbyDeleteDTO(context echo.Context) (*LobbyDelete, error) {
 	var lobby = new(LobbyDelete)
 	if err := context.Bind(lobby); err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not bind lobby, %v", err)
+		return nil, fmt.Errorf("could not bind lobby, %v", err)
 	}
 	if err := context.Validate(lobby); err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not validate lobby, %v", err)
+		return nil, fmt.Errorf("could not validate lobby, %v", err)
 	}
-	ownerId, err := getOwnerId(context)
-	if err != nil {
-		return nil, uuid.Nil, fmt.Errorf("could not bind ownerId, %v", err)
+	return lobby, nil
+}
+
+func bindPlayerKickDTO(context echo.Context) (*PlayerKick, error) {
+	var kick = new(PlayerKick)
+	if err := context.Bind(kick); err != nil {
+		return nil, fmt.Errorf("could not bind kick, %v", err)
+	}
+	if err := context.Validate(kick); err != nil {
+		return nil, fmt.Errorf("could not validate kick, %v", err)
 	}
-	return lobby, ownerId, nil
+	return kick, nil
+}
+
+func bindOwnerTransferDTO(context echo.Context) (*OwnerTransfer, error) {
+	var transfer = new(OwnerTransfer)
+	if err := context.Bind(transfer); err != nil {
+		return nil, fmt.Errorf("could not bind owner transfer, %v", err)
+	}
+	if err := context.Validate(transfer); err != nil {
+		return nil, fmt.Errorf("could not validate owner transfer, %v", err)
+	}
+	return transfer, nil
 }
 
 func getLobbyId(context echo.Context) (uuid.UUID, error) {
@@ -270,31 +336,32 @@ func getLobbyId(context echo.Context) (uuid.UUID, error) {
 	return lobbyId, nil
 }
 
-func getOwnerId(context echo.Context) (uuid.UUID, error) {
-	ownerId, err := uuid.Parse(context.Request().Header.Get(lobby_owner_id_header))
-	if err != nil {
-		return uuid.Nil, fmt.Errorf("error while binding ownerId: %v", err)
-	}
-	return ownerId, nil
-}
-
 func mapLobbyCreateToCoreLobby(lobby *LobbyCreate) *core.Lobby {
-	return &core.Lobby{ID: lobby.ID, Name: lobby.Name, Owner: mapToCorePlayer(lobby.Owner), Password: lobby.Password, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Payload: lobby.Payload}
+	return &core.Lobby{ID: lobby.ID, Name: lobby.Name, Owner: mapToCorePlayer(lobby.Owner), Password: lobby.Password, Visibility: lobby.Visibility, AfkPolicies: mapToCoreAfkPolicies(lobby.AfkPolicies), Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Payload: lobby.Payload}
 }
 
 func mapLobbyUpdateToCoreLobby(lobby *LobbyUpdate, ownerId uuid.UUID) *core.Lobby {
-	return &core.Lobby{ID: lobby.ID, Status: lobby.Status, Name: lobby.Name, Owner: &core.Player{ID: ownerId}, Password: lobby.Password, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Payload: lobby.Payload}
+	return &core.Lobby{ID: lobby.ID, Name: lobby.Name, Status: lobby.Status, Owner: &core.Player{ID: ownerId}, Password: lobby.Password, Visibility: lobby.Visibility, AfkPolicies: mapToCoreAfkPolicies(lobby.AfkPolicies), Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Payload: lobby.Payload}
 }
 
 func mapLobbyUpdateStatusToCoreLobby(lobby *LobbyUpdateStatus, ownerId uuid.UUID) *core.Lobby {
 	return &core.Lobby{ID: lobby.ID, Status: lobby.Status, Owner: &core.Player{ID: ownerId}}
 }
 
+func mapLobbyListToLobbyFilter(lobbyList *LobbyList) *core.LobbyFilter {
+	var passwordProtected *bool
+	if lobbyList.PasswordProtected != "" {
+		protected := lobbyList.PasswordProtected == "true"
+		passwordProtected = &protected
+	}
+	return &core.LobbyFilter{Status: lobbyList.Status, Difficulty: lobbyList.Difficulty, MissionLength: lobbyList.MissionLength, ExpansionPacks: lobbyList.ExpansionPacks, FreeSlots: lobbyList.FreeSlots, PasswordProtected: passwordProtected, Sort: lobbyList.Sort, Descending: lobbyList.Order == "desc", Limit: lobbyList.Limit, Cursor: lobbyList.Cursor}
+}
+
 func mapToLobby(lobby *core.Lobby) *Lobby {
 	if lobby == nil {
 		return nil
 	}
-	return &Lobby{ID: lobby.ID, Status: lobby.Status, Name: lobby.Name, Owner: mapToPlayer(lobby.Owner), Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Players: mapToPlayers(lobby.Players), Payload: lobby.Payload}
+	return &Lobby{ID: lobby.ID, Status: lobby.Status, Visibility: lobby.Visibility, AfkPolicies: mapToAfkPolicies(lobby.AfkPolicies), PasswordProtected: lobby.Password != "", Name: lobby.Name, Owner: mapToPlayer(lobby.Owner), Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Players: mapToPlayers(lobby.Players), Payload: lobby.Payload}
 }
 
 func mapToLobbies(coreLobbies []*core.Lobby) []*Lobby {
@@ -304,3 +371,36 @@ func mapToLobbies(coreLobbies []*core.Lobby) []*Lobby {
 	}
 	return lobbies
 }
+
+func mapToBans(coreBans []*core.Ban) []*Ban {
+	bans := make([]*Ban, len(coreBans))
+	for index, ban := range coreBans {
+		bans[index] = &Ban{PlayerId: ban.PlayerId, Reason: ban.Reason, CreatedAt: ban.CreatedAt}
+	}
+	return bans
+}
+
+func mapToCoreAfkPolicies(policies map[string]*AfkPolicy) map[string]*core.AfkPolicy {
+	if policies == nil {
+		return nil
+	}
+	corePolicies := make(map[string]*core.AfkPolicy, len(policies))
+	for status, policy := range policies {
+		if policy == nil {
+			continue
+		}
+		corePolicies[status] = &core.AfkPolicy{WarningSeconds: policy.WarningSeconds, RemovalSeconds: policy.RemovalSeconds, ReservedSeatSeconds: policy.ReservedSeatSeconds}
+	}
+	return corePolicies
+}
+
+func mapToAfkPolicies(corePolicies map[string]*core.AfkPolicy) map[string]*AfkPolicy {
+	if len(corePolicies) == 0 {
+		return nil
+	}
+	policies := make(map[string]*AfkPolicy, len(corePolicies))
+	for status, policy := range corePolicies {
+		policies[status] = &AfkPolicy{WarningSeconds: policy.WarningSeconds, RemovalSeconds: policy.RemovalSeconds, ReservedSeatSeconds: policy.ReservedSeatSeconds}
+	}
+	return policies
+}
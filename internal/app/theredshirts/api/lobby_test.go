@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKickPlayer_Ban(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodDelete, "/lobby/"+lobby.ID.String()+"/player/"+player.ID.String(), lobby.Owner.ID, `{"reason":"cheating","ban":true}`)
+	assert.Equal(t, http.StatusNoContent, server.do(t, req))
+
+	_, err := server.core.CreatePlayer(newTestContext(), player, "")
+	assert.ErrorIs(t, err, core.ErrPlayerBanned)
+
+	req = server.newJSONRequest(t, http.MethodPut, "/player/"+player.ID.String(), player.ID, `{"name":"Player","lobby_id":"`+lobby.ID.String()+`"}`)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+}
+
+func TestKickPlayer_NotOwner(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newRequest(t, http.MethodDelete, "/lobby/"+lobby.ID.String()+"/player/"+lobby.Owner.ID.String(), player.ID)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+
+	req = server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String()+"/ban", player.ID)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+}
+
+func TestTransferOwnership_Successfully(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/owner", lobby.Owner.ID, `{"player_id":"`+player.ID.String()+`"}`)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+
+	foundLobby, err := server.core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, foundLobby.Owner.ID)
+}
+
+func TestTransferOwnership_PlayerNotInLobby(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newJSONRequest(t, http.MethodPost, "/lobby/"+lobby.ID.String()+"/owner", lobby.Owner.ID, `{"player_id":"`+uuid.NewString()+`"}`)
+	assert.Equal(t, http.StatusNotFound, server.do(t, req))
+}
+
+func TestUpdateStatusLobby_InvalidTransition(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String()+"/status", lobby.Owner.ID, `{"status":"FINISHED"}`)
+	assert.Equal(t, http.StatusConflict, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String()+"/status", lobby.Owner.ID, `{"status":"STARTING"}`)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+}
+
+func TestGetAllLobbies_Pagination(t *testing.T) {
+	server := newTestServer(t)
+	createTestLobby(t, server)
+	createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, "/lobby?limit=1&sort=name", uuid.New())
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	cursor := resp.Header.Get(next_cursor_header)
+	assert.NotEmpty(t, cursor)
+
+	req = server.newRequest(t, http.MethodGet, "/lobby?limit=1&sort=name&cursor="+cursor, uuid.New())
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(next_cursor_header))
+
+	req = server.newRequest(t, http.MethodGet, "/lobby?cursor=invalid", uuid.New())
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	textCursor := base64.RawURLEncoding.EncodeToString([]byte(`{"value":"Enterprise","id":"` + uuid.NewString() + `"}`))
+	req = server.newRequest(t, http.MethodGet, "/lobby?sort=difficulty&cursor="+textCursor, uuid.New())
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	req = server.newRequest(t, http.MethodGet, "/lobby?sort=owner", uuid.New())
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+}
+
+func TestUpdateLobby_IfMatch(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String(), lobby.Owner.ID)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	body := `{"name":"Renamed Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4}`
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	req.Header.Set("If-Match", `"2"`)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	req.Header.Set("If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String()+"/status", lobby.Owner.ID, `{"status":"ABORTED"}`)
+	req.Header.Set("If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	req.Header.Set("If-Match", "no-version")
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	req.Header.Set("If-Match", `W/"3"`)
+	assert.Equal(t, http.StatusPreconditionFailed, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	req.Header.Set("If-Match", `"3"`)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+}
+
+func TestGetLobby_ETagChangesWithPlayers(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	getETag := func() string {
+		req := server.newRequest(t, http.MethodGet, "/lobby/"+lobby.ID.String(), lobby.Owner.ID)
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return resp.Header.Get("ETag")
+	}
+
+	created := getETag()
+	player := joinTestPlayer(t, server, lobby.ID)
+	joined := getETag()
+	assert.NotEqual(t, created, joined)
+
+	assert.Nil(t, server.core.DeletePlayer(newTestContext(), player.ID))
+	assert.NotEqual(t, joined, getETag())
+}
+
+func TestUpdateLobby_DeprecatedStatus(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	body := `{"name":"Renamed Lobby","status":"OPEN","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4}`
+	req := server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+
+	body = `{"name":"Renamed Lobby","status":"ABORTED","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4}`
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	foundLobby, err := server.core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "OPEN", foundLobby.Status)
+}
+
+func TestUpdateLobby_AfkPolicies(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	body := `{"name":"Some Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4,"afk_policies":{"PLAYING":{"removal_seconds":60,"reserved_seat_seconds":120}}}`
+	req := server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+
+	foundLobby, err := server.core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*core.AfkPolicy{"PLAYING": {RemovalSeconds: 60, ReservedSeatSeconds: 120}}, foundLobby.AfkPolicies)
+
+	body = `{"name":"Some Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4,"afk_policies":{"WAITING":{"removal_seconds":60}}}`
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	body = `{"name":"Some Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4,"afk_policies":{"OPEN":{"warning_seconds":60}}}`
+	req = server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusUnprocessableEntity, server.do(t, req))
+}
+
+func TestUpdateLobby_NullAfkPolicy(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	body := `{"name":"Some Lobby","difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4,"afk_policies":{"OPEN":null}}`
+	req := server.newJSONRequest(t, http.MethodPatch, "/lobby/"+lobby.ID.String(), lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+}
+
+func TestCreateLobby_NullAfkPolicy(t *testing.T) {
+	server := newTestServer(t)
+	lobbyId := uuid.New()
+	ownerId := uuid.New()
+
+	body := `{"name":"Some Lobby","owner":{"id":"` + ownerId.String() + `","name":"Owner"},"difficulty":1,"mission_length":1,"number_of_crew_members":1,"max_players":4,"afk_policies":{"PLAYING":null}}`
+	req := server.newJSONRequest(t, http.MethodPut, "/lobby/"+lobbyId.String(), ownerId, body)
+	assert.Equal(t, http.StatusBadRequest, server.do(t, req))
+
+	_, err := server.core.GetLobby(newTestContext(), lobbyId)
+	assert.ErrorIs(t, err, core.ErrLobbyNotFound)
+}
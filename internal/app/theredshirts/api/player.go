@@ -1,12 +1,12 @@
 package api
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -14,49 +14,35 @@ import (
 const (
 	player_root_path = "/player"
 	refresh_path     = "/last-refresh"
+	ready_path       = "/ready"
+	reconnect_path   = "/reconnect"
 	player_id_param  = "playerId"
 )
 
 type (
-	PlayerCreate struct {
-		ID        uuid.UUID              `param:"playerId" validate:"required"`
-		Name      string                 `json:"name" validate:"required"`
-		LobbyId   uuid.UUID              `json:"lobby_id" validate:"required"`
-		Password  string                 `json:"password"`
-		Spectator bool                   `json:"spectator"`
-		Payload   map[string]interface{} `json:"payload"`
-	}
-
-	PlayerUpdate struct {
-		ID        uuid.UUID              `param:"playerId" validate:"required"`
-		Name      string                 `json:"name" validate:"required"`
-		Spectator bool                   `json:"spectator"`
-		Payload   map[string]interface{} `json:"payload"`
+	PlayerCreate   = lobbyapi.PlayerCreate
+	PlayerUpdate   = lobbyapi.PlayerUpdate
+	PlayerReady    = lobbyapi.PlayerReady
+	ReconnectToken = lobbyapi.ReconnectToken
+	Player         = lobbyapi.Player
+	SimplePlayer   = lobbyapi.SimplePlayer
+
+	PlayerReconnect struct {
+		ID             uuid.UUID `param:"playerId" validate:"required"`
+		ReconnectToken string    `json:"reconnect_token" validate:"required"`
 	}
 
 	PlayerId struct {
 		ID uuid.UUID `param:"playerId" validate:"required"`
 	}
-
-	Player struct {
-		ID        uuid.UUID              `json:"id" validate:"required"`
-		Name      string                 `json:"name" validate:"required"`
-		Spectator bool                   `json:"spectator"`
-		Payload   map[string]interface{} `json:"payload"`
-	}
-
-	SimplePlayer struct {
-		ID        uuid.UUID `json:"id"`
-		Name      string    `json:"name"`
-		Spectator bool      `json:"spectator"`
-		LobbyId   uuid.UUID `json:"lobby_id"`
-	}
 )
 
 func initPlayerInterface(group *echo.Group, api *EchoApi) {
 	group.PUT("/:"+player_id_param, api.createPlayer)
 	group.PATCH("/:"+player_id_param, api.updatePlayer)
 	group.PATCH("/:"+player_id_param+refresh_path, api.updateLastRefreshPlayer)
+	group.PUT("/:"+player_id_param+ready_path, api.updateReadyPlayer)
+	group.POST("/:"+player_id_param+reconnect_path, api.reconnectPlayer)
 	group.GET("/:"+player_id_param, api.getPlayer)
 	group.DELETE("/:"+player_id_param, api.deletePlayer)
 }
@@ -72,22 +58,42 @@ func (api *EchoApi) createPlayer(context echo.Context) error {
 		return echo.ErrBadRequest
 	}
 
-	err = api.core.CreatePlayer(customContext, mapCreatePlayerToPlayer(createPlayer), createPlayer.Password)
+	if err := checkAuthenticatedPlayer(customContext, createPlayer.ID); err != nil {
+		logger.Warnf("Player is not allowed to join as other player: %v", err)
+		return echo.ErrForbidden
+	}
+
+	token, err := api.core.CreatePlayer(customContext, mapCreatePlayerToPlayer(createPlayer), createPlayer.Password)
 
 	if err != nil {
-		if errors.Is(err, core.ErrWrongLobbyPassword) {
-			logger.Infof("Player enterd wrong lobby password: %v", err)
-			return echo.ErrUnauthorized
-		}
-		if errors.Is(err, core.ErrLobbyFull) {
-			logger.Infof("Lobby is full. player cant change state: %v", err)
-			return echo.ErrConflict
-		}
-		logger.Warnf("Error while joining lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "joining lobby")
 	}
 
-	return context.NoContent(http.StatusCreated)
+	return context.JSON(http.StatusCreated, &ReconnectToken{ReconnectToken: token})
+}
+
+func (api *EchoApi) reconnectPlayer(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Reconnect player")
+
+	reconnect, err := bindPlayerReconnectDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding player to reconnect: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := checkAuthenticatedPlayer(customContext, reconnect.ID); err != nil {
+		logger.Warnf("Player is not allowed to reconnect as other player: %v", err)
+		return echo.ErrForbidden
+	}
+
+	token, err := api.core.ReconnectPlayer(customContext, reconnect.ID, reconnect.ReconnectToken)
+	if err != nil {
+		return coreError(logger, err, "reconnecting player")
+	}
+
+	return context.JSON(http.StatusOK, &ReconnectToken{ReconnectToken: token})
 }
 
 func (api *EchoApi) updatePlayer(context echo.Context) error {
@@ -101,20 +107,17 @@ func (api *EchoApi) updatePlayer(context echo.Context) error {
 		return echo.ErrBadRequest
 	}
 
-	ownerId, err := getOwnerId(context)
+	version, err := getIfMatchVersion(context)
 	if err != nil {
-		return echo.ErrBadRequest
+		return ifMatchError(logger, err)
 	}
 
-	err = api.core.UpdatePlayer(customContext, mapUpdatePlayerToCorePlayer(updatePlayer), ownerId)
+	corePlayer := mapUpdatePlayerToCorePlayer(updatePlayer)
+	corePlayer.Version = version
+	err = api.core.UpdatePlayer(customContext, corePlayer, customContext.PlayerId)
 
 	if err != nil {
-		if errors.Is(err, core.ErrLobbyFull) {
-			logger.Infof("Lobby is full. player cant change state: %v", err)
-			return echo.ErrConflict
-		}
-		logger.Warnf("Error while joining lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "updating player")
 	}
 
 	return context.NoContent(http.StatusCreated)
@@ -131,15 +134,38 @@ func (api *EchoApi) updateLastRefreshPlayer(context echo.Context) error {
 		return echo.ErrBadRequest
 	}
 
+	if err := checkAuthenticatedPlayer(customContext, updatePlayer.ID); err != nil {
+		logger.Warnf("Player is not allowed to refresh other player: %v", err)
+		return echo.ErrForbidden
+	}
+
 	err = api.core.UpdatePlayerLastRefresh(customContext, updatePlayer.ID)
 
 	if err != nil {
-		if errors.Is(err, core.ErrWrongLobbyPassword) {
-			logger.Infof("Player enterd wrong lobby password: %v", err)
-			return echo.ErrUnauthorized
-		}
-		logger.Warnf("Error while joining lobby: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "updating last refresh of player")
+	}
+
+	return context.NoContent(http.StatusOK)
+}
+
+func (api *EchoApi) updateReadyPlayer(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	logger := customContext.Logger
+	logger.Debug("Update ready player")
+
+	readyPlayer, err := bindPlayerReadyDTO(context)
+	if err != nil {
+		logger.Warnf("Error while binding player to update ready: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := checkAuthenticatedPlayer(customContext, readyPlayer.ID); err != nil {
+		logger.Warnf("Player is not allowed to change ready of other player: %v", err)
+		return echo.ErrForbidden
+	}
+
+	if err := api.core.UpdatePlayerReady(customContext, readyPlayer.ID, readyPlayer.Ready); err != nil {
+		return coreError(logger, err, "updating ready of player")
 	}
 
 	return context.NoContent(http.StatusOK)
@@ -158,8 +184,11 @@ func (api *EchoApi) getPlayer(context echo.Context) error {
 
 	player, err := api.core.GetPlayer(customContext, playerId.ID)
 	if err != nil {
-		logger.Warnf("Error while getting player [%v]: %v", playerId.ID, err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "getting player")
+	}
+
+	if player != nil {
+		setETag(context, player.Version)
 	}
 
 	return context.JSON(http.StatusOK, mapToSimplePlayer(player))
@@ -176,9 +205,13 @@ func (api *EchoApi) deletePlayer(context echo.Context) error {
 		return echo.ErrBadRequest
 	}
 
+	if err := checkAuthenticatedPlayer(customContext, deletePlayer.ID); err != nil {
+		logger.Warnf("Player is not allowed to delete other player: %v", err)
+		return echo.ErrForbidden
+	}
+
 	if err = api.core.DeletePlayer(customContext, deletePlayer.ID); err != nil {
-		logger.Warnf("Error while player leaving lobyy: %v", err)
-		return echo.ErrInternalServerError
+		return coreError(logger, err, "player leaving lobby")
 	}
 
 	return context.NoContent(http.StatusNoContent)
@@ -208,6 +241,30 @@ func bindUpdatePlayerDTO(context echo.Context) (updatePlayer *PlayerUpdate, err
 	return updatePlayer, nil
 }
 
+func bindPlayerReadyDTO(context echo.Context) (readyPlayer *PlayerReady, err error) {
+	readyPlayer = new(PlayerReady)
+	if err := context.Bind(readyPlayer); err != nil {
+		return nil, fmt.Errorf("could not bind ready player, %v", err)
+	}
+	if err := context.Validate(readyPlayer); err != nil {
+		return nil, fmt.Errorf("could not validate ready player, %v", err)
+	}
+
+	return readyPlayer, nil
+}
+
+func bindPlayerReconnectDTO(context echo.Context) (reconnect *PlayerReconnect, err error) {
+	reconnect = new(PlayerReconnect)
+	if err := context.Bind(reconnect); err != nil {
+		return nil, fmt.Errorf("could not bind reconnect player, %v", err)
+	}
+	if err := context.Validate(reconnect); err != nil {
+		return nil, fmt.Errorf("could not validate reconnect player, %v", err)
+	}
+
+	return reconnect, nil
+}
+
 func bindPlayerId(context echo.Context) (player *PlayerId, err error) {
 	player = new(PlayerId)
 	if err := context.Bind(player); err != nil {
@@ -240,7 +297,7 @@ func mapToPlayer(player *core.Player) *Player {
 	if player == nil {
 		return nil
 	}
-	return &Player{ID: player.ID, Name: player.Name, Spectator: player.Spectator, Payload: player.Payload}
+	return &Player{ID: player.ID, Name: player.Name, Spectator: player.Spectator, Ready: player.Ready, Payload: player.Payload}
 }
 
 func mapToSimplePlayer(player *core.Player) *SimplePlayer {
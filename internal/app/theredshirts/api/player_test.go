@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateReadyPlayer_Successfully(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodPut, "/player/"+player.ID.String()+"/ready", player.ID, `{"ready":true}`)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+
+	foundLobby, err := server.core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	for _, foundPlayer := range mapToLobby(foundLobby).Players {
+		assert.Equal(t, foundPlayer.ID == player.ID, foundPlayer.Ready)
+	}
+}
+
+func TestUpdateReadyPlayer_OtherPlayer(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodPut, "/player/"+player.ID.String()+"/ready", lobby.Owner.ID, `{"ready":true}`)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+}
+
+func TestCreatePlayer_LockedOut(t *testing.T) {
+	t.Setenv("JOIN_MAX_FAILED_ATTEMPTS", "1")
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	playerId := uuid.New()
+	body := `{"name":"Player","lobby_id":"` + lobby.ID.String() + `","password":"wrong"}`
+
+	req := server.newJSONRequest(t, http.MethodPut, "/player/"+playerId.String(), playerId, body)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPut, "/player/"+playerId.String(), playerId, body)
+	assert.Equal(t, http.StatusTooManyRequests, server.do(t, req))
+}
+
+func TestUpdatePlayer_IfMatch(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newRequest(t, http.MethodGet, "/player/"+player.ID.String(), player.ID)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/player/"+player.ID.String(), player.ID, `{"name":"Renamed"}`)
+	req.Header.Set("If-Match", `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPatch, "/player/"+player.ID.String(), player.ID, `{"name":"Renamed"}`)
+	req.Header.Set("If-Match", `"1"`)
+	assert.Equal(t, http.StatusCreated, server.do(t, req))
+}
+
+func TestReconnectPlayer_Successfully(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	playerId := uuid.New()
+
+	req := server.newJSONRequest(t, http.MethodPut, "/player/"+playerId.String(), playerId, `{"name":"Player","lobby_id":"`+lobby.ID.String()+`"}`)
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	token := new(ReconnectToken)
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(token))
+	assert.NotEmpty(t, token.ReconnectToken)
+
+	body := `{"reconnect_token":"` + token.ReconnectToken + `"}`
+	req = server.newJSONRequest(t, http.MethodPost, "/player/"+playerId.String()+"/reconnect", lobby.Owner.ID, body)
+	assert.Equal(t, http.StatusForbidden, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPost, "/player/"+playerId.String()+"/reconnect", playerId, body)
+	assert.Equal(t, http.StatusOK, server.do(t, req))
+
+	req = server.newJSONRequest(t, http.MethodPost, "/player/"+playerId.String()+"/reconnect", playerId, body)
+	assert.Equal(t, http.StatusUnauthorized, server.doProblem(t, req).Status)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	problem_content_type = "application/problem+json"
+	problem_type_default = "about:blank"
+)
+
+type (
+	Problem = lobbyapi.Problem
+
+	coreErrorStatus struct {
+		err    error
+		status int
+	}
+)
+
+// coreErrorStatuses maps the errors of the core layer to http status codes, all other errors are internal server errors
+var coreErrorStatuses = []coreErrorStatus{
+	{err: core.ErrLobbyNotFound, status: http.StatusNotFound},
+	{err: core.ErrPlayerNotFound, status: http.StatusNotFound},
+	{err: core.ErrPlayerNotInLobby, status: http.StatusNotFound},
+	{err: core.ErrInviteNotFound, status: http.StatusNotFound},
+	{err: core.ErrReconnectTokenInvalid, status: http.StatusUnauthorized},
+	{err: core.ErrWrongLobbyPassword, status: http.StatusForbidden},
+	{err: core.ErrNotOwner, status: http.StatusForbidden},
+	{err: core.ErrPlayerBanned, status: http.StatusForbidden},
+	{err: core.ErrLobbyPrivate, status: http.StatusForbidden},
+	{err: core.ErrNotLobbyMember, status: http.StatusForbidden},
+	{err: core.ErrPlayerConflict, status: http.StatusConflict},
+	{err: core.ErrLobbyConflict, status: http.StatusConflict},
+	{err: core.ErrLobbyFull, status: http.StatusConflict},
+	{err: core.ErrLobbyPlaying, status: http.StatusConflict},
+	{err: core.ErrInvalidStatusTransition, status: http.StatusConflict},
+	{err: core.ErrNotEnoughPlayers, status: http.StatusConflict},
+	{err: core.ErrPlayersNotReady, status: http.StatusConflict},
+	{err: core.ErrIdempotencyKeyInUse, status: http.StatusConflict},
+	{err: core.ErrInviteExpired, status: http.StatusGone},
+	{err: core.ErrReconnectTokenExpired, status: http.StatusGone},
+	{err: core.ErrVersionMismatch, status: http.StatusPreconditionFailed},
+	{err: core.ErrInvalidState, status: http.StatusUnprocessableEntity},
+	{err: core.ErrIdempotencyKeyMismatch, status: http.StatusUnprocessableEntity},
+	{err: core.ErrTooManyAttempts, status: http.StatusTooManyRequests},
+	{err: core.ErrInvalidCursor, status: http.StatusBadRequest},
+	{err: core.ErrStatusNotUpdatable, status: http.StatusBadRequest},
+}
+
+// coreError logs the error of the core layer and maps it to a http error. Only the message of the known error is sent to the client
+func coreError(logger *log.Entry, err error, action string) error {
+	for _, errorStatus := range coreErrorStatuses {
+		if errors.Is(err, errorStatus.err) {
+			logger.Infof("Request rejected while %s: %v", action, err)
+			return echo.NewHTTPError(errorStatus.status, errorStatus.err.Error()).SetInternal(err)
+		}
+	}
+	logger.Warnf("Error while %s: %v", action, err)
+	return echo.ErrInternalServerError.WithInternal(err)
+}
+
+// problemErrorHandler writes all errors as problem json
+func problemErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	httpError, ok := err.(*echo.HTTPError)
+	if !ok {
+		httpError = echo.ErrInternalServerError
+	}
+
+	problem := &Problem{Type: problem_type_default, Title: http.StatusText(httpError.Code), Status: httpError.Code, Instance: c.Request().URL.Path}
+	if message, ok := httpError.Message.(string); ok && message != problem.Title {
+		problem.Detail = message
+	}
+	if customContext, ok := c.Get(context_key).(*util.Context); ok {
+		problem.CorrelationId = customContext.CorrelationId
+	} else {
+		problem.CorrelationId = c.Request().Header.Get(correlation_id_header)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(httpError.Code)
+	} else {
+		c.Response().Header().Set(echo.HeaderContentType, problem_content_type)
+		c.Response().WriteHeader(httpError.Code)
+		err = c.Echo().JSONSerializer.Serialize(c, problem, "")
+	}
+	if err != nil {
+		log.Warnf("Error while writing problem: %v", err)
+	}
+}
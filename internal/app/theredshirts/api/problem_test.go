@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func (server *testServer) doProblem(t *testing.T, req *http.Request) *Problem {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("error while sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+	problem := new(Problem)
+	if err := json.NewDecoder(resp.Body).Decode(problem); err != nil {
+		t.Fatalf("error while decoding problem: %v", err)
+	}
+	assert.Equal(t, resp.StatusCode, problem.Status)
+	return problem
+}
+
+func TestProblem_LobbyNotFound(t *testing.T) {
+	server := newTestServer(t)
+	lobbyId := uuid.NewString()
+
+	req := server.newRequest(t, http.MethodGet, "/lobby/"+lobbyId, uuid.New())
+	req.Header.Set("X-Correlation-ID", "correlation")
+	problem := server.doProblem(t, req)
+	assert.Equal(t, &Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "lobby not found", Instance: "/lobby/" + lobbyId, CorrelationId: "correlation"}, problem)
+}
+
+func TestProblem_PlayerConflict(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+	player := joinTestPlayer(t, server, lobby.ID)
+
+	req := server.newJSONRequest(t, http.MethodPut, "/player/"+player.ID.String(), player.ID, `{"name":"Other Name","lobby_id":"`+lobby.ID.String()+`"}`)
+	problem := server.doProblem(t, req)
+	assert.Equal(t, http.StatusConflict, problem.Status)
+	assert.Equal(t, "player already exists with different parameters", problem.Detail)
+}
+
+func TestProblem_InvalidState(t *testing.T) {
+	server := newTestServer(t)
+	lobby := createTestLobby(t, server)
+
+	req := server.newRequest(t, http.MethodDelete, "/lobby/"+lobby.ID.String()+"/player/"+lobby.Owner.ID.String(), lobby.Owner.ID)
+	problem := server.doProblem(t, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+}
+
+func TestProblem_BadRequestWithoutDetail(t *testing.T) {
+	server := newTestServer(t)
+
+	req := server.newRequest(t, http.MethodGet, "/lobby/no-uuid", uuid.New())
+	problem := server.doProblem(t, req)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Empty(t, problem.Detail)
+}
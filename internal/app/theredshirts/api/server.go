@@ -4,13 +4,25 @@ import (
 	"net/http"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
 	"github.com/labstack/echo/v4"
 )
 
-const server_root_path = "/server"
+const (
+	server_root_path  = "/server"
+	health_live_path  = "/health/live"
+	health_ready_path = "/health/ready"
+
+	health_up   = "UP"
+	health_down = "DOWN"
+)
+
+type Health = lobbyapi.Health
 
 func initServerInterface(group *echo.Group, api *EchoApi) {
 	group.GET("/ping", api.ping)
+	group.GET(health_live_path, api.checkLiveness)
+	group.GET(health_ready_path, api.checkReadiness)
 }
 
 func (api *EchoApi) ping(context echo.Context) error {
@@ -18,3 +30,33 @@ func (api *EchoApi) ping(context echo.Context) error {
 	logger.Debug("Ping server")
 	return context.NoContent(http.StatusNoContent)
 }
+
+// checkLiveness answers as long as the server is able to handle requests, dependencies are not checked
+func (api *EchoApi) checkLiveness(context echo.Context) error {
+	logger := context.Get(context_key).(*util.Context).Logger
+	logger.Debug("Check liveness")
+	return context.JSON(http.StatusOK, &Health{Status: health_up})
+}
+
+// checkReadiness is DOWN when the database or the message sink can't be reached and while the server shuts down
+func (api *EchoApi) checkReadiness(context echo.Context) error {
+	customContext := context.Get(context_key).(*util.Context)
+	customContext.Logger.Debug("Check readiness")
+
+	if api.isShuttingDown() {
+		return context.JSON(http.StatusServiceUnavailable, &Health{Status: health_down})
+	}
+
+	health := &Health{Status: health_up, Checks: make(map[string]string)}
+	for _, check := range api.core.CheckHealth(customContext) {
+		health.Checks[check.Name] = health_up
+		if check.Err != nil {
+			health.Checks[check.Name] = health_down
+			health.Status = health_down
+		}
+	}
+	if health.Status != health_up {
+		return context.JSON(http.StatusServiceUnavailable, health)
+	}
+	return context.JSON(http.StatusOK, health)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func getHealth(t *testing.T, server *testServer, path string) (int, *Health) {
+	resp, err := http.DefaultClient.Do(server.newRequest(t, http.MethodGet, server_root_path+path, uuid.New()))
+	if err != nil {
+		t.Fatalf("error while sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	health := new(Health)
+	if err := json.NewDecoder(resp.Body).Decode(health); err != nil {
+		t.Fatalf("error while parsing health: %v", err)
+	}
+	return resp.StatusCode, health
+}
+
+func TestCheckLiveness(t *testing.T) {
+	server := newTestServer(t)
+
+	status, health := getHealth(t, server, health_live_path)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, health_up, health.Status)
+}
+
+func TestCheckReadiness_Ready(t *testing.T) {
+	server := newTestServer(t)
+
+	status, health := getHealth(t, server, health_ready_path)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, &Health{Status: health_up, Checks: map[string]string{"database": health_up, "message_sink": health_up}}, health)
+}
+
+func TestCheckReadiness_ShuttingDown(t *testing.T) {
+	server := newTestServer(t)
+	close(server.api.shutdown)
+
+	status, health := getHealth(t, server, health_ready_path)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, health_down, health.Status)
+
+	status, _ = getHealth(t, server, health_live_path)
+	assert.Equal(t, http.StatusOK, status)
+}
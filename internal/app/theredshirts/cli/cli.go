@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	output_table = "table"
+	output_json  = "json"
+
+	default_events_interval = time.Second
+
+	usage = `Usage: theredshirts-lobby-cli <command> [flags] [arguments]
+
+Commands:
+  migrate                                  migrate the database
+  lobbies [-status S] [-limit N] [-cursor C] list lobbies of all visibilities
+  lobby <lobbyId>                          show a lobby with its players
+  kick [-reason R] [-ban] <lobbyId> <playerId>
+                                           kick a player, also the owner
+  delete <lobbyId>                         delete a lobby regardless of its status
+  status <lobbyId> <status>                change the status of a lobby
+  events [-from ID] <lobbyId>              tail the events of a lobby
+  history [-limit N] [-cursor C] <lobbyId> show the audit log of a lobby, also after it was deleted
+  outbox                                   list messages which could not be delivered
+
+Commands which print accept -output table|json. Flags have to be placed before the arguments.
+The database is configured with the same environment variables as the lobby service.
+`
+)
+
+var (
+	// ErrUsage is returned when the command or its arguments are wrong, the usage was already printed
+	ErrUsage = errors.New("wrong usage")
+)
+
+// Cli runs the commands with the admin core and writes their output
+type Cli struct {
+	admin core.AdminCore
+	out   io.Writer
+}
+
+// Run executes the command of the arguments, which don't contain the program name. The command ends when the context is done
+func Run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(out, usage)
+		if len(args) == 0 {
+			return ErrUsage
+		}
+		return nil
+	}
+
+	if args[0] == "migrate" {
+		if err := db.Migrate(); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Database migrated")
+		return nil
+	}
+
+	admin, err := core.NewAdminCore()
+	if err != nil {
+		return fmt.Errorf("error while creating admin core: %v", err)
+	}
+	defer admin.Close()
+	return (&Cli{admin: admin, out: out}).run(ctx, args)
+}
+
+func (cli *Cli) run(ctx context.Context, args []string) error {
+	command, args := args[0], args[1:]
+	switch command {
+	case "lobbies":
+		return cli.listLobbies(args)
+	case "lobby":
+		return cli.inspectLobby(args)
+	case "kick":
+		return cli.kickPlayer(args)
+	case "delete":
+		return cli.deleteLobby(args)
+	case "status":
+		return cli.updateLobbyStatus(args)
+	case "events":
+		return cli.tailEvents(ctx, args)
+	case "history":
+		return cli.listHistory(args)
+	case "outbox":
+		return cli.listFailedMessages(args)
+	default:
+		fmt.Fprintf(cli.out, "Unknown command %s\n\n%s", command, usage)
+		return ErrUsage
+	}
+}
+
+func (cli *Cli) newFlagSet(command string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(cli.out)
+	return flags
+}
+
+// parse reads the flags and checks the number of arguments
+func (cli *Cli) parse(flags *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	if err := flags.Parse(args); err != nil {
+		return nil, ErrUsage
+	}
+	if flags.NArg() != len(names) {
+		fmt.Fprintf(cli.out, "%s needs the arguments %v\n", flags.Name(), names)
+		return nil, ErrUsage
+	}
+	return flags.Args(), nil
+}
+
+func outputFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", output_table, "output format, table or json")
+}
+
+func checkOutput(output string) error {
+	if output != output_table && output != output_json {
+		return fmt.Errorf("output %s is not supported: %w", output, ErrUsage)
+	}
+	return nil
+}
+
+func parseId(name string, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s %s is no uuid: %w", name, value, ErrUsage)
+	}
+	return id, nil
+}
+
+func newContext() *util.Context {
+	correlationId := uuid.NewString()
+	return &util.Context{CorrelationId: correlationId, Logger: log.WithField("Cli", correlationId)}
+}
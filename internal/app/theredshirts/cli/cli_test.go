@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAdmin struct {
+	lobbies    []*core.Lobby
+	nextCursor string
+	filter     *core.LobbyFilter
+	events     []*core.Event
+	history    []*core.AuditEntry
+	cursor     string
+	kicked     []uuid.UUID
+	ban        bool
+	deleted    []uuid.UUID
+	status     string
+	failed     []*core.OutboxMessage
+	err        error
+}
+
+func (admin *fakeAdmin) GetLobbies(context *util.Context, filter *core.LobbyFilter) ([]*core.Lobby, string, error) {
+	admin.filter = filter
+	return admin.lobbies, admin.nextCursor, admin.err
+}
+
+func (admin *fakeAdmin) GetLobby(context *util.Context, lobbyId uuid.UUID) (*core.Lobby, error) {
+	for _, lobby := range admin.lobbies {
+		if lobby.ID == lobbyId {
+			return lobby, nil
+		}
+	}
+	return nil, core.ErrLobbyNotFound
+}
+
+func (admin *fakeAdmin) GetLobbyEvents(context *util.Context, lobbyId uuid.UUID, afterEventId int64) ([]*core.Event, error) {
+	events := []*core.Event{}
+	for _, event := range admin.events {
+		if event.ID > afterEventId {
+			events = append(events, event)
+		}
+	}
+	return events, admin.err
+}
+
+func (admin *fakeAdmin) GetLastLobbyEventId(context *util.Context, lobbyId uuid.UUID) (int64, error) {
+	return 0, admin.err
+}
+
+func (admin *fakeAdmin) GetLobbyHistory(context *util.Context, lobbyId uuid.UUID, cursor string, limit int) ([]*core.AuditEntry, string, error) {
+	admin.cursor = cursor
+	return admin.history, admin.nextCursor, admin.err
+}
+
+func (admin *fakeAdmin) KickPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, reason string, ban bool) error {
+	admin.kicked = append(admin.kicked, playerId)
+	admin.ban = ban
+	return admin.err
+}
+
+func (admin *fakeAdmin) DeleteLobby(context *util.Context, lobbyId uuid.UUID) error {
+	admin.deleted = append(admin.deleted, lobbyId)
+	return admin.err
+}
+
+func (admin *fakeAdmin) UpdateLobbyStatus(context *util.Context, lobbyId uuid.UUID, status string) error {
+	admin.status = status
+	return admin.err
+}
+
+func (admin *fakeAdmin) GetFailedMessages(context *util.Context) ([]*core.OutboxMessage, error) {
+	return admin.failed, admin.err
+}
+
+func (admin *fakeAdmin) Close() {}
+
+func newTestCli(admin *fakeAdmin) (*Cli, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &Cli{admin: admin, out: out}, out
+}
+
+func newTestLobby() *core.Lobby {
+	owner := &core.Player{ID: uuid.New(), Name: "Kirk"}
+	return &core.Lobby{ID: uuid.New(), Name: "Enterprise", Status: "OPEN", Visibility: "PRIVATE", Owner: owner, Password: "secret-hash", MaxPlayers: 4, Players: []*core.Player{owner}, CreatedAt: time.Now()}
+}
+
+func TestListLobbies_Table(t *testing.T) {
+	lobby := newTestLobby()
+	admin := &fakeAdmin{lobbies: []*core.Lobby{lobby}, nextCursor: "next"}
+	cli, out := newTestCli(admin)
+
+	err := cli.run(context.Background(), []string{"lobbies", "-status", "OPEN", "-limit", "5"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &core.LobbyFilter{Status: "OPEN", Limit: 5}, admin.filter)
+	assert.Contains(t, out.String(), "ID")
+	assert.Contains(t, out.String(), lobby.ID.String())
+	assert.Contains(t, out.String(), "1/4")
+	assert.Contains(t, out.String(), "-cursor next")
+}
+
+func TestListLobbies_JSONWithoutPassword(t *testing.T) {
+	lobby := newTestLobby()
+	cli, out := newTestCli(&fakeAdmin{lobbies: []*core.Lobby{lobby}})
+
+	err := cli.run(context.Background(), []string{"lobbies", "-output", "json"})
+
+	assert.NoError(t, err)
+	page := &LobbyPage{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), page))
+	assert.Len(t, page.Lobbies, 1)
+	assert.Equal(t, lobby.ID, page.Lobbies[0].ID)
+	assert.Equal(t, lobby.Owner.ID, page.Lobbies[0].Owner)
+	assert.NotContains(t, out.String(), "secret-hash")
+}
+
+func TestListLobbies_UnknownOutput(t *testing.T) {
+	cli, _ := newTestCli(&fakeAdmin{})
+
+	err := cli.run(context.Background(), []string{"lobbies", "-output", "yaml"})
+
+	assert.ErrorIs(t, err, ErrUsage)
+}
+
+func TestInspectLobby(t *testing.T) {
+	lobby := newTestLobby()
+	cli, out := newTestCli(&fakeAdmin{lobbies: []*core.Lobby{lobby}})
+
+	err := cli.run(context.Background(), []string{"lobby", lobby.ID.String()})
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Enterprise")
+	assert.Contains(t, out.String(), "Kirk")
+	assert.NotContains(t, out.String(), "secret-hash")
+}
+
+func TestInspectLobby_NotFound(t *testing.T) {
+	cli, _ := newTestCli(&fakeAdmin{})
+
+	err := cli.run(context.Background(), []string{"lobby", uuid.NewString()})
+
+	assert.ErrorContains(t, err, core.ErrLobbyNotFound.Error())
+}
+
+func TestKickPlayer(t *testing.T) {
+	admin := &fakeAdmin{}
+	cli, out := newTestCli(admin)
+	playerId := uuid.New()
+
+	err := cli.run(context.Background(), []string{"kick", "-ban", "-reason", "cheating", uuid.NewString(), playerId.String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{playerId}, admin.kicked)
+	assert.True(t, admin.ban)
+	assert.Contains(t, out.String(), "kicked")
+}
+
+func TestKickPlayer_MissingArgument(t *testing.T) {
+	admin := &fakeAdmin{}
+	cli, _ := newTestCli(admin)
+
+	err := cli.run(context.Background(), []string{"kick", uuid.NewString()})
+
+	assert.ErrorIs(t, err, ErrUsage)
+	assert.Empty(t, admin.kicked)
+}
+
+func TestDeleteLobby(t *testing.T) {
+	admin := &fakeAdmin{}
+	cli, _ := newTestCli(admin)
+	lobbyId := uuid.New()
+
+	err := cli.run(context.Background(), []string{"delete", lobbyId.String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{lobbyId}, admin.deleted)
+}
+
+func TestDeleteLobby_NoUuid(t *testing.T) {
+	cli, _ := newTestCli(&fakeAdmin{})
+
+	err := cli.run(context.Background(), []string{"delete", "enterprise"})
+
+	assert.ErrorIs(t, err, ErrUsage)
+}
+
+func TestUpdateLobbyStatus_Error(t *testing.T) {
+	admin := &fakeAdmin{err: errors.New("status not allowed")}
+	cli, _ := newTestCli(admin)
+
+	err := cli.run(context.Background(), []string{"status", uuid.NewString(), "ABORTED"})
+
+	assert.ErrorContains(t, err, "status not allowed")
+	assert.Equal(t, "ABORTED", admin.status)
+}
+
+func TestTailEvents_EndsWhenLobbyIsDeleted(t *testing.T) {
+	lobby := newTestLobby()
+	admin := &fakeAdmin{events: []*core.Event{
+		{ID: 1, LobbyId: lobby.ID, Topic: "lobby.updated", Lobby: lobby},
+		{ID: 2, LobbyId: lobby.ID, Topic: "lobby.deleted"},
+	}}
+	cli, out := newTestCli(admin)
+
+	err := cli.run(context.Background(), []string{"events", "-from", "0", "-output", "json", lobby.ID.String()})
+
+	assert.NoError(t, err)
+	decoder := json.NewDecoder(out)
+	topics := []string{}
+	for decoder.More() {
+		event := &Event{}
+		assert.NoError(t, decoder.Decode(event))
+		topics = append(topics, event.Topic)
+	}
+	assert.Equal(t, []string{"lobby.updated", "lobby.deleted"}, topics)
+}
+
+func TestTailEvents_EndsWithContext(t *testing.T) {
+	cli, _ := newTestCli(&fakeAdmin{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := cli.run(ctx, []string{"events", "-interval", "10ms", uuid.NewString()})
+
+	assert.NoError(t, err)
+}
+
+func TestListHistory(t *testing.T) {
+	entry := &core.AuditEntry{ID: 3, Topic: "PLAYER_KICKED", ActorId: uuid.New(), Payload: map[string]interface{}{"reason": "afk"}}
+	admin := &fakeAdmin{history: []*core.AuditEntry{entry}, nextCursor: "3"}
+	cli, out := newTestCli(admin)
+
+	err := cli.run(context.Background(), []string{"history", "-cursor", "2", uuid.NewString()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "2", admin.cursor)
+	assert.Contains(t, out.String(), "PLAYER_KICKED")
+	assert.Contains(t, out.String(), `{"reason":"afk"}`)
+	assert.Contains(t, out.String(), "-cursor 3")
+}
+
+func TestListFailedMessages(t *testing.T) {
+	message := &core.OutboxMessage{ID: 7, LobbyId: uuid.New(), Topic: "PLAYER_JOINS_LOBBY", Attempts: 10, LastError: "sink not reachable"}
+	cli, out := newTestCli(&fakeAdmin{failed: []*core.OutboxMessage{message}})
+
+	err := cli.run(context.Background(), []string{"outbox"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "PLAYER_JOINS_LOBBY")
+	assert.Contains(t, out.String(), "sink not reachable")
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	cli, out := newTestCli(&fakeAdmin{})
+
+	err := cli.run(context.Background(), []string{"warp"})
+
+	assert.ErrorIs(t, err, ErrUsage)
+	assert.Contains(t, out.String(), "Usage")
+}
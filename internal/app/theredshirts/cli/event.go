@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/google/uuid"
+)
+
+type Event struct {
+	ID        int64                  `json:"id"`
+	LobbyId   uuid.UUID              `json:"lobby_id"`
+	Topic     string                 `json:"topic"`
+	Payload   map[string]interface{} `json:"payload"`
+	Lobby     *Lobby                 `json:"lobby"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// tailEvents prints the events of the lobby as they are stored, until the context is done or the lobby was deleted.
+// Without -from only new events are printed
+func (cli *Cli) tailEvents(ctx context.Context, args []string) error {
+	flags := cli.newFlagSet("events")
+	output := outputFlag(flags)
+	from := flags.Int64("from", -1, "print the events after this event id, 0 prints all events")
+	interval := flags.Duration("interval", default_events_interval, "how often new events are loaded")
+	args, err := cli.parse(flags, args, "lobbyId")
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	lobbyId, err := parseId("lobby id", args[0])
+	if err != nil {
+		return err
+	}
+
+	lastEventId := *from
+	if lastEventId < 0 {
+		lastEventId, err = cli.admin.GetLastLobbyEventId(newContext(), lobbyId)
+		if err != nil {
+			return fmt.Errorf("error while loading last event of lobby [%v]: %v", lobbyId, err)
+		}
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		events, err := cli.admin.GetLobbyEvents(newContext(), lobbyId, lastEventId)
+		if err != nil {
+			return fmt.Errorf("error while loading events of lobby [%v]: %v", lobbyId, err)
+		}
+		for _, event := range events {
+			if err := cli.printEvent(*output, event); err != nil {
+				return err
+			}
+			lastEventId = event.ID
+			if event.Lobby == nil {
+				return nil
+			}
+		}
+		if len(events) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cli *Cli) printEvent(output string, event *core.Event) error {
+	if output == output_json {
+		return json.NewEncoder(cli.out).Encode(&Event{ID: event.ID, LobbyId: event.LobbyId, Topic: event.Topic, Payload: event.Payload, Lobby: mapToLobby(event.Lobby), CreatedAt: event.CreatedAt})
+	}
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("error while marshal payload of event [%d]: %v", event.ID, err)
+	}
+	_, err = fmt.Fprintf(cli.out, "%s\t%d\t%s\t%s\n", formatTime(event.CreatedAt), event.ID, event.Topic, payload)
+	return err
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/google/uuid"
+)
+
+type (
+	AuditEntry struct {
+		ID            int64                  `json:"id"`
+		Topic         string                 `json:"topic"`
+		ActorId       uuid.UUID              `json:"actor_id"`
+		CorrelationId string                 `json:"correlation_id"`
+		Payload       map[string]interface{} `json:"payload"`
+		CreatedAt     time.Time              `json:"created_at"`
+	}
+
+	HistoryPage struct {
+		Entries    []*AuditEntry `json:"entries"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+)
+
+func (cli *Cli) listHistory(args []string) error {
+	flags := cli.newFlagSet("history")
+	output := outputFlag(flags)
+	limit := flags.Int("limit", 0, "maximum number of entries, at most 100")
+	cursor := flags.String("cursor", "", "cursor of the next page")
+	args, err := cli.parse(flags, args, "lobbyId")
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	lobbyId, err := parseId("lobby id", args[0])
+	if err != nil {
+		return err
+	}
+
+	entries, nextCursor, err := cli.admin.GetLobbyHistory(newContext(), lobbyId, *cursor, *limit)
+	if err != nil {
+		return fmt.Errorf("error while loading history of lobby [%v]: %v", lobbyId, err)
+	}
+
+	page := &HistoryPage{Entries: mapToAuditEntries(entries), NextCursor: nextCursor}
+	if *output == output_json {
+		return writeJSON(cli.out, page)
+	}
+	table := newTable(cli.out, "ID", "CREATED", "TOPIC", "ACTOR", "PAYLOAD")
+	for _, entry := range page.Entries {
+		payload, err := json.Marshal(entry.Payload)
+		if err != nil {
+			return fmt.Errorf("error while marshal payload of audit entry [%d]: %v", entry.ID, err)
+		}
+		table.row(entry.ID, formatTime(entry.CreatedAt), entry.Topic, entry.ActorId, string(payload))
+	}
+	if err := table.flush(); err != nil {
+		return err
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(cli.out, "\nNext page: -cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func mapToAuditEntries(coreEntries []*core.AuditEntry) []*AuditEntry {
+	entries := make([]*AuditEntry, len(coreEntries))
+	for index, entry := range coreEntries {
+		entries[index] = &AuditEntry{ID: entry.ID, Topic: entry.Topic, ActorId: entry.ActorId, CorrelationId: entry.CorrelationId, Payload: entry.Payload, CreatedAt: entry.CreatedAt}
+	}
+	return entries
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/google/uuid"
+)
+
+type (
+	Lobby struct {
+		ID             uuid.UUID              `json:"id"`
+		Name           string                 `json:"name"`
+		Status         string                 `json:"status"`
+		Visibility     string                 `json:"visibility"`
+		Owner          uuid.UUID              `json:"owner"`
+		MaxPlayers     int                    `json:"max_players"`
+		Difficulty     int                    `json:"difficulty"`
+		MissionLength  int                    `json:"mission_length"`
+		ExpansionPacks []string               `json:"expansion_packs"`
+		Players        []*Player              `json:"players"`
+		Payload        map[string]interface{} `json:"payload"`
+		CreatedAt      time.Time              `json:"created_at"`
+		Version        int                    `json:"version"`
+	}
+
+	Player struct {
+		ID          uuid.UUID `json:"id"`
+		Name        string    `json:"name"`
+		Spectator   bool      `json:"spectator"`
+		Ready       bool      `json:"ready"`
+		JoinedAt    time.Time `json:"joined_at"`
+		LastRefresh time.Time `json:"last_refresh"`
+	}
+
+	LobbyPage struct {
+		Lobbies    []*Lobby `json:"lobbies"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+)
+
+func (cli *Cli) listLobbies(args []string) error {
+	flags := cli.newFlagSet("lobbies")
+	output := outputFlag(flags)
+	status := flags.String("status", "", "only lobbies with the status")
+	limit := flags.Int("limit", 0, "maximum number of lobbies, at most 100")
+	cursor := flags.String("cursor", "", "cursor of the next page")
+	if _, err := cli.parse(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	lobbies, nextCursor, err := cli.admin.GetLobbies(newContext(), &core.LobbyFilter{Status: *status, Limit: *limit, Cursor: *cursor})
+	if err != nil {
+		return fmt.Errorf("error while loading lobbies: %v", err)
+	}
+
+	page := &LobbyPage{Lobbies: mapToLobbies(lobbies), NextCursor: nextCursor}
+	if *output == output_json {
+		return writeJSON(cli.out, page)
+	}
+	table := newTable(cli.out, "ID", "NAME", "STATUS", "VISIBILITY", "OWNER", "PLAYERS", "CREATED")
+	for _, lobby := range page.Lobbies {
+		table.row(lobby.ID, lobby.Name, lobby.Status, lobby.Visibility, lobby.Owner, fmt.Sprintf("%d/%d", len(lobby.Players), lobby.MaxPlayers), formatTime(lobby.CreatedAt))
+	}
+	if err := table.flush(); err != nil {
+		return err
+	}
+	if page.NextCursor != "" {
+		fmt.Fprintf(cli.out, "\nNext page: -cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func (cli *Cli) inspectLobby(args []string) error {
+	flags := cli.newFlagSet("lobby")
+	output := outputFlag(flags)
+	args, err := cli.parse(flags, args, "lobbyId")
+	if err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+	lobbyId, err := parseId("lobby id", args[0])
+	if err != nil {
+		return err
+	}
+
+	coreLobby, err := cli.admin.GetLobby(newContext(), lobbyId)
+	if err != nil {
+		return fmt.Errorf("error while loading lobby [%v]: %v", lobbyId, err)
+	}
+
+	lobby := mapToLobby(coreLobby)
+	if *output == output_json {
+		return writeJSON(cli.out, lobby)
+	}
+	details := newTable(cli.out)
+	details.row("ID:", lobby.ID)
+	details.row("Name:", lobby.Name)
+	details.row("Status:", lobby.Status)
+	details.row("Visibility:", lobby.Visibility)
+	details.row("Owner:", lobby.Owner)
+	details.row("Players:", fmt.Sprintf("%d/%d", len(lobby.Players), lobby.MaxPlayers))
+	details.row("Difficulty:", lobby.Difficulty)
+	details.row("Mission length:", lobby.MissionLength)
+	details.row("Expansion packs:", lobby.ExpansionPacks)
+	details.row("Created:", formatTime(lobby.CreatedAt))
+	details.row("Version:", lobby.Version)
+	if err := details.flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cli.out)
+	players := newTable(cli.out, "PLAYER", "NAME", "SPECTATOR", "READY", "JOINED", "LAST REFRESH")
+	for _, player := range lobby.Players {
+		players.row(player.ID, player.Name, player.Spectator, player.Ready, formatTime(player.JoinedAt), formatTime(player.LastRefresh))
+	}
+	return players.flush()
+}
+
+func (cli *Cli) kickPlayer(args []string) error {
+	flags := cli.newFlagSet("kick")
+	reason := flags.String("reason", "", "reason sent to the players")
+	ban := flags.Bool("ban", false, "ban the player from the lobby")
+	args, err := cli.parse(flags, args, "lobbyId", "playerId")
+	if err != nil {
+		return err
+	}
+	lobbyId, err := parseId("lobby id", args[0])
+	if err != nil {
+		return err
+	}
+	playerId, err := parseId("player id", args[1])
+	if err != nil {
+		return err
+	}
+
+	if err := cli.admin.KickPlayer(newContext(), lobbyId, playerId, *reason, *ban); err != nil {
+		return fmt.Errorf("error while kicking player [%v] from lobby [%v]: %v", playerId, lobbyId, err)
+	}
+	fmt.Fprintf(cli.out, "Player %v kicked from lobby %v\n", playerId, lobbyId)
+	return nil
+}
+
+func (cli *Cli) deleteLobby(args []string) error {
+	flags := cli.newFlagSet("delete")
+	args, err := cli.parse(flags, args, "lobbyId")
+	if err != nil {
+		return err
+	}
+	lobbyId, err := parseId("lobby id", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := cli.admin.DeleteLobby(newContext(), lobbyId); err != nil {
+		return fmt.Errorf("error while deleting lobby [%v]: %v", lobbyId, err)
+	}
+	fmt.Fprintf(cli.out, "Lobby %v deleted\n", lobbyId)
+	return nil
+}
+
+func (cli *Cli) updateLobbyStatus(args []string) error {
+	flags := cli.newFlagSet("status")
+	args, err := cli.parse(flags, args, "lobbyId", "status")
+	if err != nil {
+		return err
+	}
+	lobbyId, err := parseId("lobby id", args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := cli.admin.UpdateLobbyStatus(newContext(), lobbyId, args[1]); err != nil {
+		return fmt.Errorf("error while changing status of lobby [%v]: %v", lobbyId, err)
+	}
+	fmt.Fprintf(cli.out, "Lobby %v changed to %s\n", lobbyId, args[1])
+	return nil
+}
+
+func mapToLobby(lobby *core.Lobby) *Lobby {
+	if lobby == nil {
+		return nil
+	}
+	var owner uuid.UUID
+	if lobby.Owner != nil {
+		owner = lobby.Owner.ID
+	}
+	return &Lobby{ID: lobby.ID, Name: lobby.Name, Status: lobby.Status, Visibility: lobby.Visibility, Owner: owner, MaxPlayers: lobby.MaxPlayers, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, ExpansionPacks: lobby.ExpansionPacks, Players: mapToPlayers(lobby.Players), Payload: lobby.Payload, CreatedAt: lobby.CreatedAt, Version: lobby.Version}
+}
+
+func mapToLobbies(coreLobbies []*core.Lobby) []*Lobby {
+	lobbies := make([]*Lobby, len(coreLobbies))
+	for index, lobby := range coreLobbies {
+		lobbies[index] = mapToLobby(lobby)
+	}
+	return lobbies
+}
+
+func mapToPlayers(corePlayers []*core.Player) []*Player {
+	players := make([]*Player, len(corePlayers))
+	for index, player := range corePlayers {
+		players[index] = &Player{ID: player.ID, Name: player.Name, Spectator: player.Spectator, Ready: player.Ready, JoinedAt: player.JoinedAt, LastRefresh: player.LastRefresh}
+	}
+	return players
+}
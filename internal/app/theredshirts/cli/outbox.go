@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/core"
+	"github.com/google/uuid"
+)
+
+type OutboxMessage struct {
+	ID             int64                  `json:"id"`
+	LobbyId        uuid.UUID              `json:"lobby_id"`
+	SenderPlayerId uuid.UUID              `json:"sender_player_id"`
+	CorrelationId  string                 `json:"correlation_id"`
+	Topic          string                 `json:"topic"`
+	Payload        map[string]interface{} `json:"payload"`
+	CreatedAt      time.Time              `json:"created_at"`
+	Attempts       int                    `json:"attempts"`
+	NextAttempt    time.Time              `json:"next_attempt"`
+	LastError      string                 `json:"last_error"`
+}
+
+func (cli *Cli) listFailedMessages(args []string) error {
+	flags := cli.newFlagSet("outbox")
+	output := outputFlag(flags)
+	if _, err := cli.parse(flags, args); err != nil {
+		return err
+	}
+	if err := checkOutput(*output); err != nil {
+		return err
+	}
+
+	coreMessages, err := cli.admin.GetFailedMessages(newContext())
+	if err != nil {
+		return fmt.Errorf("error while loading failed messages: %v", err)
+	}
+
+	messages := mapToOutboxMessages(coreMessages)
+	if *output == output_json {
+		return writeJSON(cli.out, messages)
+	}
+	table := newTable(cli.out, "ID", "LOBBY", "TOPIC", "ATTEMPTS", "NEXT ATTEMPT", "CORRELATION ID", "LAST ERROR")
+	for _, message := range messages {
+		table.row(message.ID, message.LobbyId, message.Topic, message.Attempts, formatTime(message.NextAttempt), message.CorrelationId, message.LastError)
+	}
+	return table.flush()
+}
+
+func mapToOutboxMessages(coreMessages []*core.OutboxMessage) []*OutboxMessage {
+	messages := make([]*OutboxMessage, len(coreMessages))
+	for index, message := range coreMessages {
+		messages[index] = &OutboxMessage{ID: message.ID, LobbyId: message.LobbyId, SenderPlayerId: message.SenderPlayerId, CorrelationId: message.CorrelationId, Topic: message.Topic, Payload: message.Payload, CreatedAt: message.CreatedAt, Attempts: message.Attempts, NextAttempt: message.NextAttempt, LastError: message.LastError}
+	}
+	return messages
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// table aligns its rows in columns, the header is optional
+type table struct {
+	writer *tabwriter.Writer
+}
+
+func newTable(out io.Writer, header ...string) *table {
+	table := &table{writer: tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)}
+	if len(header) > 0 {
+		fmt.Fprintln(table.writer, strings.Join(header, "\t"))
+	}
+	return table
+}
+
+func (table *table) row(columns ...interface{}) {
+	values := make([]string, len(columns))
+	for index, column := range columns {
+		values[index] = fmt.Sprint(column)
+	}
+	fmt.Fprintln(table.writer, strings.Join(values, "\t"))
+}
+
+func (table *table) flush() error {
+	if err := table.writer.Flush(); err != nil {
+		return fmt.Errorf("error while writing table: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("error while writing json: %v", err)
+	}
+	return nil
+}
+
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return "-"
+	}
+	return value.Local().Format(time.RFC3339)
+}
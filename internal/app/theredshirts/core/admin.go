@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+type (
+	// AdminCore operates lobbies without being their owner, e.g. from the command line. Changes are made in the name of the
+	// lobby user and the messages wait in the outbox until a running lobby service delivers them
+	AdminCore interface {
+		GetLobbies(context *util.Context, filter *LobbyFilter) ([]*Lobby, string, error)
+		GetLobby(context *util.Context, lobbyId uuid.UUID) (*Lobby, error)
+		GetLobbyEvents(context *util.Context, lobbyId uuid.UUID, afterEventId int64) ([]*Event, error)
+		GetLastLobbyEventId(context *util.Context, lobbyId uuid.UUID) (int64, error)
+		GetLobbyHistory(context *util.Context, lobbyId uuid.UUID, cursor string, limit int) ([]*AuditEntry, string, error)
+		KickPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, reason string, ban bool) error
+		DeleteLobby(context *util.Context, lobbyId uuid.UUID) error
+		UpdateLobbyStatus(context *util.Context, lobbyId uuid.UUID, status string) error
+		GetFailedMessages(context *util.Context) ([]*OutboxMessage, error)
+		Close()
+	}
+
+	AdminFacade struct {
+		core *CoreFacade
+	}
+)
+
+// NewAdminCore connects to the database of the lobby service. No schedulers are started, so it can run next to the service
+func NewAdminCore() (AdminCore, error) {
+	core, err := newCoreFacade()
+	if err != nil {
+		return nil, err
+	}
+	return &AdminFacade{core: core}, nil
+}
+
+func (admin AdminFacade) Close() {
+	admin.core.db.Close()
+}
+
+// GetLobbies lists the lobbies of all visibilities
+func (admin AdminFacade) GetLobbies(context *util.Context, filter *LobbyFilter) ([]*Lobby, string, error) {
+	query, err := mapToLobbyQuery(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	query.Visibility = ""
+	return admin.core.getLobbies(context, query)
+}
+
+func (admin AdminFacade) GetLobby(context *util.Context, lobbyId uuid.UUID) (*Lobby, error) {
+	return admin.core.GetLobby(context, lobbyId)
+}
+
+func (admin AdminFacade) GetLobbyEvents(context *util.Context, lobbyId uuid.UUID, afterEventId int64) ([]*Event, error) {
+	return admin.core.GetLobbyEvents(context, lobbyId, afterEventId)
+}
+
+func (admin AdminFacade) GetLastLobbyEventId(context *util.Context, lobbyId uuid.UUID) (int64, error) {
+	return admin.core.GetLastLobbyEventId(context, lobbyId)
+}
+
+// GetLobbyHistory also returns the history of lobbies which were deleted
+func (admin AdminFacade) GetLobbyHistory(context *util.Context, lobbyId uuid.UUID, cursor string, limit int) ([]*AuditEntry, string, error) {
+	context.Logger.Debugf("Admin getting history of lobby [%v]", lobbyId)
+	core := admin.core
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, "", err
+	}
+	defer core.rollback(tx)
+
+	entries, nextCursor, err := core.getLobbyHistory(tx, lobbyId, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, nextCursor, core.commit(tx, context)
+}
+
+// GetFailedMessages returns the dead letters, the messages which were given up after several failed attempts to deliver them
+func (admin AdminFacade) GetFailedMessages(context *util.Context) ([]*OutboxMessage, error) {
+	return admin.core.GetFailedMessages(context)
+}
+
+// KickPlayer also kicks the owner. The lobby is handed over like when the owner leaves, or deleted if nobody can take it over
+func (admin AdminFacade) KickPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, reason string, ban bool) error {
+	context.Logger.Debugf("Admin kicking player [%v] from lobby [%v], ban [%t]", playerId, lobbyId, ban)
+	core := admin.core
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	// the lobby is locked before its players are loaded, so the owner and the players can't change until the kick is committed
+	if _, err := tx.dbTx.GetLobbyByIdForUpdate(lobbyId); err != nil {
+		return fmt.Errorf("something went wrong while locking lobby [%v]: %v", lobbyId, err)
+	}
+	lobby, err := core.getLobby(tx, lobbyId)
+	if err != nil {
+		return err
+	}
+	if findPlayer(lobby.Players, playerId) == nil {
+		return fmt.Errorf("player [%v] is not part of lobby [%v]: %w", playerId, lobbyId, ErrPlayerNotInLobby)
+	}
+
+	if lobby.Owner != nil && lobby.Owner.ID == playerId {
+		successor := findSuccessor(lobby.Players, playerId, core.ownerSuccession)
+		if successor == nil {
+			context.Logger.Debugf("No new owner found. Deleting lobby [%s]", lobbyId)
+			if err := core.removeLobby(tx, lobbyId, uuid.Nil); err != nil {
+				return err
+			}
+			return core.commit(tx, context)
+		}
+		if err := core.changeOwner(tx, lobbyId, successor.ID, uuid.Nil); err != nil {
+			return err
+		}
+	}
+
+	if err := core.removeKickedPlayer(context, tx, lobbyId, playerId, uuid.Nil, reason, ban); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+// DeleteLobby deletes the lobby with all its players, regardless of its status
+func (admin AdminFacade) DeleteLobby(context *util.Context, lobbyId uuid.UUID) error {
+	context.Logger.Debugf("Admin deleting lobby [%v]", lobbyId)
+	core := admin.core
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	lobby, err := tx.dbTx.GetLobbyByIdForUpdate(lobbyId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading lobby [%v] from database: %v", lobbyId, err)
+	}
+	if lobby == nil {
+		return ErrLobbyNotFound
+	}
+
+	if err := core.removeLobby(tx, lobbyId, uuid.Nil); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+// UpdateLobbyStatus changes the status with the same transitions and preconditions as the owner
+func (admin AdminFacade) UpdateLobbyStatus(context *util.Context, lobbyId uuid.UUID, status string) error {
+	context.Logger.Debugf("Admin changing status of lobby [%v] to %s", lobbyId, status)
+	core := admin.core
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	lobby, err := tx.dbTx.GetLobbyByIdForUpdate(lobbyId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading lobby [%v] from database: %v", lobbyId, err)
+	}
+	if lobby == nil {
+		return ErrLobbyNotFound
+	}
+
+	if err := core.changeLobbyStatus(tx, lobby, status, uuid.Nil); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAdmin(core *CoreFacade) *AdminFacade {
+	return &AdminFacade{core: core}
+}
+
+func TestAdminGetLobbies_AllVisibilities(t *testing.T) {
+	core := newTestCore(t)
+	lobby := newTestLobby(4)
+	lobby.Visibility = visibility_private
+	assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+
+	publicLobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{})
+	assert.Nil(t, err)
+	assert.Empty(t, publicLobbies)
+
+	lobbies, _, err := newTestAdmin(core).GetLobbies(newTestContext(), &LobbyFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+	assert.Equal(t, lobby.ID, lobbies[0].ID)
+}
+
+func TestAdminKickPlayer_HandsOverLobbyOfOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	assert.Nil(t, newTestAdmin(core).KickPlayer(newTestContext(), lobby.ID, lobby.Owner.ID, "cheating", true))
+
+	storedLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, storedLobby.Owner.ID)
+	assert.Len(t, storedLobby.Players, 1)
+	owner := &Player{ID: lobby.Owner.ID, Name: lobby.Owner.Name, LobbyId: lobby.ID}
+	assert.ErrorIs(t, joinWithPassword(core, owner, test_password), ErrPlayerBanned)
+
+	messages := storedMessages(t, core)
+	kicked := messages[len(messages)-1]
+	assert.Equal(t, PLAYER_KICKED, kicked.Topic)
+	assert.Equal(t, core.lobbyPlayerId, kicked.SenderPlayerId)
+	assert.Contains(t, storedTopics(t, core), OWNER_CHANGED)
+}
+
+func TestAdminKickPlayer_DeletesLobbyWithoutSuccessor(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	assert.Nil(t, newTestAdmin(core).KickPlayer(newTestContext(), lobby.ID, lobby.Owner.ID, "", false))
+
+	_, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.ErrorIs(t, err, ErrLobbyNotFound)
+}
+
+func TestAdminKickPlayer_NotInLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	otherLobby := createTestLobby(t, core, 4)
+
+	err := newTestAdmin(core).KickPlayer(newTestContext(), lobby.ID, otherLobby.Owner.ID, "", false)
+	assert.ErrorIs(t, err, ErrPlayerNotInLobby)
+}
+
+func TestAdminKickPlayer_WaitsForLobbyLock(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	_, err = tx.dbTx.GetLobbyByIdForUpdate(lobby.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.dbTx.DeletePlayer(player.ID))
+
+	kicked := make(chan error)
+	go func() {
+		kicked <- newTestAdmin(core).KickPlayer(newTestContext(), lobby.ID, player.ID, "afk", false)
+	}()
+	select {
+	case <-kicked:
+		t.Fatal("player was kicked while the lobby was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Nil(t, core.commit(tx, newTestContext()))
+	assert.ErrorIs(t, <-kicked, ErrPlayerNotInLobby)
+}
+
+func TestAdminDeleteLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	admin := newTestAdmin(core)
+
+	assert.Nil(t, admin.DeleteLobby(newTestContext(), lobby.ID))
+
+	_, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.ErrorIs(t, err, ErrLobbyNotFound)
+	storedPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, storedPlayer)
+	assert.ErrorIs(t, admin.DeleteLobby(newTestContext(), lobby.ID), ErrLobbyNotFound)
+}
+
+func TestAdminUpdateLobbyStatus(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	admin := newTestAdmin(core)
+
+	assert.Nil(t, admin.UpdateLobbyStatus(newTestContext(), lobby.ID, lobby_aborted))
+	assert.ErrorIs(t, admin.UpdateLobbyStatus(newTestContext(), lobby.ID, lobby_playing), ErrInvalidStatusTransition)
+
+	storedLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby_aborted, storedLobby.Status)
+	messages := storedMessages(t, core)
+	assert.Equal(t, LOBBY_ABORTED, messages[len(messages)-1].Topic)
+	assert.Equal(t, core.lobbyPlayerId, messages[len(messages)-1].SenderPlayerId)
+}
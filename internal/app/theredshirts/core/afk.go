@@ -0,0 +1,131 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+)
+
+const (
+	afk_policy_min_seconds = 5
+	afk_policy_max_seconds = 3600
+)
+
+var lobbyStatuses = []string{lobby_open, lobby_starting, lobby_playing, lobby_finished, lobby_aborted}
+
+// loadAfkPolicies reads the default policy of every lobby status from env. A status without own values falls back to
+// AFK_WARNING_SECONDS and AFK_REMOVAL_SECONDS. Seats are only reserved in playing lobbies
+func loadAfkPolicies() (map[string]*AfkPolicy, error) {
+	warning, err := util.GetEnvIntWithFallback("AFK_WARNING_SECONDS", 5)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading afk warning from env: %v", err)
+	}
+	removal, err := util.GetEnvIntWithFallback("AFK_REMOVAL_SECONDS", 15)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading afk removal from env: %v", err)
+	}
+	reservedSeat, err := util.GetEnvIntWithFallback("AFK_RESERVED_SEAT_SECONDS", 0)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading reserved seat from env: %v", err)
+	}
+
+	policies := make(map[string]*AfkPolicy, len(lobbyStatuses))
+	for _, status := range lobbyStatuses {
+		policy := &AfkPolicy{}
+		if policy.WarningSeconds, err = util.GetEnvIntWithFallback("AFK_WARNING_SECONDS_"+status, warning); err != nil {
+			return nil, fmt.Errorf("error while loading afk warning of status %s from env: %v", status, err)
+		}
+		if policy.RemovalSeconds, err = util.GetEnvIntWithFallback("AFK_REMOVAL_SECONDS_"+status, removal); err != nil {
+			return nil, fmt.Errorf("error while loading afk removal of status %s from env: %v", status, err)
+		}
+		if status == lobby_playing {
+			policy.ReservedSeatSeconds = reservedSeat
+		}
+		if policy.WarningSeconds <= 0 || policy.RemovalSeconds < policy.WarningSeconds || policy.ReservedSeatSeconds < 0 {
+			return nil, fmt.Errorf("afk policy %+v of status %s is invalid", *policy, status)
+		}
+		policies[status] = policy
+	}
+	return policies, nil
+}
+
+// afkPolicy merges the overrides of the lobby into the default policy of its status
+func (core CoreFacade) afkPolicy(lobby *db.Lobby) *AfkPolicy {
+	if lobby == nil {
+		return core.afkPolicies[lobby_open]
+	}
+	policy := *core.afkPolicies[lobby.Status]
+	if override := lobby.AfkPolicies[lobby.Status]; override != nil {
+		if override.WarningSeconds != 0 {
+			policy.WarningSeconds = override.WarningSeconds
+		}
+		if override.RemovalSeconds != 0 {
+			policy.RemovalSeconds = override.RemovalSeconds
+		}
+		if override.ReservedSeatSeconds != 0 && lobby.Status == lobby_playing {
+			policy.ReservedSeatSeconds = override.ReservedSeatSeconds
+		}
+	}
+	return &policy
+}
+
+// minAfkWarning is the shortest time after which any player can be warned
+func (core CoreFacade) minAfkWarning() time.Duration {
+	warning := afk_policy_min_seconds
+	for _, policy := range core.afkPolicies {
+		if policy.WarningSeconds < warning {
+			warning = policy.WarningSeconds
+		}
+	}
+	return time.Duration(warning) * time.Second
+}
+
+// checkAfkPolicies validates the overrides of a lobby against the defaults
+func (core CoreFacade) checkAfkPolicies(policies map[string]*AfkPolicy) error {
+	for status, override := range policies {
+		if _, ok := core.afkPolicies[status]; !ok {
+			return fmt.Errorf("no lobby status %s found for afk policy: %w", status, ErrInvalidState)
+		}
+		if override == nil {
+			return fmt.Errorf("afk policy of status %s is empty: %w", status, ErrInvalidState)
+		}
+		for _, seconds := range []int{override.WarningSeconds, override.RemovalSeconds, override.ReservedSeatSeconds} {
+			if seconds != 0 && (seconds < afk_policy_min_seconds || seconds > afk_policy_max_seconds) {
+				return fmt.Errorf("afk policy of status %s has to be between %d and %d seconds: %w", status, afk_policy_min_seconds, afk_policy_max_seconds, ErrInvalidState)
+			}
+		}
+		policy := core.afkPolicy(&db.Lobby{Status: status, AfkPolicies: map[string]*db.AfkPolicy{status: mapToDBAfkPolicy(override)}})
+		if policy.RemovalSeconds < policy.WarningSeconds {
+			return fmt.Errorf("afk player of status %s is removed before they are warned: %w", status, ErrInvalidState)
+		}
+	}
+	return nil
+}
+
+func mapToDBAfkPolicies(policies map[string]*AfkPolicy) map[string]*db.AfkPolicy {
+	if policies == nil {
+		return nil
+	}
+	dbPolicies := make(map[string]*db.AfkPolicy, len(policies))
+	for status, policy := range policies {
+		dbPolicies[status] = mapToDBAfkPolicy(policy)
+	}
+	return dbPolicies
+}
+
+func mapToDBAfkPolicy(policy *AfkPolicy) *db.AfkPolicy {
+	return &db.AfkPolicy{WarningSeconds: policy.WarningSeconds, RemovalSeconds: policy.RemovalSeconds, ReservedSeatSeconds: policy.ReservedSeatSeconds}
+}
+
+func mapToAfkPolicies(dbPolicies map[string]*db.AfkPolicy) map[string]*AfkPolicy {
+	if dbPolicies == nil {
+		return nil
+	}
+	policies := make(map[string]*AfkPolicy, len(dbPolicies))
+	for status, policy := range dbPolicies {
+		policies[status] = &AfkPolicy{WarningSeconds: policy.WarningSeconds, RemovalSeconds: policy.RemovalSeconds, ReservedSeatSeconds: policy.ReservedSeatSeconds}
+	}
+	return policies
+}
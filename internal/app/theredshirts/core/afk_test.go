@@ -0,0 +1,158 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startTestGame moves the lobby with the owner and the player to playing
+func startTestGame(t *testing.T, core *CoreFacade, lobby *Lobby, player *Player) {
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_starting))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_playing))
+}
+
+func lastTopic(t *testing.T, core *CoreFacade) string {
+	topics := storedTopics(t, core)
+	return topics[len(topics)-1]
+}
+
+func countTopic(topics []string, topic string) int {
+	count := 0
+	for _, storedTopic := range topics {
+		if storedTopic == topic {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLoadAfkPolicies(t *testing.T) {
+	t.Setenv("AFK_REMOVAL_SECONDS", "20")
+	t.Setenv("AFK_REMOVAL_SECONDS_PLAYING", "60")
+	t.Setenv("AFK_RESERVED_SEAT_SECONDS", "120")
+
+	policies, err := loadAfkPolicies()
+
+	assert.Nil(t, err)
+	assert.Equal(t, &AfkPolicy{WarningSeconds: 5, RemovalSeconds: 20}, policies[lobby_open])
+	assert.Equal(t, &AfkPolicy{WarningSeconds: 5, RemovalSeconds: 60, ReservedSeatSeconds: 120}, policies[lobby_playing])
+}
+
+func TestLoadAfkPolicies_RemovalBeforeWarning(t *testing.T) {
+	t.Setenv("AFK_WARNING_SECONDS_OPEN", "30")
+
+	_, err := loadAfkPolicies()
+
+	assert.NotNil(t, err)
+}
+
+func TestCleanUpAfkPlayers_PolicyOfStatus(t *testing.T) {
+	core := newTestCore(t)
+	core.afkPolicies[lobby_playing] = &AfkPolicy{WarningSeconds: 30, RemovalSeconds: 120}
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	startTestGame(t, core, lobby, player)
+	setLastRefresh(t, core, player, time.Now().Add(-time.Minute))
+	setLastRefresh(t, core, lobby.Owner, time.Now())
+
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, foundPlayer)
+	assert.Equal(t, PLAYER_LAGGING, lastTopic(t, core))
+}
+
+func TestCleanUpAfkPlayers_LobbyOverride(t *testing.T) {
+	core := newTestCore(t)
+	lobby := newTestLobby(4)
+	lobby.AfkPolicies = map[string]*AfkPolicy{lobby_open: {RemovalSeconds: 120}}
+	assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-time.Minute))
+
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, foundPlayer)
+	assert.Equal(t, PLAYER_LAGGING, lastTopic(t, core))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.AfkPolicies, foundLobby.AfkPolicies)
+}
+
+func TestCleanUpAfkPlayers_ReservedSeat(t *testing.T) {
+	core := newTestCore(t)
+	lobby := newTestLobby(4)
+	lobby.AfkPolicies = map[string]*AfkPolicy{lobby_playing: {ReservedSeatSeconds: 60}}
+	assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	startTestGame(t, core, lobby, player)
+	setLastRefresh(t, core, lobby.Owner, time.Now())
+	setLastRefresh(t, core, player, time.Now().Add(-30*time.Second))
+
+	runCleanUp(t, core)
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, foundPlayer)
+	assert.Equal(t, PLAYER_SEAT_RESERVED, lastTopic(t, core))
+	assert.Equal(t, 1, countTopic(storedTopics(t, core), PLAYER_SEAT_RESERVED))
+
+	setLastRefresh(t, core, player, time.Now().Add(-2*time.Minute))
+	runCleanUp(t, core)
+
+	foundPlayer, err = core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+}
+
+func TestCleanUpAfkPlayers_NoReservedSeatOutsideOfGame(t *testing.T) {
+	core := newTestCore(t)
+	lobby := newTestLobby(4)
+	lobby.AfkPolicies = map[string]*AfkPolicy{lobby_open: {ReservedSeatSeconds: 60}}
+	assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-30*time.Second))
+
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+}
+
+func TestCreateLobby_InvalidAfkPolicy(t *testing.T) {
+	core := newTestCore(t)
+	for _, policies := range []map[string]*AfkPolicy{
+		{"WAITING": {WarningSeconds: 10}},
+		{lobby_open: {WarningSeconds: 1}},
+		{lobby_open: {WarningSeconds: 60}},
+		{lobby_playing: {WarningSeconds: 30, RemovalSeconds: 20}},
+	} {
+		lobby := newTestLobby(4)
+		lobby.AfkPolicies = policies
+		assert.ErrorIs(t, core.CreateLobby(newTestContext(), lobby), ErrInvalidState)
+	}
+}
+
+func TestUpdateLobby_AfkPolicy(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	lobby.AfkPolicies = map[string]*AfkPolicy{lobby_playing: {WarningSeconds: 20, RemovalSeconds: 60}}
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	lobby.AfkPolicies = nil
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]*AfkPolicy{lobby_playing: {WarningSeconds: 20, RemovalSeconds: 60}}, foundLobby.AfkPolicies)
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+const (
+	//Topics only recorded in the audit log
+	LOBBY_CREATED = "LOBBY_CREATED"
+	LOBBY_DELETED = "LOBBY_DELETED"
+
+	lobby_history_default_size = 50
+	lobby_history_max_size     = 100
+)
+
+// storeAuditEntries records every message of the transaction in the audit log of its lobby. The actor is the authenticated player,
+// it is uuid.Nil if the server made the change on its own, like the scavenger removing inactive players
+func (core CoreFacade) storeAuditEntries(context *util.Context, tx *transaction) error {
+	now := time.Now()
+	for _, message := range tx.messages {
+		payload := message.payload
+		if message.auditPayload != nil {
+			payload = message.auditPayload
+		}
+		entry := &db.LobbyAuditEntry{LobbyId: message.lobbyId, Topic: message.topic, ActorId: context.PlayerId, CorrelationId: context.CorrelationId, Payload: payload, CreatedAt: now}
+		if err := tx.dbTx.CreateLobbyAuditEntry(entry); err != nil {
+			return fmt.Errorf("error while storing audit entry with topic %s: %v", message.topic, err)
+		}
+	}
+	return nil
+}
+
+// GetLobbyHistory returns a page of the audit log of the lobby, oldest entries first, and the cursor of the next page.
+// Only the owner and the players of the lobby can read its history
+func (core CoreFacade) GetLobbyHistory(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, cursor string, limit int) ([]*AuditEntry, string, error) {
+	context.Logger.Debugf("Get history of lobby [%v] for player [%v]", lobbyId, playerId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, "", err
+	}
+	defer core.rollback(tx)
+
+	lobby, err := core.getLobby(tx, lobbyId)
+	if err != nil {
+		return nil, "", err
+	}
+	if !IsLobbyMember(lobby, playerId) {
+		return nil, "", fmt.Errorf("player [%v] is not member of lobby [%v]: %w", playerId, lobbyId, ErrNotLobbyMember)
+	}
+
+	entries, nextCursor, err := core.getLobbyHistory(tx, lobbyId, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return entries, nextCursor, core.commit(tx, context)
+}
+
+// getLobbyHistory pages through the audit log by the sequence of the lobby. The sequences are committed in order,
+// so a page never skips an entry which is committed later
+func (core CoreFacade) getLobbyHistory(tx *transaction, lobbyId uuid.UUID, cursor string, limit int) ([]*AuditEntry, string, error) {
+	var afterSequence int64
+	if cursor != "" {
+		var err error
+		afterSequence, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || afterSequence < 0 {
+			return nil, "", fmt.Errorf("cursor %s is no audit entry id: %w", cursor, ErrInvalidCursor)
+		}
+	}
+	if limit <= 0 {
+		limit = lobby_history_default_size
+	}
+	if limit > lobby_history_max_size {
+		limit = lobby_history_max_size
+	}
+
+	entries, err := tx.dbTx.GetLobbyAuditEntriesAfter(lobbyId, afterSequence, limit+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("something went wrong while loading history of lobby [%v]: %v", lobbyId, err)
+	}
+
+	nextCursor := ""
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextCursor = strconv.FormatInt(entries[limit-1].Sequence, 10)
+	}
+	return mapToAuditEntries(entries), nextCursor, nil
+}
+
+// lobbySettingsChanges lists the settings which differ between the stored lobby and the update. The password itself is never recorded
+func lobbySettingsChanges(dbLobby *db.Lobby, lobby *Lobby) map[string]interface{} {
+	changes := make(map[string]interface{})
+	if dbLobby.Name != lobby.Name {
+		changes["name"] = lobby.Name
+	}
+	if (dbLobby.Password != "") != (lobby.Password != "") {
+		changes["password_protected"] = lobby.Password != ""
+	}
+	if lobby.Visibility != "" && dbLobby.Visibility != lobby.Visibility {
+		changes["visibility"] = lobby.Visibility
+	}
+	if dbLobby.Difficulty != lobby.Difficulty {
+		changes["difficulty"] = lobby.Difficulty
+	}
+	if dbLobby.MissionLength != lobby.MissionLength {
+		changes["mission_length"] = lobby.MissionLength
+	}
+	if dbLobby.NumberOfCrewMembers != lobby.NumberOfCrewMembers {
+		changes["number_of_crew_members"] = lobby.NumberOfCrewMembers
+	}
+	if dbLobby.MaxPlayers != lobby.MaxPlayers {
+		changes["max_players"] = lobby.MaxPlayers
+	}
+	if !reflect.DeepEqual(dbLobby.ExpansionPacks, lobby.ExpansionPacks) {
+		changes["expansion_packs"] = lobby.ExpansionPacks
+	}
+	if !reflect.DeepEqual(dbLobby.Payload, lobby.Payload) {
+		changes["payload"] = lobby.Payload
+	}
+	if lobby.AfkPolicies != nil && !reflect.DeepEqual(mapToAfkPolicies(dbLobby.AfkPolicies), lobby.AfkPolicies) {
+		changes["afk_policies"] = lobby.AfkPolicies
+	}
+	return changes
+}
+
+func mapToAuditEntries(dbEntries []*db.LobbyAuditEntry) []*AuditEntry {
+	entries := make([]*AuditEntry, len(dbEntries))
+	for index, entry := range dbEntries {
+		entries[index] = &AuditEntry{ID: entry.Sequence, LobbyId: entry.LobbyId, Topic: entry.Topic, ActorId: entry.ActorId, CorrelationId: entry.CorrelationId, Payload: entry.Payload, CreatedAt: entry.CreatedAt}
+	}
+	return entries
+}
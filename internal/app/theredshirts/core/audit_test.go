@@ -0,0 +1,109 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func historyTopics(t *testing.T, core *CoreFacade, lobbyId uuid.UUID) []string {
+	entries, _, err := AdminFacade{core: core}.GetLobbyHistory(newTestContext(), lobbyId, "", 0)
+	assert.Nil(t, err)
+	topics := make([]string, len(entries))
+	for index, entry := range entries {
+		topics[index] = entry.Topic
+	}
+	return topics
+}
+
+func TestGetLobbyHistory_RecordsLobbyLifecycle(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	bannedId := uuid.New()
+
+	ownerContext := newTestContext()
+	ownerContext.PlayerId = lobby.Owner.ID
+	assert.Nil(t, core.KickPlayer(ownerContext, lobby.ID, player.ID, lobby.Owner.ID, "afk", false))
+	assert.Nil(t, core.KickPlayer(ownerContext, lobby.ID, bannedId, lobby.Owner.ID, "cheating", true))
+	assert.Nil(t, core.DeleteLobby(ownerContext, lobby.ID, lobby.Owner.ID))
+
+	_, _, err := core.GetLobbyHistory(newTestContext(), lobby.ID, lobby.Owner.ID, "", 0)
+	assert.ErrorIs(t, err, ErrLobbyNotFound)
+
+	entries, nextCursor, err := AdminFacade{core: core}.GetLobbyHistory(newTestContext(), lobby.ID, "", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, nextCursor)
+	assert.Equal(t, []string{LOBBY_CREATED, PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_KICKED, PLAYER_KICKED, PLAYER_LEAVES_LOBBY, LOBBY_DELETED}, historyTopics(t, core, lobby.ID))
+
+	kicked := entries[3]
+	assert.Equal(t, lobby.Owner.ID, kicked.ActorId)
+	assert.Equal(t, ownerContext.CorrelationId, kicked.CorrelationId)
+	assert.Equal(t, map[string]interface{}{"player_id": player.ID, "reason": "afk", "banned": false}, kicked.Payload)
+	assert.Equal(t, bannedId, entries[4].Payload["player_id"])
+	assert.Equal(t, uuid.Nil, entries[0].ActorId)
+
+	assert.NotContains(t, storedTopics(t, core), LOBBY_CREATED)
+	assert.NotContains(t, storedTopics(t, core), LOBBY_DELETED)
+}
+
+func TestGetLobbyHistory_RecordsSettingsChanges(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	lobby.Name = "Renamed Lobby"
+	lobby.Password = ""
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	entries, _, err := core.GetLobbyHistory(newTestContext(), lobby.ID, lobby.Owner.ID, "", 0)
+	assert.Nil(t, err)
+	updated := entries[len(entries)-1]
+	assert.Equal(t, PLAYER_UPDATES_LOBBY, updated.Topic)
+	assert.Equal(t, map[string]interface{}{"name": "Renamed Lobby", "password_protected": false}, updated.Payload)
+	assert.Equal(t, map[string]interface{}{}, storedMessages(t, core)[1].Payload)
+}
+
+func TestGetLobbyHistory_Pagination(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	entries, nextCursor, err := core.GetLobbyHistory(newTestContext(), lobby.ID, lobby.Owner.ID, "", 2)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.NotEmpty(t, nextCursor)
+
+	entries, nextCursor, err = core.GetLobbyHistory(newTestContext(), lobby.ID, lobby.Owner.ID, nextCursor, 2)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, PLAYER_JOINS_LOBBY, entries[0].Topic)
+	assert.Empty(t, nextCursor)
+
+	_, _, err = core.GetLobbyHistory(newTestContext(), lobby.ID, lobby.Owner.ID, "no-id", 2)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestGetLobbyHistory_NotMember(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	_, _, err := core.GetLobbyHistory(newTestContext(), lobby.ID, uuid.New(), "", 0)
+	assert.ErrorIs(t, err, ErrNotLobbyMember)
+
+	entries, _, err := core.GetLobbyHistory(newTestContext(), lobby.ID, player.ID, "", 0)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestGetLobbyHistory_SequencePerLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	entries, _, err := core.GetLobbyHistory(newTestContext(), lobby.ID, lobby.Owner.ID, "", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, []int64{entries[0].ID, entries[1].ID, entries[2].ID})
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+func (core CoreFacade) KickPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, ownerId uuid.UUID, reason string, ban bool) error {
+	context.Logger.Debugf("Kicking player [%v] from lobby [%v], ban [%t]", playerId, lobbyId, ban)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+	if err := core.kickPlayer(context, tx, lobbyId, playerId, ownerId, reason, ban); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+// kickPlayer removes the player from the lobby. A banned player can't join the lobby again, even if they weren't part of it yet
+func (core CoreFacade) kickPlayer(context *util.Context, tx *transaction, lobbyId uuid.UUID, playerId uuid.UUID, ownerId uuid.UUID, reason string, ban bool) error {
+	if _, err := core.getOwnedLobby(tx, lobbyId, ownerId); err != nil {
+		return err
+	}
+
+	if playerId == ownerId {
+		return fmt.Errorf("owner [%v] can't kick themselves from lobby [%v]: %w", ownerId, lobbyId, ErrInvalidState)
+	}
+	return core.removeKickedPlayer(context, tx, lobbyId, playerId, ownerId, reason, ban)
+}
+
+// removeKickedPlayer bans and removes the player without checking who kicks them. The sender is uuid.Nil if the server kicks the player
+func (core CoreFacade) removeKickedPlayer(context *util.Context, tx *transaction, lobbyId uuid.UUID, playerId uuid.UUID, senderPlayerId uuid.UUID, reason string, ban bool) error {
+	if ban {
+		if err := tx.dbTx.CreateLobbyBan(&db.LobbyBan{LobbyId: lobbyId, PlayerId: playerId, Reason: reason, CreatedAt: time.Now()}); err != nil {
+			return fmt.Errorf("something went wrong while banning player [%v] from lobby [%v]: %v", playerId, lobbyId, err)
+		}
+	}
+
+	player, err := tx.dbTx.GetPlayerById(playerId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading player [%v] from database: %v", playerId, err)
+	}
+
+	if player == nil || player.LobbyId != lobbyId {
+		context.Logger.Debugf("Player [%v] is not part of lobby [%v]", playerId, lobbyId)
+		if ban {
+			tx.messages = append(tx.messages, &message{senderPlayerId: senderPlayerId, lobbyId: lobbyId, topic: PLAYER_KICKED, auditOnly: true, payload: map[string]interface{}{"player_id": playerId, "reason": reason, "banned": ban}})
+		}
+		return nil
+	}
+
+	if err := tx.dbTx.DeletePlayer(playerId); err != nil {
+		return fmt.Errorf("error while deleting player [%v] from database: %v", playerId, err)
+	}
+
+	if err := tx.dbTx.DeleteReconnectToken(playerId); err != nil {
+		return fmt.Errorf("error while deleting reconnect token of player [%v]: %v", playerId, err)
+	}
+	if err := core.touchLobby(tx, lobbyId); err != nil {
+		return err
+	}
+
+	tx.messages = append(tx.messages, &message{senderPlayerId: senderPlayerId, lobbyId: lobbyId, topic: PLAYER_KICKED, payload: map[string]interface{}{"player_id": playerId, "reason": reason, "banned": ban}})
+	return nil
+}
+
+func (core CoreFacade) GetBans(context *util.Context, lobbyId uuid.UUID, ownerId uuid.UUID) ([]*Ban, error) {
+	context.Logger.Debugf("Get bans of lobby [%v]", lobbyId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	if _, err := core.getOwnedLobby(tx, lobbyId, ownerId); err != nil {
+		return nil, err
+	}
+
+	bans, err := tx.dbTx.GetLobbyBans(lobbyId)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading bans of lobby [%v] from database: %v", lobbyId, err)
+	}
+	return mapToBans(bans), core.commit(tx, context)
+}
+
+func (core CoreFacade) getOwnedLobby(tx *transaction, lobbyId uuid.UUID, ownerId uuid.UUID) (*db.Lobby, error) {
+	lobby, err := tx.dbTx.GetLobbyById(lobbyId)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading lobby [%v] from database: %v", lobbyId, err)
+	}
+
+	if lobby == nil {
+		return nil, ErrLobbyNotFound
+	}
+
+	if lobby.Owner != ownerId {
+		return nil, fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]: %w", ownerId, lobby.Owner, lobbyId, ErrNotOwner)
+	}
+	return lobby, nil
+}
+
+func mapToBans(dbBans []*db.LobbyBan) []*Ban {
+	bans := make([]*Ban, len(dbBans))
+	for index, ban := range dbBans {
+		bans[index] = &Ban{LobbyId: ban.LobbyId, PlayerId: ban.PlayerId, Reason: ban.Reason, CreatedAt: ban.CreatedAt}
+	}
+	return bans
+}
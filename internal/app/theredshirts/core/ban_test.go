@@ -0,0 +1,76 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKickPlayer_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	assert.Nil(t, core.KickPlayer(newTestContext(), lobby.ID, player.ID, lobby.Owner.ID, "afk", false))
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+	messages := storedMessages(t, core)
+	kicked := messages[len(messages)-1]
+	assert.Equal(t, PLAYER_KICKED, kicked.Topic)
+	assert.Equal(t, lobby.Owner.ID, kicked.SenderPlayerId)
+	assert.Equal(t, map[string]interface{}{"player_id": player.ID, "reason": "afk", "banned": false}, kicked.Payload)
+
+	assert.Nil(t, joinWithPassword(core, player, test_password))
+}
+
+func TestKickPlayer_Ban(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	assert.Nil(t, core.KickPlayer(newTestContext(), lobby.ID, player.ID, lobby.Owner.ID, "cheating", true))
+
+	_, err := core.CreatePlayer(newTestContext(), player, test_password)
+	assert.ErrorIs(t, err, ErrPlayerBanned)
+
+	bans, err := core.GetBans(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Len(t, bans, 1)
+	assert.Equal(t, player.ID, bans[0].PlayerId)
+	assert.Equal(t, "cheating", bans[0].Reason)
+}
+
+func TestKickPlayer_BanBeforeJoining(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	playerId := uuid.New()
+
+	assert.Nil(t, core.KickPlayer(newTestContext(), lobby.ID, playerId, lobby.Owner.ID, "", true))
+
+	_, err := core.CreatePlayer(newTestContext(), &Player{ID: playerId, Name: "Player", LobbyId: lobby.ID}, test_password)
+	assert.ErrorIs(t, err, ErrPlayerBanned)
+	assert.NotContains(t, storedTopics(t, core), PLAYER_KICKED)
+}
+
+func TestKickPlayer_NotOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.KickPlayer(newTestContext(), lobby.ID, lobby.Owner.ID, player.ID, "", true)
+	assert.ErrorIs(t, err, ErrNotOwner)
+
+	_, err = core.GetBans(newTestContext(), lobby.ID, player.ID)
+	assert.ErrorIs(t, err, ErrNotOwner)
+}
+
+func TestKickPlayer_Owner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	err := core.KickPlayer(newTestContext(), lobby.ID, lobby.Owner.ID, lobby.Owner.ID, "", false)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
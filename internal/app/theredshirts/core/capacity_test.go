@@ -0,0 +1,115 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	concurrent_joins = 20
+	count_delay      = 5 * time.Millisecond
+)
+
+// slowCountDB pauses after counting the players of a lobby, so concurrent joins overlap between counting and inserting
+type (
+	slowCountDB struct {
+		db.DB
+	}
+
+	slowCountTx struct {
+		db.DBTx
+	}
+)
+
+func (connection *slowCountDB) StartTransaction() (db.DBTx, error) {
+	tx, err := connection.DB.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &slowCountTx{DBTx: tx}, nil
+}
+
+func (tx *slowCountTx) GetNumberOfPlayersInLobby(lobbyId uuid.UUID) (int, error) {
+	count, err := tx.DBTx.GetNumberOfPlayersInLobby(lobbyId)
+	time.Sleep(count_delay)
+	return count, err
+}
+
+func newSlowCountCore(t *testing.T) *CoreFacade {
+	core := newTestCore(t)
+	core.db = &slowCountDB{DB: core.db}
+	return core
+}
+
+func countPlayers(t *testing.T, core *CoreFacade, lobbyId uuid.UUID) int {
+	lobby, err := core.GetLobby(newTestContext(), lobbyId)
+	if err != nil {
+		t.Fatalf("error while loading lobby: %v", err)
+	}
+	count := 0
+	for _, player := range lobby.Players {
+		if !player.Spectator {
+			count++
+		}
+	}
+	return count
+}
+
+func TestCreatePlayer_ConcurrentJoinsDontExceedCapacity(t *testing.T) {
+	core := newSlowCountCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	var joined, full int64
+	var wg sync.WaitGroup
+	for i := 0; i < concurrent_joins; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := core.CreatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}, test_password)
+			switch {
+			case err == nil:
+				atomic.AddInt64(&joined, 1)
+			case errors.Is(err, ErrLobbyFull):
+				atomic.AddInt64(&full, 1)
+			default:
+				t.Errorf("unexpected error while joining: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(3), joined)
+	assert.Equal(t, int64(concurrent_joins-3), full)
+	assert.Equal(t, 4, countPlayers(t, core, lobby.ID))
+}
+
+func TestUpdatePlayer_ConcurrentSwitchesFromSpectatorDontExceedCapacity(t *testing.T) {
+	core := newSlowCountCore(t)
+	lobby := createTestLobby(t, core, 4)
+	spectators := make([]*Player, concurrent_joins)
+	for i := range spectators {
+		spectators[i] = joinTestPlayer(t, core, lobby.ID, true)
+	}
+
+	var wg sync.WaitGroup
+	for _, spectator := range spectators {
+		wg.Add(1)
+		go func(spectator *Player) {
+			defer wg.Done()
+			player := &Player{ID: spectator.ID, Name: spectator.Name, Spectator: false}
+			if err := core.UpdatePlayer(newTestContext(), player, spectator.ID); err != nil && !errors.Is(err, ErrLobbyFull) {
+				t.Errorf("unexpected error while switching from spectator: %v", err)
+			}
+		}(spectator)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4, countPlayers(t, core, lobby.ID))
+}
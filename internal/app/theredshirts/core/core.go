@@ -3,21 +3,40 @@ package core
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/adapter"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/go-co-op/gocron"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type (
 
 	//Facade
 	CoreFacade struct {
-		db             db.DB
-		messageAdapter *adapter.MessageAdapter
-		lobbyPlayerId  uuid.UUID
+		db                    db.DB
+		messageSink           adapter.MessageSink
+		lobbyPlayerId         uuid.UUID
+		outboxMaxBackoff      time.Duration
+		outboxFailedAttempts  int
+		eventRetention        time.Duration
+		eventBroker           *eventBroker
+		presence              *presence
+		leader                *leader
+		ownerSuccession       string
+		minPlayers            int
+		passwordCost          int
+		joinMaxFailedAttempts int
+		joinLockout           time.Duration
+		idempotencyKeyTTL     time.Duration
+		afkPolicies           map[string]*AfkPolicy
+		scavengerInterval     time.Duration
+		reconnectWindow       time.Duration
+		schedulers            []*gocron.Scheduler
 	}
 
 	transaction struct {
@@ -28,15 +47,35 @@ type (
 	Core interface {
 		CreateLobby(context *util.Context, lobby *Lobby) error
 		GetLobby(context *util.Context, lobbyId uuid.UUID) (*Lobby, error)
+		GetLobbyJoinEventId(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID) (int64, error)
 		UpdateLobby(context *util.Context, lobby *Lobby, playerId uuid.UUID) error
 		UpdateLobbyStatus(context *util.Context, lobby *Lobby, playerId uuid.UUID) error
-		GetLobbies(context *util.Context) ([]*Lobby, error)
+		GetLobbies(context *util.Context, filter *LobbyFilter) ([]*Lobby, string, error)
 		DeleteLobby(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID) error
-		CreatePlayer(context *util.Context, join *Player, password string) error
+		CreatePlayer(context *util.Context, join *Player, password string) (string, error)
+		ReconnectPlayer(context *util.Context, playerId uuid.UUID, token string) (string, error)
 		GetPlayer(context *util.Context, playerId uuid.UUID) (*Player, error)
 		UpdatePlayer(context *util.Context, player *Player, playerId uuid.UUID) error
 		UpdatePlayerLastRefresh(context *util.Context, playerId uuid.UUID) error
+		UpdatePlayerReady(context *util.Context, playerId uuid.UUID, ready bool) error
 		DeletePlayer(context *util.Context, playerId uuid.UUID) error
+		TransferOwnership(context *util.Context, lobbyId uuid.UUID, newOwnerId uuid.UUID, ownerId uuid.UUID) error
+		KickPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, ownerId uuid.UUID, reason string, ban bool) error
+		GetBans(context *util.Context, lobbyId uuid.UUID, ownerId uuid.UUID) ([]*Ban, error)
+		CreateInvite(context *util.Context, lobbyId uuid.UUID, ownerId uuid.UUID, expiresAt *time.Time, maxUses int) (*Invite, error)
+		GetInvites(context *util.Context, lobbyId uuid.UUID, ownerId uuid.UUID) ([]*Invite, error)
+		RevokeInvite(context *util.Context, lobbyId uuid.UUID, code string, ownerId uuid.UUID) error
+		JoinWithInvite(context *util.Context, code string, player *Player) error
+		GetLobbyEvents(context *util.Context, lobbyId uuid.UUID, afterEventId int64) ([]*Event, error)
+		GetLastLobbyEventId(context *util.Context, lobbyId uuid.UUID) (int64, error)
+		SubscribeLobbyEvents(lobbyId uuid.UUID) (<-chan struct{}, func())
+		ConnectPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID) (func(), error)
+		GetLobbyHistory(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID, cursor string, limit int) ([]*AuditEntry, string, error)
+		StartIdempotentRequest(context *util.Context, playerId uuid.UUID, key string, requestHash string) (*IdempotentResponse, error)
+		FinishIdempotentRequest(context *util.Context, playerId uuid.UUID, key string, response *IdempotentResponse) error
+		AbortIdempotentRequest(context *util.Context, playerId uuid.UUID, key string) error
+		CheckHealth(context *util.Context) []*HealthCheck
+		Shutdown(context *util.Context)
 	}
 
 	//Objects
@@ -53,44 +92,243 @@ type (
 		ExpansionPacks      []string
 		Players             []*Player
 		Payload             map[string]interface{}
+		Visibility          string
+		AfkPolicies         map[string]*AfkPolicy
+		CreatedAt           time.Time
+		Version             int
+	}
+
+	// AfkPolicy decides after how many seconds without refresh a player is warned and removed. A reserved seat keeps
+	// a dropped player in a playing lobby for some more seconds
+	AfkPolicy struct {
+		WarningSeconds      int
+		RemovalSeconds      int
+		ReservedSeatSeconds int
+	}
+
+	// LobbyFilter selects a page of the public lobbies. Zero values don't filter
+	LobbyFilter struct {
+		Status            string
+		Difficulty        int
+		MissionLength     int
+		ExpansionPacks    []string
+		FreeSlots         int
+		PasswordProtected *bool
+		Sort              string
+		Descending        bool
+		Limit             int
+		Cursor            string
 	}
 
 	Player struct {
 		ID          uuid.UUID
 		Name        string
 		LastRefresh time.Time
+		JoinedAt    time.Time
 		LobbyId     uuid.UUID
 		Spectator   bool
+		Ready       bool
 		Payload     map[string]interface{}
+		Version     int
+	}
+
+	OutboxMessage struct {
+		ID             int64
+		LobbyId        uuid.UUID
+		SenderPlayerId uuid.UUID
+		CorrelationId  string
+		Topic          string
+		Payload        map[string]interface{}
+		CreatedAt      time.Time
+		Attempts       int
+		NextAttempt    time.Time
+		LastError      string
+	}
+
+	Ban struct {
+		LobbyId   uuid.UUID
+		PlayerId  uuid.UUID
+		Reason    string
+		CreatedAt time.Time
+	}
+
+	Invite struct {
+		Code      string
+		LobbyId   uuid.UUID
+		CreatedAt time.Time
+		ExpiresAt *time.Time
+		MaxUses   int
+		Uses      int
+	}
+
+	AuditEntry struct {
+		ID            int64
+		LobbyId       uuid.UUID
+		Topic         string
+		ActorId       uuid.UUID
+		CorrelationId string
+		Payload       map[string]interface{}
+		CreatedAt     time.Time
+	}
+
+	IdempotentResponse struct {
+		StatusCode  int
+		ContentType string
+		Body        []byte
+	}
+
+	// HealthCheck tells whether a dependency can be reached. Err is nil when it is healthy
+	HealthCheck struct {
+		Name string
+		Err  error
+	}
+
+	Event struct {
+		ID        int64
+		LobbyId   uuid.UUID
+		Topic     string
+		Payload   map[string]interface{}
+		Lobby     *Lobby
+		CreatedAt time.Time
 	}
 )
 
 const (
-	lobby_open    = "OPEN"
-	lobby_playing = "PLAYING"
+	lobby_open     = "OPEN"
+	lobby_starting = "STARTING"
+	lobby_playing  = "PLAYING"
+	lobby_finished = "FINISHED"
+	lobby_aborted  = "ABORTED"
+
+	// Unlisted lobbies are hidden from the lobby list, private lobbies can only be joined with an invite
+	visibility_public   = "PUBLIC"
+	visibility_unlisted = "UNLISTED"
+	visibility_private  = "PRIVATE"
+
+	lobby_page_max_size = 100
+
+	correlation_id_log_field = "X-Correlation-ID"
 )
 
 var (
-	ErrWrongLobbyPassword = errors.New("wrong password")
-	ErrLobbyFull          = errors.New("lobby is full")
+	ErrLobbyNotFound           = errors.New("lobby not found")
+	ErrPlayerNotFound          = errors.New("player not found")
+	ErrLobbyConflict           = errors.New("lobby already exists with different parameters")
+	ErrPlayerConflict          = errors.New("player already exists with different parameters")
+	ErrInvalidState            = errors.New("invalid state")
+	ErrWrongLobbyPassword      = errors.New("wrong password")
+	ErrLobbyFull               = errors.New("lobby is full")
+	ErrPlayerBanned            = errors.New("player is banned from lobby")
+	ErrNotOwner                = errors.New("player is not owner of lobby")
+	ErrPlayerNotInLobby        = errors.New("player is not part of lobby")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrNotEnoughPlayers        = errors.New("not enough players in lobby")
+	ErrLobbyPlaying            = errors.New("lobby is playing")
+	ErrPlayersNotReady         = errors.New("not all players are ready")
+	ErrTooManyAttempts         = errors.New("too many failed attempts")
+	ErrInviteNotFound          = errors.New("invite not found")
+	ErrInviteExpired           = errors.New("invite expired")
+	ErrLobbyPrivate            = errors.New("lobby is private")
+	ErrInvalidCursor           = errors.New("invalid cursor")
+	ErrVersionMismatch         = errors.New("version does not match")
+	ErrIdempotencyKeyInUse     = errors.New("idempotency key is in use")
+	ErrIdempotencyKeyMismatch  = errors.New("idempotency key was used for another request")
+	ErrReconnectTokenInvalid   = errors.New("reconnect token is invalid")
+	ErrReconnectTokenExpired   = errors.New("reconnect token expired")
+	ErrNotLobbyMember          = errors.New("player is not member of lobby")
+	ErrStatusNotUpdatable      = errors.New("status can only be changed with the status endpoint")
 )
 
 func NewCore() (Core, error) {
+	core, err := newCoreFacade()
+	if err != nil {
+		return nil, err
+	}
+	core.schedulers = []*gocron.Scheduler{core.startCleanUp(), core.startOutboxDispatcher(), core.startPresenceFlush()}
+	return core, nil
+}
+
+// newCoreFacade connects to the database and loads the configuration, without starting the schedulers
+func newCoreFacade() (*CoreFacade, error) {
 	db, err := db.NewConnection()
 	if err != nil {
 		return nil, fmt.Errorf("error while initializing database: %v", err)
 	}
-	messageAdapter, err := adapter.NewMessageAdapter()
+	messageSink, err := adapter.NewMessageSink()
 	if err != nil {
-		return nil, fmt.Errorf("erro while initializing messageadapter: %v", err)
+		return nil, fmt.Errorf("error while initializing message sink: %v", err)
 	}
 	lobbyPlayerId, err := util.GetEnvUUID("LOBBY_USER")
 	if err != nil {
 		return nil, fmt.Errorf("error while loading lobby user from env: %v", err)
 	}
-	core := &CoreFacade{db: db, messageAdapter: messageAdapter, lobbyPlayerId: lobbyPlayerId}
-	core.startCleanUp()
-	return core, nil
+	outboxMaxBackoff, err := util.GetEnvIntWithFallback("OUTBOX_MAX_BACKOFF_SECONDS", 300)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading outbox max backoff from env: %v", err)
+	}
+	outboxFailedAttempts, err := util.GetEnvIntWithFallback("OUTBOX_FAILED_ATTEMPTS", 5)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading outbox failed attempts from env: %v", err)
+	}
+	eventRetention, err := util.GetEnvIntWithFallback("EVENT_RETENTION_SECONDS", 3600)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading event retention from env: %v", err)
+	}
+	ownerSuccession := strings.ToLower(util.GetEnvWithFallback("OWNER_SUCCESSION", succession_longest_present))
+	if ownerSuccession != succession_longest_present && ownerSuccession != succession_players_only {
+		return nil, fmt.Errorf("no owner succession %s found", ownerSuccession)
+	}
+	minPlayers, err := util.GetEnvIntWithFallback("LOBBY_MIN_PLAYERS", 2)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading minimum number of players from env: %v", err)
+	}
+	passwordCost, err := util.GetEnvIntWithFallback("PASSWORD_HASH_COST", bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading password hash cost from env: %v", err)
+	}
+	joinMaxFailedAttempts, err := util.GetEnvIntWithFallback("JOIN_MAX_FAILED_ATTEMPTS", 5)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading maximum failed join attempts from env: %v", err)
+	}
+	joinLockout, err := util.GetEnvIntWithFallback("JOIN_LOCKOUT_SECONDS", 300)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading join lockout from env: %v", err)
+	}
+	idempotencyKeyTTL, err := util.GetEnvIntWithFallback("IDEMPOTENCY_KEY_TTL_SECONDS", 86400)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading idempotency key ttl from env: %v", err)
+	}
+	afkPolicies, err := loadAfkPolicies()
+	if err != nil {
+		return nil, err
+	}
+	scavengerInterval, err := util.GetEnvIntWithFallback("SCAVENGER_INTERVAL_SECONDS", 10)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading scavenger interval from env: %v", err)
+	}
+	if scavengerInterval <= 0 {
+		return nil, fmt.Errorf("scavenger interval %d has to be positive", scavengerInterval)
+	}
+	reconnectWindow, err := util.GetEnvIntWithFallback("RECONNECT_WINDOW_SECONDS", 300)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading reconnect window from env: %v", err)
+	}
+	return &CoreFacade{db: db, messageSink: messageSink, lobbyPlayerId: lobbyPlayerId, outboxMaxBackoff: time.Duration(outboxMaxBackoff) * time.Second, outboxFailedAttempts: outboxFailedAttempts, eventRetention: time.Duration(eventRetention) * time.Second, eventBroker: newEventBroker(), presence: newPresence(), leader: newLeader(), ownerSuccession: ownerSuccession, minPlayers: minPlayers, passwordCost: passwordCost, joinMaxFailedAttempts: joinMaxFailedAttempts, joinLockout: time.Duration(joinLockout) * time.Second, idempotencyKeyTTL: time.Duration(idempotencyKeyTTL) * time.Second, afkPolicies: afkPolicies, scavengerInterval: time.Duration(scavengerInterval) * time.Second, reconnectWindow: time.Duration(reconnectWindow) * time.Second}, nil
+}
+
+// Shutdown stops the scavenger and the other schedulers, writes pending presence and delivers the messages waiting in the outbox.
+// The database is closed afterwards, so the core can't be used anymore
+func (core CoreFacade) Shutdown(context *util.Context) {
+	context.Logger.Info("Shutdown core")
+	for _, scheduler := range core.schedulers {
+		scheduler.Stop()
+	}
+	core.resignLeader()
+	if err := core.flushPresence(context); err != nil {
+		context.Logger.Warnf("Error while flushing presence: %v", err)
+	}
+	core.deliverOutboxMessages(context)
+	core.db.Close()
 }
 
 func (core CoreFacade) startTransaction() (*transaction, error) {
@@ -102,14 +340,22 @@ func (core CoreFacade) startTransaction() (*transaction, error) {
 }
 
 func (core CoreFacade) commit(tx *transaction, context *util.Context) error {
+	if err := core.storeAuditEntries(context, tx); err != nil {
+		return err
+	}
+	// the events lock the sequence of their lobby, so the outbox messages stored afterwards get their ids in commit order
+	lobbyIds, err := core.storeEvents(tx)
+	if err != nil {
+		return err
+	}
+	if err := core.storeMessages(context, tx); err != nil {
+		return err
+	}
 	if err := tx.dbTx.Commit(); err != nil {
-		return fmt.Errorf("error while commiting transaction: %v", err)
+		return fmt.Errorf("error while commiting transaction: %w", wrapVersionConflict(err))
 	}
-	for _, message := range tx.messages {
-		err := core.createMessage(context, message)
-		if err != nil {
-			context.Logger.Warnf("Error while creating message after transaction: %v", err)
-		}
+	for _, lobbyId := range lobbyIds {
+		core.eventBroker.notify(lobbyId)
 	}
 	return nil
 }
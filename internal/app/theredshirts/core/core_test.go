@@ -0,0 +1,134 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/adapter"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const test_password = "secret"
+
+func newTestCore(t *testing.T) *CoreFacade {
+	t.Setenv("DATABASE", "inmemory")
+	database, err := db.NewConnection()
+	if err != nil {
+		t.Fatalf("error while creating database: %v", err)
+	}
+	return &CoreFacade{db: database, messageSink: adapter.NewRecordingSink(), lobbyPlayerId: uuid.New(), outboxMaxBackoff: time.Minute, outboxFailedAttempts: 5, eventRetention: time.Hour, eventBroker: newEventBroker(), presence: newPresence(), leader: newLeader(), ownerSuccession: succession_longest_present, minPlayers: 2, passwordCost: bcrypt.MinCost, joinMaxFailedAttempts: 3, joinLockout: time.Minute, idempotencyKeyTTL: time.Hour, afkPolicies: newTestAfkPolicies(), scavengerInterval: time.Second, reconnectWindow: time.Minute}
+}
+
+func newTestAfkPolicies() map[string]*AfkPolicy {
+	policies := make(map[string]*AfkPolicy, len(lobbyStatuses))
+	for _, status := range lobbyStatuses {
+		policies[status] = &AfkPolicy{WarningSeconds: 5, RemovalSeconds: 15}
+	}
+	return policies
+}
+
+func newTestContext() *util.Context {
+	correlationId := uuid.NewString()
+	return &util.Context{CorrelationId: correlationId, Logger: log.WithField("test", correlationId)}
+}
+
+func newTestLobby(maxPlayers int) *Lobby {
+	owner := &Player{ID: uuid.New(), Name: "Owner"}
+	return &Lobby{ID: uuid.New(), Name: "Some Lobby", Owner: owner, Password: test_password, Difficulty: 1, MissionLength: 1, NumberOfCrewMembers: 1, MaxPlayers: maxPlayers}
+}
+
+func createTestLobby(t *testing.T, core *CoreFacade, maxPlayers int) *Lobby {
+	lobby := newTestLobby(maxPlayers)
+	if err := core.CreateLobby(newTestContext(), lobby); err != nil {
+		t.Fatalf("error while creating lobby: %v", err)
+	}
+	return lobby
+}
+
+func joinTestPlayer(t *testing.T, core *CoreFacade, lobbyId uuid.UUID, spectator bool) *Player {
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobbyId, Spectator: spectator}
+	if _, err := core.CreatePlayer(newTestContext(), player, test_password); err != nil {
+		t.Fatalf("error while joining lobby: %v", err)
+	}
+	return player
+}
+
+// joinWithPassword joins the lobby and drops the reconnect token
+func joinWithPassword(core *CoreFacade, player *Player, password string) error {
+	_, err := core.CreatePlayer(newTestContext(), player, password)
+	return err
+}
+
+// storedMessages returns all messages waiting in the outbox, oldest first.
+func storedMessages(t *testing.T, core *CoreFacade) []*db.OutboxMessage {
+	tx, err := core.db.StartTransaction()
+	if err != nil {
+		t.Fatalf("error while starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	messages, err := tx.GetOutboxMessages()
+	if err != nil {
+		t.Fatalf("error while loading outbox: %v", err)
+	}
+	return messages
+}
+
+func storedTopics(t *testing.T, core *CoreFacade) []string {
+	messages := storedMessages(t, core)
+	topics := make([]string, len(messages))
+	for index, message := range messages {
+		topics[index] = message.Topic
+	}
+	return topics
+}
+
+func TestCommit_StoresMessagesInOutbox(t *testing.T) {
+	core := newTestCore(t)
+	context := newTestContext()
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	lobbyId := uuid.New()
+	tx.messages = append(tx.messages, &message{lobbyId: lobbyId, topic: PLAYER_LAGGING, payload: map[string]interface{}{}})
+	assert.Nil(t, core.commit(tx, context))
+
+	messages := storedMessages(t, core)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, lobbyId, messages[0].LobbyId)
+	assert.Equal(t, core.lobbyPlayerId, messages[0].SenderPlayerId)
+	assert.Equal(t, context.CorrelationId, messages[0].CorrelationId)
+}
+
+func TestRollback_DiscardsMessages(t *testing.T) {
+	core := newTestCore(t)
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	tx.messages = append(tx.messages, &message{lobbyId: uuid.New(), topic: PLAYER_LAGGING, payload: map[string]interface{}{}})
+	assert.Nil(t, core.rollback(tx))
+
+	assert.Empty(t, storedMessages(t, core))
+}
+
+func TestShutdown_FlushesPendingMessagesAndPresence(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	lastRefresh := time.Now().Add(time.Minute)
+	core.presence.refresh(player.ID, lastRefresh)
+	topics := storedTopics(t, core)
+	assert.NotEmpty(t, topics)
+
+	core.Shutdown(newTestContext())
+
+	assert.Empty(t, storedMessages(t, core))
+	assert.Equal(t, topics, core.messageSink.(*adapter.RecordingSink).Topics())
+	storedPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.WithinDuration(t, lastRefresh, storedPlayer.LastRefresh, time.Millisecond)
+}
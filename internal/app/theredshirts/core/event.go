@@ -0,0 +1,165 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+const (
+	lobby_event_batch_size = 100
+)
+
+type eventBroker struct {
+	mutex       sync.Mutex
+	subscribers map[uuid.UUID]map[chan struct{}]bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{subscribers: make(map[uuid.UUID]map[chan struct{}]bool)}
+}
+
+func (broker *eventBroker) subscribe(lobbyId uuid.UUID) chan struct{} {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	notification := make(chan struct{}, 1)
+	if broker.subscribers[lobbyId] == nil {
+		broker.subscribers[lobbyId] = make(map[chan struct{}]bool)
+	}
+	broker.subscribers[lobbyId][notification] = true
+	return notification
+}
+
+func (broker *eventBroker) unsubscribe(lobbyId uuid.UUID, notification chan struct{}) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	delete(broker.subscribers[lobbyId], notification)
+	if len(broker.subscribers[lobbyId]) == 0 {
+		delete(broker.subscribers, lobbyId)
+	}
+}
+
+// notify wakes up all subscribers of the lobby without blocking. Subscribers which were not woken up yet stay notified once
+func (broker *eventBroker) notify(lobbyId uuid.UUID) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+	for notification := range broker.subscribers[lobbyId] {
+		select {
+		case notification <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// storeEvents persists every message of the transaction together with the state of its lobby after the transaction
+func (core CoreFacade) storeEvents(tx *transaction) ([]uuid.UUID, error) {
+	now := time.Now()
+	snapshots := make(map[uuid.UUID]*db.LobbySnapshot)
+	lobbyIds := make([]uuid.UUID, 0)
+	for _, message := range tx.messages {
+		if message.auditOnly {
+			continue
+		}
+		snapshot, ok := snapshots[message.lobbyId]
+		if !ok {
+			var err error
+			snapshot, err = core.loadLobbySnapshot(tx, message.lobbyId)
+			if err != nil {
+				return nil, err
+			}
+			snapshots[message.lobbyId] = snapshot
+			lobbyIds = append(lobbyIds, message.lobbyId)
+		}
+		if err := tx.dbTx.CreateLobbyEvent(&db.LobbyEvent{LobbyId: message.lobbyId, Topic: message.topic, Payload: message.payload, Snapshot: snapshot, CreatedAt: now}); err != nil {
+			return nil, fmt.Errorf("error while storing event with topic %s: %v", message.topic, err)
+		}
+	}
+	return lobbyIds, nil
+}
+
+func (core CoreFacade) loadLobbySnapshot(tx *transaction, lobbyId uuid.UUID) (*db.LobbySnapshot, error) {
+	lobby, err := tx.dbTx.GetLobbyById(lobbyId)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading lobby [%v] for snapshot: %v", lobbyId, err)
+	}
+	if lobby == nil {
+		return nil, nil
+	}
+	players, err := tx.dbTx.GetAllPlayersInLobby(lobbyId)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading players of lobby [%v] for snapshot: %v", lobbyId, err)
+	}
+	lobby.Password = ""
+	return &db.LobbySnapshot{Lobby: lobby, Players: players}, nil
+}
+
+func (core CoreFacade) GetLobbyEvents(context *util.Context, lobbyId uuid.UUID, afterEventId int64) ([]*Event, error) {
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	events, err := tx.dbTx.GetLobbyEventsAfter(lobbyId, afterEventId, lobby_event_batch_size)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading events of lobby [%v]: %v", lobbyId, err)
+	}
+	return mapToEvents(events), core.commit(tx, context)
+}
+
+func (core CoreFacade) GetLastLobbyEventId(context *util.Context, lobbyId uuid.UUID) (int64, error) {
+	tx, err := core.startTransaction()
+	if err != nil {
+		return 0, err
+	}
+	defer core.rollback(tx)
+
+	lastEventId, err := tx.dbTx.GetLastLobbyEventId(lobbyId)
+	if err != nil {
+		return 0, fmt.Errorf("something went wrong while loading last event id of lobby [%v]: %v", lobbyId, err)
+	}
+	return lastEventId, core.commit(tx, context)
+}
+
+// SubscribeLobbyEvents returns a channel which receives a value whenever new events of the lobby were committed by this instance.
+// The returned function has to be called to end the subscription.
+func (core CoreFacade) SubscribeLobbyEvents(lobbyId uuid.UUID) (<-chan struct{}, func()) {
+	notification := core.eventBroker.subscribe(lobbyId)
+	return notification, func() { core.eventBroker.unsubscribe(lobbyId, notification) }
+}
+
+func (core CoreFacade) cleanUpLobbyEvents(tx *transaction) error {
+	if err := tx.dbTx.DeleteLobbyEventsBefore(time.Now().Add(-core.eventRetention)); err != nil {
+		return fmt.Errorf("error while cleaning up lobby events: %v", err)
+	}
+	return nil
+}
+
+func mapToEvents(dbEvents []*db.LobbyEvent) []*Event {
+	events := make([]*Event, len(dbEvents))
+	for index, event := range dbEvents {
+		events[index] = &Event{ID: event.Sequence, LobbyId: event.LobbyId, Topic: event.Topic, Payload: event.Payload, Lobby: mapSnapshotToLobby(event.Snapshot), CreatedAt: event.CreatedAt}
+	}
+	return events
+}
+
+func mapSnapshotToLobby(snapshot *db.LobbySnapshot) *Lobby {
+	if snapshot == nil || snapshot.Lobby == nil {
+		return nil
+	}
+	players := mapToPlayers(snapshot.Players)
+	return mapToLobby(snapshot.Lobby, findPlayer(players, snapshot.Lobby.Owner), players)
+}
+
+func findPlayer(players []*Player, playerId uuid.UUID) *Player {
+	for _, player := range players {
+		if player.ID == playerId {
+			return player
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLobbyEvents_ContainsSnapshot(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	events, err := core.GetLobbyEvents(newTestContext(), lobby.ID, 0)
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, PLAYER_JOINS_LOBBY, events[1].Topic)
+	assert.Equal(t, player.ID, events[1].Payload["player_id"])
+	assert.Len(t, events[1].Lobby.Players, 2)
+	assert.Equal(t, lobby.Owner.ID, events[1].Lobby.Owner.ID)
+	assert.Empty(t, events[1].Lobby.Password)
+}
+
+func TestGetLobbyEvents_AfterEventId(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	lastEventId, err := core.GetLastLobbyEventId(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	events, err := core.GetLobbyEvents(newTestContext(), lobby.ID, lastEventId)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Greater(t, events[0].ID, lastEventId)
+}
+
+func TestGetLobbyEvents_LobbyDeleted(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	lastEventId, err := core.GetLastLobbyEventId(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+
+	events, err := core.GetLobbyEvents(newTestContext(), lobby.ID, lastEventId)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, PLAYER_LEAVES_LOBBY, events[0].Topic)
+	assert.Nil(t, events[0].Lobby)
+}
+
+func TestSubscribeLobbyEvents_NotifiedOnCommit(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	notification, unsubscribe := core.SubscribeLobbyEvents(lobby.ID)
+	defer unsubscribe()
+
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	select {
+	case <-notification:
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not notified")
+	}
+}
+
+func TestSubscribeLobbyEvents_NotNotifiedForOtherLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	notification, unsubscribe := core.SubscribeLobbyEvents(lobby.ID)
+	defer unsubscribe()
+
+	createTestLobby(t, core, 4)
+
+	select {
+	case <-notification:
+		t.Fatal("subscriber was notified for other lobby")
+	default:
+	}
+}
+
+func TestCleanUpLobbyEvents(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	core.eventRetention = -time.Minute
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	assert.Nil(t, core.cleanUpLobbyEvents(tx))
+	assert.Nil(t, core.commit(tx, newTestContext()))
+
+	events, err := core.GetLobbyEvents(newTestContext(), lobby.ID, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+)
+
+const (
+	health_check_database     = "database"
+	health_check_message_sink = "message_sink"
+)
+
+// CheckHealth checks that the database and the message sink can be reached
+func (core CoreFacade) CheckHealth(context *util.Context) []*HealthCheck {
+	checks := []*HealthCheck{
+		{Name: health_check_database, Err: core.db.Ping()},
+		{Name: health_check_message_sink, Err: core.messageSink.Ping(context)},
+	}
+	for _, check := range checks {
+		if check.Err != nil {
+			context.Logger.Warnf("Health check %s failed: %v", check.Name, check.Err)
+		}
+	}
+	return checks
+}
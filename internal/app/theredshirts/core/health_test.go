@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHealth_Healthy(t *testing.T) {
+	core := newTestCore(t)
+
+	checks := core.CheckHealth(newTestContext())
+	assert.Len(t, checks, 2)
+	for _, check := range checks {
+		assert.Nil(t, check.Err, check.Name)
+	}
+}
+
+func TestCheckHealth_MessageSinkUnreachable(t *testing.T) {
+	core := newTestCore(t)
+	sink := newFailingSink()
+	sink.setFailing(true)
+	core.messageSink = sink
+
+	checks := core.CheckHealth(newTestContext())
+	assert.Equal(t, health_check_database, checks[0].Name)
+	assert.Nil(t, checks[0].Err)
+	assert.Equal(t, health_check_message_sink, checks[1].Name)
+	assert.NotNil(t, checks[1].Err)
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+const (
+	idempotency_pending = 0
+)
+
+// StartIdempotentRequest reserves the key for the request. If the request was already answered, the stored response is returned
+// and the request must not be executed again. The reservation lasts as long as a stored response. Only an abort releases it
+// earlier, because a request which reached the handler may have been executed even if its response was never stored
+func (core CoreFacade) StartIdempotentRequest(context *util.Context, playerId uuid.UUID, key string, requestHash string) (*IdempotentResponse, error) {
+	context.Logger.Debugf("Starting idempotent request with key %s", key)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	response, err := core.startIdempotentRequest(tx, playerId, key, requestHash)
+	if err != nil {
+		return nil, err
+	}
+	if err := core.commit(tx, context); err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyAlreadyExists) {
+			return nil, ErrIdempotencyKeyInUse
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+func (core CoreFacade) startIdempotentRequest(tx *transaction, playerId uuid.UUID, key string, requestHash string) (*IdempotentResponse, error) {
+	now := time.Now()
+	reservation := &db.IdempotencyKey{PlayerId: playerId, Key: key, RequestHash: requestHash, StatusCode: idempotency_pending, Body: []byte{}, CreatedAt: now, ExpiresAt: now.Add(core.idempotencyKeyTTL)}
+
+	found, err := tx.dbTx.GetIdempotencyKey(playerId, key)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading idempotency key %s from database: %v", key, err)
+	}
+
+	if found == nil {
+		if err := tx.dbTx.CreateIdempotencyKey(reservation); err != nil {
+			if errors.Is(err, db.ErrIdempotencyKeyAlreadyExists) {
+				return nil, ErrIdempotencyKeyInUse
+			}
+			return nil, fmt.Errorf("something went wrong while creating idempotency key %s: %v", key, err)
+		}
+		return nil, nil
+	}
+
+	if found.ExpiresAt.Before(now) {
+		if err := tx.dbTx.UpdateIdempotencyKey(reservation); err != nil {
+			return nil, fmt.Errorf("something went wrong while renewing idempotency key %s: %v", key, err)
+		}
+		return nil, nil
+	}
+
+	if found.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+
+	if found.StatusCode == idempotency_pending {
+		return nil, ErrIdempotencyKeyInUse
+	}
+	return &IdempotentResponse{StatusCode: found.StatusCode, ContentType: found.ContentType, Body: found.Body}, nil
+}
+
+// FinishIdempotentRequest stores the response of the request reserved by StartIdempotentRequest
+func (core CoreFacade) FinishIdempotentRequest(context *util.Context, playerId uuid.UUID, key string, response *IdempotentResponse) error {
+	context.Logger.Debugf("Finishing idempotent request with key %s", key)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	found, err := tx.dbTx.GetIdempotencyKey(playerId, key)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading idempotency key %s from database: %v", key, err)
+	}
+
+	if found == nil {
+		return fmt.Errorf("idempotency key %s not found", key)
+	}
+
+	found.StatusCode = response.StatusCode
+	found.ContentType = response.ContentType
+	found.Body = response.Body
+	found.ExpiresAt = time.Now().Add(core.idempotencyKeyTTL)
+	if err := tx.dbTx.UpdateIdempotencyKey(found); err != nil {
+		return fmt.Errorf("something went wrong while storing response of idempotency key %s: %v", key, err)
+	}
+	return core.commit(tx, context)
+}
+
+// AbortIdempotentRequest releases the key, so the request can be retried
+func (core CoreFacade) AbortIdempotentRequest(context *util.Context, playerId uuid.UUID, key string) error {
+	context.Logger.Debugf("Aborting idempotent request with key %s", key)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	if err := tx.dbTx.DeleteIdempotencyKey(playerId, key); err != nil {
+		return fmt.Errorf("something went wrong while deleting idempotency key %s: %v", key, err)
+	}
+	return core.commit(tx, context)
+}
+
+func (core CoreFacade) cleanUpIdempotencyKeys(tx *transaction) error {
+	if err := tx.dbTx.DeleteIdempotencyKeysBefore(time.Now()); err != nil {
+		return fmt.Errorf("error while cleaning up idempotency keys: %v", err)
+	}
+	return nil
+}
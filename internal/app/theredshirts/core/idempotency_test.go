@@ -0,0 +1,93 @@
+package core
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartIdempotentRequest_ReplaysResponse(t *testing.T) {
+	core := newTestCore(t)
+	playerId := uuid.New()
+
+	response, err := core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.Nil(t, err)
+	assert.Nil(t, response)
+
+	_, err = core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyInUse)
+
+	assert.Nil(t, core.FinishIdempotentRequest(newTestContext(), playerId, "key", &IdempotentResponse{StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{}`)}))
+
+	response, err = core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.Nil(t, err)
+	assert.Equal(t, &IdempotentResponse{StatusCode: http.StatusCreated, ContentType: "application/json", Body: []byte(`{}`)}, response)
+
+	_, err = core.StartIdempotentRequest(newTestContext(), playerId, "key", "other hash")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyMismatch)
+
+	response, err = core.StartIdempotentRequest(newTestContext(), uuid.New(), "key", "other hash")
+	assert.Nil(t, err)
+	assert.Nil(t, response)
+}
+
+func TestAbortIdempotentRequest_ReleasesKey(t *testing.T) {
+	core := newTestCore(t)
+	playerId := uuid.New()
+
+	_, err := core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.Nil(t, err)
+	assert.Nil(t, core.AbortIdempotentRequest(newTestContext(), playerId, "key"))
+
+	response, err := core.StartIdempotentRequest(newTestContext(), playerId, "key", "other hash")
+	assert.Nil(t, err)
+	assert.Nil(t, response)
+}
+
+func TestStartIdempotentRequest_PendingRequestIsKeptForTTL(t *testing.T) {
+	core := newTestCore(t)
+	playerId := uuid.New()
+
+	_, err := core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.Nil(t, err)
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	defer core.rollback(tx)
+	key, err := tx.dbTx.GetIdempotencyKey(playerId, "key")
+	assert.Nil(t, err)
+	assert.Equal(t, idempotency_pending, key.StatusCode)
+	assert.True(t, key.ExpiresAt.After(time.Now().Add(core.idempotencyKeyTTL-time.Minute)))
+
+	_, err = core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.ErrorIs(t, err, ErrIdempotencyKeyInUse)
+}
+
+func TestStartIdempotentRequest_Expired(t *testing.T) {
+	core := newTestCore(t)
+	core.idempotencyKeyTTL = -time.Second
+	playerId := uuid.New()
+
+	_, err := core.StartIdempotentRequest(newTestContext(), playerId, "key", "hash")
+	assert.Nil(t, err)
+	assert.Nil(t, core.FinishIdempotentRequest(newTestContext(), playerId, "key", &IdempotentResponse{StatusCode: http.StatusOK}))
+
+	response, err := core.StartIdempotentRequest(newTestContext(), playerId, "key", "other hash")
+	assert.Nil(t, err)
+	assert.Nil(t, response)
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	assert.Nil(t, core.cleanUpIdempotencyKeys(tx))
+	assert.Nil(t, core.commit(tx, newTestContext()))
+
+	tx, err = core.startTransaction()
+	assert.Nil(t, err)
+	defer core.rollback(tx)
+	key, err := tx.dbTx.GetIdempotencyKey(playerId, "key")
+	assert.Nil(t, err)
+	assert.Nil(t, key)
+}
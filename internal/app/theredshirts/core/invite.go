@@ -0,0 +1,207 @@
+package core
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+const (
+	// invite_code_alphabet leaves out characters which are easily confused like 0/O and 1/I
+	invite_code_alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	invite_code_length   = 6
+	invite_code_retries  = 5
+)
+
+func (core CoreFacade) CreateInvite(context *util.Context, lobbyId uuid.UUID, ownerId uuid.UUID, expiresAt *time.Time, maxUses int) (*Invite, error) {
+	context.Logger.Debugf("Creating invite for lobby [%v]", lobbyId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	invite, err := core.createInvite(tx, lobbyId, ownerId, expiresAt, maxUses)
+	if err != nil {
+		return nil, err
+	}
+	return invite, core.commit(tx, context)
+}
+
+func (core CoreFacade) createInvite(tx *transaction, lobbyId uuid.UUID, ownerId uuid.UUID, expiresAt *time.Time, maxUses int) (*Invite, error) {
+	if _, err := core.getOwnedLobby(tx, lobbyId, ownerId); err != nil {
+		return nil, err
+	}
+
+	if maxUses < 0 {
+		return nil, fmt.Errorf("maximum uses of invite can't be negative: %w", ErrInvalidState)
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("invite can't expire in the past: %w", ErrInvalidState)
+	}
+
+	for attempt := 0; attempt < invite_code_retries; attempt++ {
+		code, err := generateInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		invite := &db.Invite{Code: code, LobbyId: lobbyId, CreatedAt: time.Now(), ExpiresAt: expiresAt, MaxUses: maxUses}
+		err = tx.dbTx.CreateInvite(invite)
+		if errors.Is(err, db.ErrInviteAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("something went wrong while creating invite for lobby [%v]: %v", lobbyId, err)
+		}
+		return mapToInvite(invite), nil
+	}
+	return nil, fmt.Errorf("no unused invite code found after %d attempts", invite_code_retries)
+}
+
+func (core CoreFacade) GetInvites(context *util.Context, lobbyId uuid.UUID, ownerId uuid.UUID) ([]*Invite, error) {
+	context.Logger.Debugf("Get invites of lobby [%v]", lobbyId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	if _, err := core.getOwnedLobby(tx, lobbyId, ownerId); err != nil {
+		return nil, err
+	}
+
+	invites, err := tx.dbTx.GetInvitesInLobby(lobbyId)
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading invites of lobby [%v] from database: %v", lobbyId, err)
+	}
+	return mapToInvites(invites), core.commit(tx, context)
+}
+
+func (core CoreFacade) RevokeInvite(context *util.Context, lobbyId uuid.UUID, code string, ownerId uuid.UUID) error {
+	context.Logger.Debugf("Revoking invite [%s] of lobby [%v]", code, lobbyId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+	if err := core.revokeInvite(tx, lobbyId, code, ownerId); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+func (core CoreFacade) revokeInvite(tx *transaction, lobbyId uuid.UUID, code string, ownerId uuid.UUID) error {
+	if _, err := core.getOwnedLobby(tx, lobbyId, ownerId); err != nil {
+		return err
+	}
+
+	invite, err := tx.dbTx.GetInviteByCode(code)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading invite [%s] from database: %v", code, err)
+	}
+
+	if invite == nil || invite.LobbyId != lobbyId {
+		return fmt.Errorf("invite [%s] of lobby [%v]: %w", code, lobbyId, ErrInviteNotFound)
+	}
+
+	if err := tx.dbTx.DeleteInvite(code); err != nil {
+		return fmt.Errorf("something went wrong while deleting invite [%s]: %v", code, err)
+	}
+	return nil
+}
+
+// JoinWithInvite adds the player to the lobby of the invite. The invite replaces the password of the lobby
+func (core CoreFacade) JoinWithInvite(context *util.Context, code string, player *Player) error {
+	context.Logger.Debugf("Joining with invite [%s]: %+v", code, *player)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+	if err := core.joinWithInvite(tx, code, player); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+func (core CoreFacade) joinWithInvite(tx *transaction, code string, player *Player) error {
+	invite, err := tx.dbTx.GetInviteByCode(code)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading invite [%s] from database: %v", code, err)
+	}
+
+	if invite == nil {
+		return fmt.Errorf("invite [%s]: %w", code, ErrInviteNotFound)
+	}
+
+	player.LobbyId = invite.LobbyId
+	joined, err := core.isAlreadyJoined(tx, player.ID, player.Name, invite.LobbyId, player.Spectator)
+	if err != nil || joined {
+		return err
+	}
+
+	if invite.ExpiresAt != nil && !invite.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("invite [%s] expired at %v: %w", code, *invite.ExpiresAt, ErrInviteExpired)
+	}
+
+	lobby, err := tx.dbTx.GetLobbyByIdForUpdate(invite.LobbyId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading lobby %v from database: %v", invite.LobbyId, err)
+	}
+
+	if lobby == nil {
+		return ErrLobbyNotFound
+	}
+
+	// the use is counted by the database, the uses loaded with the invite can be outdated by concurrent joins
+	used, err := tx.dbTx.UseInvite(code)
+	if err != nil {
+		return fmt.Errorf("something went wrong while using invite [%s]: %v", code, err)
+	}
+
+	if !used {
+		return fmt.Errorf("invite [%s] has no uses left: %w", code, ErrInviteExpired)
+	}
+
+	if err := core.checkBan(tx, lobby.ID, player.ID); err != nil {
+		return err
+	}
+
+	if lobby.Status == lobby_playing && !player.Spectator {
+		return ErrLobbyPlaying
+	}
+
+	return core.joinLobby(tx, lobby, player.ID, player.Name, player.Spectator, player.Payload)
+}
+
+func generateInviteCode() (string, error) {
+	code := make([]byte, invite_code_length)
+	max := big.NewInt(int64(len(invite_code_alphabet)))
+	for index := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("error while generating invite code: %v", err)
+		}
+		code[index] = invite_code_alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func mapToInvite(invite *db.Invite) *Invite {
+	return &Invite{Code: invite.Code, LobbyId: invite.LobbyId, CreatedAt: invite.CreatedAt, ExpiresAt: invite.ExpiresAt, MaxUses: invite.MaxUses, Uses: invite.Uses}
+}
+
+func mapToInvites(dbInvites []*db.Invite) []*Invite {
+	invites := make([]*Invite, len(dbInvites))
+	for index, invite := range dbInvites {
+		invites[index] = mapToInvite(invite)
+	}
+	return invites
+}
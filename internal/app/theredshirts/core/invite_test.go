@@ -0,0 +1,136 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateInvite_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 0)
+	assert.Nil(t, err)
+	assert.Len(t, invite.Code, invite_code_length)
+	for _, character := range invite.Code {
+		assert.Contains(t, invite_code_alphabet, string(character))
+	}
+
+	invites, err := core.GetInvites(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Len(t, invites, 1)
+	assert.Equal(t, invite.Code, invites[0].Code)
+}
+
+func TestCreateInvite_NotOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	_, err := core.CreateInvite(newTestContext(), lobby.ID, player.ID, nil, 0)
+	assert.ErrorIs(t, err, ErrNotOwner)
+}
+
+func TestJoinWithInvite_WithoutPassword(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 0)
+	assert.Nil(t, err)
+
+	player := &Player{ID: uuid.New(), Name: "Invited"}
+	assert.Nil(t, core.JoinWithInvite(newTestContext(), invite.Code, player))
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.ID, foundPlayer.LobbyId)
+	assert.Contains(t, storedTopics(t, core), PLAYER_JOINS_LOBBY)
+
+	// joining again is idempotent and doesn't count as another use
+	assert.Nil(t, core.JoinWithInvite(newTestContext(), invite.Code, player))
+	invites, err := core.GetInvites(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, invites[0].Uses)
+}
+
+func TestJoinWithInvite_UseLimit(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 1)
+	assert.Nil(t, err)
+
+	assert.Nil(t, core.JoinWithInvite(newTestContext(), invite.Code, &Player{ID: uuid.New(), Name: "First"}))
+	err = core.JoinWithInvite(newTestContext(), invite.Code, &Player{ID: uuid.New(), Name: "Second"})
+	assert.ErrorIs(t, err, ErrInviteExpired)
+}
+
+func TestJoinWithInvite_ConcurrentUseLimit(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 10)
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 2)
+	assert.Nil(t, err)
+
+	results := make(chan error)
+	for index := 0; index < 8; index++ {
+		go func() {
+			results <- core.JoinWithInvite(newTestContext(), invite.Code, &Player{ID: uuid.New(), Name: "Invited"})
+		}()
+	}
+	joined := 0
+	for index := 0; index < 8; index++ {
+		if err := <-results; err == nil {
+			joined++
+		} else {
+			assert.ErrorIs(t, err, ErrInviteExpired)
+		}
+	}
+
+	assert.Equal(t, 2, joined)
+	invites, err := core.GetInvites(newTestContext(), lobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, invites[0].Uses)
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Len(t, foundLobby.Players, 3)
+}
+
+func TestJoinWithInvite_Expired(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	tx, err := core.db.StartTransaction()
+	assert.Nil(t, err)
+	expiresAt := time.Now().Add(-time.Minute)
+	assert.Nil(t, tx.CreateInvite(&db.Invite{Code: "EXPIRD", LobbyId: lobby.ID, CreatedAt: time.Now().Add(-time.Hour), ExpiresAt: &expiresAt}))
+	assert.Nil(t, tx.Commit())
+
+	err = core.JoinWithInvite(newTestContext(), "EXPIRD", &Player{ID: uuid.New(), Name: "Late"})
+	assert.ErrorIs(t, err, ErrInviteExpired)
+}
+
+func TestRevokeInvite_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 0)
+	assert.Nil(t, err)
+
+	assert.Nil(t, core.RevokeInvite(newTestContext(), lobby.ID, invite.Code, lobby.Owner.ID))
+
+	err = core.JoinWithInvite(newTestContext(), invite.Code, &Player{ID: uuid.New(), Name: "Invited"})
+	assert.ErrorIs(t, err, ErrInviteNotFound)
+}
+
+func TestJoinWithInvite_Banned(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 0)
+	assert.Nil(t, err)
+	playerId := uuid.New()
+	assert.Nil(t, core.KickPlayer(newTestContext(), lobby.ID, playerId, lobby.Owner.ID, "", true))
+
+	err = core.JoinWithInvite(newTestContext(), invite.Code, &Player{ID: playerId, Name: "Banned"})
+	assert.ErrorIs(t, err, ErrPlayerBanned)
+}
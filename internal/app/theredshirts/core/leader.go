@@ -0,0 +1,68 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	scavenger_leader_lock = "theredshirts-lobby-scavenger"
+	// scavenger_fence_lock is held by the transaction of a scavenge, so a former leader, which lost the leader lock
+	// while it was scavenging, can't scavenge at the same time as the new leader
+	scavenger_fence_lock = "theredshirts-lobby-scavenger-fence"
+)
+
+// leader remembers whether this instance holds the lock of the scavenger. Only the leader cleans up, so replicas don't send duplicate messages
+type leader struct {
+	mutex sync.Mutex
+	lock  db.LeaderLock
+}
+
+func newLeader() *leader {
+	return &leader{}
+}
+
+// isLeader keeps the lock while it is held and otherwise tries to take it over, e.g. after the former leader died
+func (core CoreFacade) isLeader() bool {
+	core.leader.mutex.Lock()
+	defer core.leader.mutex.Unlock()
+	if core.leader.lock != nil {
+		if core.leader.lock.IsHeld() {
+			return true
+		}
+		log.Warn("Lost scavenger leadership")
+		core.leader.lock.Release()
+		core.leader.lock = nil
+	}
+
+	lock, err := core.db.TryLeaderLock(scavenger_leader_lock)
+	if err != nil {
+		log.Warnf("Error while trying to become scavenger leader: %v", err)
+		return false
+	}
+	if lock == nil {
+		return false
+	}
+	log.Info("Became scavenger leader")
+	core.leader.lock = lock
+	return true
+}
+
+// holdsLeaderLock reports whether this instance still holds the lock, without trying to take it over
+func (core CoreFacade) holdsLeaderLock() bool {
+	core.leader.mutex.Lock()
+	defer core.leader.mutex.Unlock()
+	return core.leader.lock != nil && core.leader.lock.IsHeld()
+}
+
+// resignLeader releases the lock, so another instance can take over
+func (core CoreFacade) resignLeader() {
+	core.leader.mutex.Lock()
+	defer core.leader.mutex.Unlock()
+	if core.leader.lock != nil {
+		core.leader.lock.Release()
+		core.leader.lock = nil
+	}
+}
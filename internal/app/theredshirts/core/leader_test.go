@@ -0,0 +1,113 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReplica returns a second instance, which shares the database with the given core
+func newReplica(core *CoreFacade) *CoreFacade {
+	replica := *core
+	replica.eventBroker = newEventBroker()
+	replica.presence = newPresence()
+	replica.leader = newLeader()
+	return &replica
+}
+
+// newTestPostgresInstance returns an instance with its own connection to the postgres configured by the POSTGRES_ environment variables.
+// The test is skipped if no postgres is configured
+func newTestPostgresInstance(t *testing.T) *CoreFacade {
+	if os.Getenv("POSTGRES_PASSWORD") == "" {
+		t.Skip("postgres is not configured, set POSTGRES_PASSWORD and the other POSTGRES_ variables to run this test")
+	}
+	core := newTestCore(t)
+	t.Setenv("DATABASE", "postgresql")
+	database, err := db.NewConnection()
+	if err != nil {
+		t.Fatalf("error while connecting to postgres: %v", err)
+	}
+	t.Cleanup(database.Close)
+	core.db = database
+	return core
+}
+
+func TestScavenge_OnlyLeaderCleansUp(t *testing.T) {
+	first := newTestCore(t)
+	second := newReplica(first)
+	lobby := createTestLobby(t, first, 4)
+	player := joinTestPlayer(t, first, lobby.ID, false)
+	setLastRefresh(t, first, player, time.Now().Add(-10*time.Second))
+
+	first.scavenge()
+	second.scavenge()
+
+	assert.True(t, first.isLeader())
+	assert.False(t, second.isLeader())
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LAGGING}, storedTopics(t, first))
+}
+
+func TestScavenge_FailoverWhenLeaderResigns(t *testing.T) {
+	first := newTestCore(t)
+	second := newReplica(first)
+	lobby := createTestLobby(t, first, 4)
+	player := joinTestPlayer(t, first, lobby.ID, false)
+
+	assert.True(t, first.isLeader())
+	assert.False(t, second.isLeader())
+
+	first.resignLeader()
+	setLastRefresh(t, first, player, time.Now().Add(-time.Minute))
+	second.scavenge()
+
+	assert.True(t, second.isLeader())
+	assert.False(t, first.isLeader())
+	foundPlayer, err := second.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+}
+
+func TestScavenge_FailoverWhenLeaderDies(t *testing.T) {
+	first := newTestPostgresInstance(t)
+	second := newTestPostgresInstance(t)
+	lobby := createTestLobby(t, second, 4)
+	player := joinTestPlayer(t, second, lobby.ID, false)
+	setLastRefresh(t, second, player, time.Now().Add(-time.Minute))
+
+	assert.True(t, first.isLeader())
+	assert.False(t, second.isLeader())
+
+	first.db.Close()
+	assert.False(t, first.isLeader())
+	second.scavenge()
+
+	assert.True(t, second.isLeader())
+	foundPlayer, err := second.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+	second.resignLeader()
+}
+
+func TestScavenge_FencedByRunningScavenge(t *testing.T) {
+	first := newTestCore(t)
+	second := newReplica(first)
+	lobby := createTestLobby(t, first, 4)
+	player := joinTestPlayer(t, first, lobby.ID, false)
+	setLastRefresh(t, first, player, time.Now().Add(-10*time.Second))
+	assert.True(t, first.isLeader())
+
+	tx, err := second.startTransaction()
+	assert.Nil(t, err)
+	locked, err := tx.dbTx.TryTransactionLock(scavenger_fence_lock)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	first.scavenge()
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY}, storedTopics(t, first))
+
+	second.rollback(tx)
+	first.scavenge()
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LAGGING}, storedTopics(t, first))
+}
@@ -1,8 +1,11 @@
 package core
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
@@ -24,9 +27,28 @@ func (core CoreFacade) CreateLobby(context *util.Context, lobby *Lobby) error {
 }
 
 func (core CoreFacade) createLobby(tx *transaction, context *util.Context, lobby *Lobby) error {
+	if lobby.Visibility == "" {
+		lobby.Visibility = visibility_public
+	}
+	if !isVisibility(lobby.Visibility) {
+		return fmt.Errorf("no visibility %s found: %w", lobby.Visibility, ErrInvalidState)
+	}
+	if err := core.checkAfkPolicies(lobby.AfkPolicies); err != nil {
+		return err
+	}
 	dbLobby := mapToDBLobby(lobby)
+	dbLobby.CreatedAt = time.Now()
+	password, err := core.hashPassword(lobby.Password)
+	if err != nil {
+		return err
+	}
+	dbLobby.Password = password
 
-	if err := tx.dbTx.CreateLobby(dbLobby); err != nil {
+	err = tx.dbTx.CreateLobby(dbLobby)
+	if err == nil {
+		tx.messages = append(tx.messages, &message{senderPlayerId: lobby.Owner.ID, lobbyId: lobby.ID, topic: LOBBY_CREATED, auditOnly: true,
+			payload: map[string]interface{}{"name": lobby.Name, "visibility": lobby.Visibility, "max_players": lobby.MaxPlayers, "password_protected": lobby.Password != ""}})
+	} else {
 		if !errors.Is(err, db.ErrLobbyAlreadyExists) {
 			return fmt.Errorf("error while creating lobby: %v", err)
 		}
@@ -35,12 +57,12 @@ func (core CoreFacade) createLobby(tx *transaction, context *util.Context, lobby
 			return fmt.Errorf("something went wrong while checking if lobby [%v] is already created: %v", lobby.ID, err)
 		}
 
-		if lobby == nil {
-			return fmt.Errorf("lobby not found")
+		if foundLobby == nil {
+			return ErrLobbyNotFound
 		}
 
-		if lobby.Name != foundLobby.Name || lobby.Password != foundLobby.Password {
-			return fmt.Errorf("request of lobby [%v] doesn't match lobby from database [%v]", lobby, foundLobby)
+		if lobby.Name != foundLobby.Name || !checkPassword(foundLobby.Password, lobby.Password) {
+			return fmt.Errorf("request of lobby [%v] doesn't match lobby from database [%v]: %w", lobby, foundLobby, ErrLobbyConflict)
 		}
 
 	}
@@ -66,11 +88,11 @@ func (core CoreFacade) UpdateLobby(context *util.Context, lobby *Lobby, playerId
 	}
 
 	if dbLobby == nil {
-		return fmt.Errorf("lobby not found")
+		return ErrLobbyNotFound
 	}
 
 	if dbLobby.Owner != playerId {
-		return fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]", lobby.Owner.ID, dbLobby.Owner, lobby.ID)
+		return fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]: %w", lobby.Owner.ID, dbLobby.Owner, lobby.ID, ErrNotOwner)
 	}
 
 	if err := core.updateLobby(context, tx, lobby, playerId); err != nil {
@@ -81,34 +103,58 @@ func (core CoreFacade) UpdateLobby(context *util.Context, lobby *Lobby, playerId
 }
 
 func (core CoreFacade) updateLobby(context *util.Context, tx *transaction, lobby *Lobby, playerId uuid.UUID) error {
-	dbLobby, err := tx.dbTx.GetLobbyById(lobby.ID)
+	dbLobby, err := tx.dbTx.GetLobbyByIdForUpdate(lobby.ID)
 	if err != nil {
 		return fmt.Errorf("something went wrong while loading lobby [%v] from database: %v", lobby.ID, err)
 	}
 
 	if dbLobby == nil {
-		return fmt.Errorf("lobby not found")
+		return ErrLobbyNotFound
+	}
+
+	if err := checkVersion(lobby.ID, lobby.Version, dbLobby.Version); err != nil {
+		return err
 	}
 
+	// Clients from before the status lifecycle still send the status, it is accepted as long as it doesn't change
+	if lobby.Status != "" && lobby.Status != dbLobby.Status {
+		return fmt.Errorf("status of lobby [%v] can't be changed from %s to %s: %w", lobby.ID, dbLobby.Status, lobby.Status, ErrStatusNotUpdatable)
+	}
+
+	changes := lobbySettingsChanges(dbLobby, lobby)
 	dbLobby.Name = lobby.Name
-	dbLobby.Status = lobby.Status
 	dbLobby.Difficulty = lobby.Difficulty
 	dbLobby.Owner = lobby.Owner.ID
-	dbLobby.Password = lobby.Password
+	password, err := core.hashPassword(lobby.Password)
+	if err != nil {
+		return err
+	}
+	dbLobby.Password = password
 	dbLobby.MissionLength = lobby.MissionLength
 	dbLobby.NumberOfCrewMembers = lobby.NumberOfCrewMembers
 	dbLobby.MaxPlayers = lobby.MaxPlayers
 	dbLobby.ExpansionPacks = lobby.ExpansionPacks
 	dbLobby.Payload = lobby.Payload
+	if lobby.Visibility != "" {
+		if !isVisibility(lobby.Visibility) {
+			return fmt.Errorf("no visibility %s found: %w", lobby.Visibility, ErrInvalidState)
+		}
+		dbLobby.Visibility = lobby.Visibility
+	}
+	if lobby.AfkPolicies != nil {
+		if err := core.checkAfkPolicies(lobby.AfkPolicies); err != nil {
+			return err
+		}
+		dbLobby.AfkPolicies = mapToDBAfkPolicies(lobby.AfkPolicies)
+	}
 
 	if err := tx.dbTx.UpdateLobby(dbLobby); err != nil {
-		if err != nil {
-			return fmt.Errorf("something went wrong while updating lobby [%v]: %v", lobby.ID, err)
-		}
+		return fmt.Errorf("something went wrong while updating lobby [%v]: %w", lobby.ID, wrapVersionConflict(err))
 	}
-	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: lobby.ID, topic: PLAYER_UPDATES_LOBBY, payload: map[string]interface{}{}})
 
-	return nil
+	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: lobby.ID, topic: PLAYER_UPDATES_LOBBY, payload: map[string]interface{}{}, auditPayload: changes})
+
+	return core.resetPlayersReady(tx, lobby.ID, playerId)
 }
 
 func (core CoreFacade) UpdateLobbyStatus(context *util.Context, lobby *Lobby, playerId uuid.UUID) error {
@@ -125,29 +171,24 @@ func (core CoreFacade) UpdateLobbyStatus(context *util.Context, lobby *Lobby, pl
 }
 
 func (core CoreFacade) updateLobbyStatus(context *util.Context, tx *transaction, lobby *Lobby, playerId uuid.UUID) error {
-	dbLobby, err := tx.dbTx.GetLobbyById(lobby.ID)
+	dbLobby, err := tx.dbTx.GetLobbyByIdForUpdate(lobby.ID)
 	if err != nil {
 		return fmt.Errorf("something went wrong while loading lobby [%v] from database: %v", lobby.ID, err)
 	}
 
 	if dbLobby == nil {
-		return fmt.Errorf("lobby not found")
+		return ErrLobbyNotFound
 	}
 
 	if dbLobby.Owner != playerId {
-		return fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]", playerId, dbLobby.Owner, lobby.ID)
+		return fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]: %w", playerId, dbLobby.Owner, lobby.ID, ErrNotOwner)
 	}
 
-	dbLobby.Status = lobby.Status
-
-	if err := tx.dbTx.UpdateLobby(dbLobby); err != nil {
-		if err != nil {
-			return fmt.Errorf("something went wrong while updating state of lobby [%v]: %v", lobby.ID, err)
-		}
+	if err := checkVersion(lobby.ID, lobby.Version, dbLobby.Version); err != nil {
+		return err
 	}
 
-	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: lobby.ID, topic: PLAYER_UPDATES_LOBBY, payload: map[string]interface{}{}})
-	return nil
+	return core.changeLobbyStatus(tx, dbLobby, lobby.Status, playerId)
 }
 
 func (core CoreFacade) DeleteLobby(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID) error {
@@ -158,7 +199,7 @@ func (core CoreFacade) DeleteLobby(context *util.Context, lobbyId uuid.UUID, pla
 	}
 	defer core.rollback(tx)
 	if err = core.deleteLobby(tx, context, lobbyId, playerId); err != nil {
-		return nil
+		return err
 	}
 	return core.commit(tx, context)
 }
@@ -174,12 +215,31 @@ func (core CoreFacade) deleteLobby(tx *transaction, context *util.Context, lobby
 	}
 
 	if lobby.Owner != playerId {
-		return fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]", playerId, lobby.Owner, lobbyId)
+		return fmt.Errorf("player [%v] is not owner [%v] of the lobby [%v]: %w", playerId, lobby.Owner, lobbyId, ErrNotOwner)
+	}
+	return core.removeLobby(tx, lobbyId, playerId)
+}
+
+// removeLobby deletes the lobby together with its players. The players have to go first, they reference the lobby and
+// a lobby closed by the players only succession can still contain spectators. The sender is uuid.Nil if the server deletes the lobby
+func (core CoreFacade) removeLobby(tx *transaction, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	players, err := tx.dbTx.GetAllPlayersInLobby(lobbyId)
+	if err != nil {
+		return fmt.Errorf("an error accourd while loading players of lobby [%v]: %v", lobbyId, err)
+	}
+
+	if err := tx.dbTx.DeleteAllPlayerInLobby(lobbyId); err != nil {
+		return fmt.Errorf("an error accourd while deleting players of lobby [%v]: %v", lobbyId, err)
+	}
+
+	for _, player := range players {
+		tx.messages = append(tx.messages, &message{senderPlayerId: uuid.Nil, lobbyId: lobbyId, topic: PLAYER_LEAVES_LOBBY, payload: map[string]interface{}{"player_id": player.ID}})
 	}
 
 	if err := tx.dbTx.DeleteLobby(lobbyId); err != nil {
 		return fmt.Errorf("an error accourd while deleting lobby [%v]: %v", lobbyId, err)
 	}
+	tx.messages = append(tx.messages, &message{senderPlayerId: senderPlayerId, lobbyId: lobbyId, topic: LOBBY_DELETED, auditOnly: true, payload: map[string]interface{}{}})
 	return nil
 }
 
@@ -199,6 +259,38 @@ func (core CoreFacade) GetLobby(context *util.Context, lobbyId uuid.UUID) (*Lobb
 	return lobby, core.commit(tx, context)
 }
 
+// GetLobbyJoinEventId returns the event with which the player joined the lobby. It returns ErrNotLobbyMember if the player is neither
+// the owner nor a player of the lobby and 0 if the event is already cleaned up
+func (core CoreFacade) GetLobbyJoinEventId(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID) (int64, error) {
+	context.Logger.Debugf("Check member: LobbyId [%v], PlayerId [%v]", lobbyId, playerId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return 0, err
+	}
+	defer core.rollback(tx)
+
+	lobby, err := core.getLobby(tx, lobbyId)
+	if err != nil {
+		return 0, err
+	}
+	if !IsLobbyMember(lobby, playerId) {
+		return 0, fmt.Errorf("player [%v] is not member of lobby [%v]: %w", playerId, lobbyId, ErrNotLobbyMember)
+	}
+	joinEventId, err := tx.dbTx.GetLastPlayerLobbyEventId(lobbyId, playerId, []string{PLAYER_JOINS_LOBBY, PLAYER_RECONNECTED})
+	if err != nil {
+		return 0, fmt.Errorf("something went wrong while loading join event of player [%v] in lobby [%v]: %v", playerId, lobbyId, err)
+	}
+	return joinEventId, core.commit(tx, context)
+}
+
+// IsLobbyMember reports whether the player is the owner or a player of the lobby
+func IsLobbyMember(lobby *Lobby, playerId uuid.UUID) bool {
+	if lobby.Owner != nil && lobby.Owner.ID == playerId {
+		return true
+	}
+	return findPlayer(lobby.Players, playerId) != nil
+}
+
 func (core CoreFacade) getLobby(tx *transaction, lobbyId uuid.UUID) (*Lobby, error) {
 	lobby, err := tx.dbTx.GetLobbyById(lobbyId)
 	if err != nil {
@@ -206,56 +298,137 @@ func (core CoreFacade) getLobby(tx *transaction, lobbyId uuid.UUID) (*Lobby, err
 	}
 
 	if lobby == nil {
-		return nil, fmt.Errorf("lobby not found")
+		return nil, ErrLobbyNotFound
 	}
 
-	players, err := tx.dbTx.GetAllPlayersInLobby(lobbyId)
+	lobbies, err := core.loadLobbies(tx, []*db.Lobby{lobby})
 	if err != nil {
-		return nil, fmt.Errorf("something went wrong while loading players of lobby [%v] from database: %v", lobby.ID, err)
+		return nil, err
+	}
+	return lobbies[0], nil
+}
+
+// loadLobbies adds the players to the lobbies with one query for all lobbies. The owner is always one of the players
+func (core CoreFacade) loadLobbies(tx *transaction, lobbies []*db.Lobby) ([]*Lobby, error) {
+	lobbyIds := make([]uuid.UUID, len(lobbies))
+	for index, lobby := range lobbies {
+		lobbyIds[index] = lobby.ID
 	}
 
-	owner, err := tx.dbTx.GetPlayerById(lobby.Owner)
+	players, err := tx.dbTx.GetAllPlayersInLobbies(lobbyIds)
 	if err != nil {
-		return nil, fmt.Errorf("something went wrong while loading owner [%v] of lobby [%v] from database: %v", lobby.Owner, lobby.ID, err)
+		return nil, fmt.Errorf("something went wrong while loading players of lobbies from database: %v", err)
 	}
 
-	return mapToLobby(lobby, mapToPlayer(owner), mapToPlayers(players)), nil
+	playersByLobby := make(map[uuid.UUID][]*Player, len(lobbies))
+	for _, player := range mapToPlayers(players) {
+		playersByLobby[player.LobbyId] = append(playersByLobby[player.LobbyId], player)
+	}
+
+	coreLobbies := make([]*Lobby, len(lobbies))
+	for index, lobby := range lobbies {
+		lobbyPlayers := playersByLobby[lobby.ID]
+		if lobbyPlayers == nil {
+			lobbyPlayers = make([]*Player, 0)
+		}
+		coreLobbies[index] = mapToLobby(lobby, findPlayer(lobbyPlayers, lobby.Owner), lobbyPlayers)
+	}
+	return coreLobbies, nil
 }
 
-func (core CoreFacade) GetLobbies(context *util.Context) ([]*Lobby, error) {
+// GetLobbies returns a page of public lobbies matching the filter and the cursor of the next page, which is empty on the last page
+func (core CoreFacade) GetLobbies(context *util.Context, filter *LobbyFilter) ([]*Lobby, string, error) {
+	query, err := mapToLobbyQuery(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	return core.getLobbies(context, query)
+}
+
+func (core CoreFacade) getLobbies(context *util.Context, query *db.LobbyQuery) ([]*Lobby, string, error) {
 	tx, err := core.startTransaction()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer core.rollback(tx)
 
-	lobbies, err := tx.dbTx.GetAllLobbies()
+	lobbies, next, err := tx.dbTx.GetLobbies(query)
 	if err != nil {
-		return nil, fmt.Errorf("something went wrong while loading all lobbies from database: %v", err)
+		return nil, "", fmt.Errorf("something went wrong while loading lobbies from database: %v", err)
 	}
 
-	coreLobbies := make([]*Lobby, len(lobbies))
-	for index, lobby := range lobbies {
+	coreLobbies, err := core.loadLobbies(tx, lobbies)
+	if err != nil {
+		return nil, "", err
+	}
 
-		players, err := tx.dbTx.GetAllPlayersInLobby(lobby.ID)
-		if err != nil {
-			return nil, fmt.Errorf("something went wrong while loading players of lobby [%v] from database: %v", lobby.ID, err)
-		}
+	cursor, err := encodeLobbyCursor(next)
+	if err != nil {
+		return nil, "", err
+	}
+	return coreLobbies, cursor, core.commit(tx, context)
+}
 
-		owner, err := tx.dbTx.GetPlayerById(lobby.Owner)
-		if err != nil {
-			return nil, fmt.Errorf("something went wrong while loading owner [%v] of lobby [%v] from database: %v", lobby.Owner, lobby.ID, err)
-		}
-		coreLobbies[index] = mapToLobby(lobby, mapToPlayer(owner), mapToPlayers(players))
+func isVisibility(visibility string) bool {
+	return visibility == visibility_public || visibility == visibility_unlisted || visibility == visibility_private
+}
+
+func mapToLobbyQuery(filter *LobbyFilter) (*db.LobbyQuery, error) {
+	cursor, err := decodeLobbyCursor(filter.Cursor, filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > lobby_page_max_size {
+		limit = lobby_page_max_size
+	}
+	return &db.LobbyQuery{Visibility: visibility_public, Status: filter.Status, Difficulty: filter.Difficulty, MissionLength: filter.MissionLength, ExpansionPacks: filter.ExpansionPacks, FreeSlots: filter.FreeSlots, PasswordProtected: filter.PasswordProtected, Sort: filter.Sort, Descending: filter.Descending, Limit: limit, Cursor: cursor, Now: time.Now()}, nil
+}
+
+// encodeLobbyCursor hides the sort value and id of the last lobby behind an opaque string
+func encodeLobbyCursor(cursor *db.LobbyCursor) (string, error) {
+	if cursor == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("error while encoding cursor: %v", err)
 	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
 
-	return coreLobbies, core.commit(tx, context)
+// decodeLobbyCursor reads the cursor and checks that its value fits to the sort, so a foreign cursor can't reach the database
+func decodeLobbyCursor(cursor string, sort string) (*db.LobbyCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("cursor %s is not base64: %w", cursor, ErrInvalidCursor)
+	}
+	dbCursor := new(db.LobbyCursor)
+	if err := json.Unmarshal(data, dbCursor); err != nil {
+		return nil, fmt.Errorf("cursor %s can't be parsed: %w", cursor, ErrInvalidCursor)
+	}
+	if err := db.ValidateLobbyCursor(sort, dbCursor); err != nil {
+		return nil, fmt.Errorf("cursor %s doesn't fit to sort %s: %v: %w", cursor, sort, err, ErrInvalidCursor)
+	}
+	return dbCursor, nil
 }
 
 func mapToDBLobby(lobby *Lobby) *db.Lobby {
-	return &db.Lobby{ID: lobby.ID, Status: lobby.Status, Name: lobby.Name, Owner: lobby.Owner.ID, Password: lobby.Password, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Payload: lobby.Payload}
+	return &db.Lobby{ID: lobby.ID, Status: lobby.Status, Name: lobby.Name, Owner: lobby.Owner.ID, Password: lobby.Password, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Payload: lobby.Payload, Visibility: lobby.Visibility, AfkPolicies: mapToDBAfkPolicies(lobby.AfkPolicies), CreatedAt: lobby.CreatedAt}
 }
 
 func mapToLobby(lobby *db.Lobby, owner *Player, players []*Player) *Lobby {
-	return &Lobby{ID: lobby.ID, Status: lobby.Status, Name: lobby.Name, Owner: owner, Password: lobby.Password, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Players: players, Payload: lobby.Payload}
+	return &Lobby{ID: lobby.ID, Status: lobby.Status, Name: lobby.Name, Owner: owner, Password: lobby.Password, Difficulty: lobby.Difficulty, MissionLength: lobby.MissionLength, NumberOfCrewMembers: lobby.NumberOfCrewMembers, MaxPlayers: lobby.MaxPlayers, ExpansionPacks: lobby.ExpansionPacks, Players: players, Payload: lobby.Payload, Visibility: lobby.Visibility, AfkPolicies: mapToAfkPolicies(lobby.AfkPolicies), CreatedAt: lobby.CreatedAt, Version: lobby.Version}
+}
+
+// touchLobby counts up the version of the lobby when its players change, because they are part of the lobby and its ETag
+func (core CoreFacade) touchLobby(tx *transaction, lobbyId uuid.UUID) error {
+	if err := tx.dbTx.IncrementLobbyVersion(lobbyId); err != nil {
+		return fmt.Errorf("something went wrong while updating version of lobby [%v]: %v", lobbyId, err)
+	}
+	return nil
 }
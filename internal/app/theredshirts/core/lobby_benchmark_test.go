@@ -0,0 +1,176 @@
+package core
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	benchmark_lobbies           = 1000
+	benchmark_players_per_lobby = 4
+)
+
+// countingDB counts the queries loading lobbies and players, to make the number of round trips to postgres visible
+type (
+	countingDB struct {
+		db.DB
+		queries *int64
+	}
+
+	countingTx struct {
+		db.DBTx
+		queries *int64
+	}
+)
+
+func (connection *countingDB) StartTransaction() (db.DBTx, error) {
+	tx, err := connection.DB.StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return &countingTx{DBTx: tx, queries: connection.queries}, nil
+}
+
+func (tx *countingTx) GetLobbies(query *db.LobbyQuery) ([]*db.Lobby, *db.LobbyCursor, error) {
+	atomic.AddInt64(tx.queries, 1)
+	return tx.DBTx.GetLobbies(query)
+}
+
+func (tx *countingTx) GetPlayerById(id uuid.UUID) (*db.Player, error) {
+	atomic.AddInt64(tx.queries, 1)
+	return tx.DBTx.GetPlayerById(id)
+}
+
+func (tx *countingTx) GetAllPlayersInLobby(lobbyId uuid.UUID) ([]*db.Player, error) {
+	atomic.AddInt64(tx.queries, 1)
+	return tx.DBTx.GetAllPlayersInLobby(lobbyId)
+}
+
+func (tx *countingTx) GetAllPlayersInLobbies(lobbyIds []uuid.UUID) ([]*db.Player, error) {
+	atomic.AddInt64(tx.queries, 1)
+	return tx.DBTx.GetAllPlayersInLobbies(lobbyIds)
+}
+
+// newBenchmarkCore fills the database directly, creating the lobbies through the core would be too slow
+func newBenchmarkCore(b testing.TB, numberOfLobbies int) (*CoreFacade, *int64) {
+	b.Setenv("DATABASE", "inmemory")
+	connection, err := db.NewConnection()
+	if err != nil {
+		b.Fatalf("error while creating database: %v", err)
+	}
+	tx, err := connection.StartTransaction()
+	if err != nil {
+		b.Fatalf("error while starting transaction: %v", err)
+	}
+	now := time.Now()
+	for lobbyIndex := 0; lobbyIndex < numberOfLobbies; lobbyIndex++ {
+		lobby := &db.Lobby{ID: uuid.New(), Status: lobby_open, Name: "Some Lobby", Difficulty: 1, MissionLength: 1, NumberOfCrewMembers: 1, MaxPlayers: benchmark_players_per_lobby, Visibility: visibility_public, CreatedAt: now.Add(time.Duration(lobbyIndex))}
+		for playerIndex := 0; playerIndex < benchmark_players_per_lobby; playerIndex++ {
+			player := &db.Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID, LastRefresh: now, JoinedAt: now}
+			if playerIndex == 0 {
+				lobby.Owner = player.ID
+				if err := tx.CreateLobby(lobby); err != nil {
+					b.Fatalf("error while creating lobby: %v", err)
+				}
+			}
+			if err := tx.CreatePlayer(player); err != nil {
+				b.Fatalf("error while creating player: %v", err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		b.Fatalf("error while commiting test data: %v", err)
+	}
+
+	queries := new(int64)
+	return &CoreFacade{db: &countingDB{DB: connection, queries: queries}, eventBroker: newEventBroker(), presence: newPresence()}, queries
+}
+
+// getLobbiesPerLobby loads the players like before they were batched, with two queries per lobby. It is the baseline of the benchmark
+func (core CoreFacade) getLobbiesPerLobby(context *util.Context, filter *LobbyFilter) ([]*Lobby, string, error) {
+	query, err := mapToLobbyQuery(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, "", err
+	}
+	defer core.rollback(tx)
+
+	lobbies, next, err := tx.dbTx.GetLobbies(query)
+	if err != nil {
+		return nil, "", err
+	}
+	coreLobbies := make([]*Lobby, len(lobbies))
+	for index, lobby := range lobbies {
+		players, err := tx.dbTx.GetAllPlayersInLobby(lobby.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		owner, err := tx.dbTx.GetPlayerById(lobby.Owner)
+		if err != nil {
+			return nil, "", err
+		}
+		coreLobbies[index] = mapToLobby(lobby, mapToPlayer(owner), mapToPlayers(players))
+	}
+	cursor, err := encodeLobbyCursor(next)
+	if err != nil {
+		return nil, "", err
+	}
+	return coreLobbies, cursor, core.commit(tx, context)
+}
+
+func BenchmarkGetLobbies(b *testing.B) {
+	for _, loader := range []struct {
+		name       string
+		getLobbies func(core *CoreFacade, context *util.Context, filter *LobbyFilter) ([]*Lobby, string, error)
+	}{
+		{name: "batched", getLobbies: (*CoreFacade).GetLobbies},
+		{name: "per_lobby", getLobbies: (*CoreFacade).getLobbiesPerLobby},
+	} {
+		b.Run(loader.name, func(b *testing.B) {
+			core, queries := newBenchmarkCore(b, benchmark_lobbies)
+			context := newTestContext()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				cursor := ""
+				for {
+					lobbies, next, err := loader.getLobbies(core, context, &LobbyFilter{Cursor: cursor})
+					if err != nil {
+						b.Fatalf("error while loading lobbies: %v", err)
+					}
+					if len(lobbies) == 0 || len(lobbies[0].Players) != benchmark_players_per_lobby {
+						b.Fatalf("unexpected lobbies loaded")
+					}
+					if next == "" {
+						break
+					}
+					cursor = next
+				}
+			}
+			b.ReportMetric(float64(atomic.LoadInt64(queries))/float64(b.N), "queries/op")
+		})
+	}
+}
+
+func TestGetLobbies_QueriesPerPage(t *testing.T) {
+	for _, numberOfLobbies := range []int{1, 10, lobby_page_max_size} {
+		t.Run(fmt.Sprint(numberOfLobbies), func(t *testing.T) {
+			core, queries := newBenchmarkCore(t, numberOfLobbies)
+
+			lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{})
+			assert.Nil(t, err)
+			assert.Len(t, lobbies, numberOfLobbies)
+			assert.Equal(t, int64(2), atomic.LoadInt64(queries))
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLobby_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.Name, foundLobby.Name)
+	assert.Equal(t, lobby_open, foundLobby.Status)
+	assert.Equal(t, lobby.Owner.ID, foundLobby.Owner.ID)
+	assert.Len(t, foundLobby.Players, 1)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY}, storedTopics(t, core))
+}
+
+func TestCreateLobby_Repeated(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY}, storedTopics(t, core))
+}
+
+func TestCreateLobby_RepeatedWithOtherName(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	lobby.Name = "Other Lobby"
+	assert.ErrorContains(t, core.CreateLobby(newTestContext(), lobby), "doesn't match lobby from database")
+}
+
+func TestUpdateLobby_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	lobby.Name = "Renamed Lobby"
+	lobby.Status = lobby_open
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed Lobby", foundLobby.Name)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_UPDATES_LOBBY}, storedTopics(t, core))
+}
+
+func TestUpdateLobby_StatusChange(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	lobby.Status = lobby_aborted
+	err := core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID)
+	assert.ErrorIs(t, err, ErrStatusNotUpdatable)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby_open, foundLobby.Status)
+}
+
+func TestUpdateLobby_NotOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.UpdateLobby(newTestContext(), lobby, player.ID)
+	assert.ErrorIs(t, err, ErrNotOwner)
+}
+
+func TestUpdateLobbyStatus_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.UpdateLobbyStatus(newTestContext(), &Lobby{ID: lobby.ID, Status: lobby_starting}, lobby.Owner.ID)
+	assert.Nil(t, err)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby_starting, foundLobby.Status)
+}
+
+func TestGetLobby_NotFound(t *testing.T) {
+	core := newTestCore(t)
+
+	_, err := core.GetLobby(newTestContext(), uuid.New())
+	assert.ErrorIs(t, err, ErrLobbyNotFound)
+}
+
+func TestGetLobbies_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	otherLobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, otherLobby.ID, false)
+
+	lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 2)
+	for _, foundLobby := range lobbies {
+		switch foundLobby.ID {
+		case lobby.ID:
+			assert.Len(t, foundLobby.Players, 1)
+		case otherLobby.ID:
+			assert.Len(t, foundLobby.Players, 2)
+		default:
+			t.Errorf("unexpected lobby %v", foundLobby.ID)
+		}
+	}
+}
+
+func TestDeleteLobby_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+
+	lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{})
+	assert.Nil(t, err)
+	assert.Empty(t, lobbies)
+}
+
+func TestDeleteLobby_RemovesPlayers(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	assert.Nil(t, core.DeleteLobby(newTestContext(), lobby.ID, lobby.Owner.ID))
+
+	_, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.ErrorIs(t, err, ErrLobbyNotFound)
+	for _, playerId := range []uuid.UUID{lobby.Owner.ID, player.ID} {
+		foundPlayer, err := core.GetPlayer(newTestContext(), playerId)
+		assert.Nil(t, err)
+		assert.Nil(t, foundPlayer)
+	}
+	messages := storedMessages(t, core)
+	assert.Len(t, messages, 4)
+	leftPlayers := []interface{}{}
+	for _, message := range messages[2:] {
+		assert.Equal(t, PLAYER_LEAVES_LOBBY, message.Topic)
+		leftPlayers = append(leftPlayers, message.Payload["player_id"])
+	}
+	assert.ElementsMatch(t, []interface{}{lobby.Owner.ID, player.ID}, leftPlayers)
+}
+
+func TestDeleteLobby_NotOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.DeleteLobby(newTestContext(), lobby.ID, player.ID)
+	assert.ErrorIs(t, err, ErrNotOwner)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Len(t, foundLobby.Players, 2)
+}
+
+func TestGetLobbies_HidesUnlistedAndPrivate(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	for _, visibility := range []string{visibility_unlisted, visibility_private} {
+		hiddenLobby := newTestLobby(4)
+		hiddenLobby.Visibility = visibility
+		assert.Nil(t, core.CreateLobby(newTestContext(), hiddenLobby))
+	}
+
+	lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+	assert.Equal(t, lobby.ID, lobbies[0].ID)
+}
+
+func TestGetLobbies_Filter(t *testing.T) {
+	core := newTestCore(t)
+	fullLobby := createTestLobby(t, core, 2)
+	joinTestPlayer(t, core, fullLobby.ID, false)
+	openLobby := newTestLobby(4)
+	openLobby.Password = ""
+	openLobby.Difficulty = 3
+	openLobby.ExpansionPacks = []string{"red", "blue"}
+	assert.Nil(t, core.CreateLobby(newTestContext(), openLobby))
+
+	lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{FreeSlots: 1})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+	assert.Equal(t, openLobby.ID, lobbies[0].ID)
+
+	protected := true
+	lobbies, _, err = core.GetLobbies(newTestContext(), &LobbyFilter{PasswordProtected: &protected})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+	assert.Equal(t, fullLobby.ID, lobbies[0].ID)
+
+	lobbies, _, err = core.GetLobbies(newTestContext(), &LobbyFilter{Difficulty: 3, ExpansionPacks: []string{"blue"}})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+	assert.Equal(t, openLobby.ID, lobbies[0].ID)
+
+	lobbies, _, err = core.GetLobbies(newTestContext(), &LobbyFilter{ExpansionPacks: []string{"blue", "green"}})
+	assert.Nil(t, err)
+	assert.Empty(t, lobbies)
+}
+
+func TestGetLobbies_Pagination(t *testing.T) {
+	core := newTestCore(t)
+	for _, name := range []string{"c", "a", "e", "b", "d"} {
+		lobby := newTestLobby(4)
+		lobby.Name = name
+		assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+	}
+
+	names := make([]string, 0)
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		lobbies, next, err := core.GetLobbies(newTestContext(), &LobbyFilter{Sort: "name", Descending: true, Limit: 2, Cursor: cursor})
+		assert.Nil(t, err)
+		for _, lobby := range lobbies {
+			names = append(names, lobby.Name)
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"e", "d", "c", "b", "a"}, names)
+	assert.Empty(t, cursor)
+}
+
+func TestGetLobbies_InvalidCursor(t *testing.T) {
+	core := newTestCore(t)
+
+	_, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestGetLobbies_CursorOfOtherSort(t *testing.T) {
+	core := newTestCore(t)
+	for index := 0; index < 3; index++ {
+		createTestLobby(t, core, 4)
+	}
+
+	_, cursor, err := core.GetLobbies(newTestContext(), &LobbyFilter{Sort: "name", Limit: 1})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, cursor)
+
+	for _, sort := range []string{"", "difficulty", "free_slots"} {
+		_, _, err = core.GetLobbies(newTestContext(), &LobbyFilter{Sort: sort, Cursor: cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	}
+}
+
+func TestGetLobbies_CreatedAtPagination(t *testing.T) {
+	core := newTestCore(t)
+	created := make([]uuid.UUID, 0)
+	for index := 0; index < 3; index++ {
+		created = append(created, createTestLobby(t, core, 4).ID)
+	}
+
+	found := make([]uuid.UUID, 0)
+	cursor := ""
+	for page := 0; page < 3; page++ {
+		lobbies, next, err := core.GetLobbies(newTestContext(), &LobbyFilter{Limit: 1, Cursor: cursor})
+		assert.Nil(t, err)
+		for _, lobby := range lobbies {
+			found = append(found, lobby.ID)
+		}
+		cursor = next
+	}
+	assert.ElementsMatch(t, created, found)
+}
+
+func TestCreatePlayer_PrivateLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := newTestLobby(4)
+	lobby.Visibility = visibility_private
+	assert.Nil(t, core.CreateLobby(newTestContext(), lobby))
+
+	_, err := core.CreatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}, test_password)
+	assert.ErrorIs(t, err, ErrLobbyPrivate)
+
+	invite, err := core.CreateInvite(newTestContext(), lobby.ID, lobby.Owner.ID, nil, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, core.JoinWithInvite(newTestContext(), invite.Code, &Player{ID: uuid.New(), Name: "Invited"}))
+}
@@ -2,8 +2,10 @@ package core
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/adapter"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
 	"github.com/google/uuid"
 )
@@ -15,6 +17,16 @@ const (
 	PLAYER_UPDATES_LOBBY = "PLAYER_UPDATES_LOBBY"
 	PLAYER_UPDATED       = "PLAYER_UPDATED"
 	PLAYER_LAGGING       = "PLAYER_LAGGING"
+	PLAYER_SEAT_RESERVED = "PLAYER_SEAT_RESERVED"
+	PLAYER_RECONNECTED   = "PLAYER_RECONNECTED"
+	PLAYER_KICKED        = "PLAYER_KICKED"
+	OWNER_CHANGED        = "OWNER_CHANGED"
+	PLAYER_READY_CHANGED = "PLAYER_READY_CHANGED"
+	LOBBY_OPENED         = "LOBBY_OPENED"
+	LOBBY_STARTING       = "LOBBY_STARTING"
+	LOBBY_PLAYING        = "LOBBY_PLAYING"
+	LOBBY_FINISHED       = "LOBBY_FINISHED"
+	LOBBY_ABORTED        = "LOBBY_ABORTED"
 )
 
 type message struct {
@@ -22,27 +34,32 @@ type message struct {
 	lobbyId        uuid.UUID
 	topic          string
 	payload        map[string]interface{}
+	// auditPayload replaces the payload in the audit log, to record details which are not sent to the players
+	auditPayload map[string]interface{}
+	// auditOnly messages are neither sent nor part of the lobby events
+	auditOnly bool
 }
 
-func (core CoreFacade) createMessageId(context *util.Context, lobbyId uuid.UUID, senderPlayerId uuid.UUID) (string, error) {
-	msgId, err := core.messageAdapter.CreateMessageId(context, lobbyId, senderPlayerId)
-	if err != nil {
-		return "", fmt.Errorf("error while creating message id: %v", err)
+func (core CoreFacade) storeMessages(context *util.Context, tx *transaction) error {
+	now := time.Now()
+	for _, message := range tx.messages {
+		if message.auditOnly {
+			continue
+		}
+		if message.senderPlayerId == uuid.Nil {
+			message.senderPlayerId = core.lobbyPlayerId
+		}
+		outboxMessage := &db.OutboxMessage{LobbyId: message.lobbyId, SenderPlayerId: message.senderPlayerId, CorrelationId: context.CorrelationId, Topic: message.topic, Payload: message.payload, CreatedAt: now, NextAttempt: now}
+		if err := tx.dbTx.CreateOutboxMessage(outboxMessage); err != nil {
+			return fmt.Errorf("error while storing message with topic %s in outbox: %v", message.topic, err)
+		}
 	}
-	return msgId, nil
+	return nil
 }
 
-func (core CoreFacade) createMessage(context *util.Context, message *message) error {
-	if message.senderPlayerId == uuid.Nil {
-		message.senderPlayerId = core.lobbyPlayerId
-	}
-
-	msgId, err := core.createMessageId(context, message.lobbyId, message.senderPlayerId)
-	if err != nil {
-		return err
-	}
-	if err := core.messageAdapter.CreateMessage(context, &adapter.Message{Topic: message.topic, Message: message.payload}, message.lobbyId, msgId, message.senderPlayerId); err != nil {
-		return fmt.Errorf("error while sending message with topic %s: %v", message.topic, err)
+func (core CoreFacade) createMessage(context *util.Context, message *db.OutboxMessage) error {
+	if err := core.messageSink.SendMessage(context, &adapter.Message{Topic: message.Topic, Message: message.Payload}, message.LobbyId, message.SenderPlayerId); err != nil {
+		return fmt.Errorf("error while sending message with topic %s: %v", message.Topic, err)
 	}
 	return nil
 }
@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	outbox_batch_size   = 50
+	outbox_base_backoff = time.Second
+	// outbox_claim_lease has to be longer than the sink needs to deliver a whole batch
+	outbox_claim_lease = 10 * time.Minute
+)
+
+func (core CoreFacade) startOutboxDispatcher() *gocron.Scheduler {
+	log.Info("Start outbox dispatcher")
+	s := gocron.NewScheduler(time.UTC)
+
+	s.Every(1).Seconds().SingletonMode().Do(func() {
+		correlationId := uuid.NewString()
+		logger := log.WithFields(log.Fields{
+			"Dispatcher": correlationId,
+		})
+		core.deliverOutboxMessages(&util.Context{CorrelationId: correlationId, Logger: logger})
+	})
+
+	s.StartAsync()
+	return s
+}
+
+// deliverOutboxMessages dispatches until no due message is left. Messages which could not be delivered wait for their next attempt
+func (core CoreFacade) deliverOutboxMessages(context *util.Context) {
+	for {
+		delivered, err := core.dispatchOutboxMessages(context)
+		if err != nil {
+			context.Logger.Warnf("Error while dispatching outbox messages: %v", err)
+			return
+		}
+		if delivered == 0 {
+			return
+		}
+	}
+}
+
+// dispatchOutboxMessages delivers the oldest due message of every lobby and returns how many were delivered.
+// Messages of a lobby are only delivered after all older messages of the same lobby are delivered.
+// The messages are claimed in a short transaction, so no transaction stays open while the sink is called
+func (core CoreFacade) dispatchOutboxMessages(context *util.Context) (int, error) {
+	messages, err := core.claimOutboxMessages()
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, message := range messages {
+		messageContext := &util.Context{CorrelationId: message.CorrelationId, Logger: context.Logger.WithField(correlation_id_log_field, message.CorrelationId)}
+		sendErr := core.createMessage(messageContext, message)
+		if err := core.settleOutboxMessage(messageContext, message, sendErr); err != nil {
+			return delivered, err
+		}
+		if sendErr == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// claimOutboxMessages loads the due messages and moves their next attempt behind the claim lease. Other dispatchers skip
+// claimed messages, if the dispatcher dies before settling them they are due again when the lease ran out
+func (core CoreFacade) claimOutboxMessages() ([]*db.OutboxMessage, error) {
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	now := time.Now()
+	messages, err := tx.dbTx.GetDueOutboxMessages(now, outbox_batch_size)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading due outbox messages: %v", err)
+	}
+	for _, message := range messages {
+		claimed := *message
+		claimed.NextAttempt = now.Add(outbox_claim_lease)
+		if err := tx.dbTx.UpdateOutboxMessage(&claimed); err != nil {
+			return nil, fmt.Errorf("error while claiming outbox message [%d]: %v", message.ID, err)
+		}
+	}
+
+	if err := tx.dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("error while commiting transaction: %v", err)
+	}
+	return messages, nil
+}
+
+// settleOutboxMessage deletes a delivered message or schedules the next attempt of a message which could not be delivered
+func (core CoreFacade) settleOutboxMessage(context *util.Context, message *db.OutboxMessage, sendErr error) error {
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	if sendErr != nil {
+		core.deferOutboxMessage(context, message, sendErr)
+		if err := tx.dbTx.UpdateOutboxMessage(message); err != nil {
+			return fmt.Errorf("error while updating outbox message [%d]: %v", message.ID, err)
+		}
+	} else if err := tx.dbTx.DeleteOutboxMessage(message.ID); err != nil {
+		return fmt.Errorf("error while deleting outbox message [%d]: %v", message.ID, err)
+	}
+
+	if err := tx.dbTx.Commit(); err != nil {
+		return fmt.Errorf("error while commiting transaction: %v", err)
+	}
+	return nil
+}
+
+func (core CoreFacade) deferOutboxMessage(context *util.Context, message *db.OutboxMessage, err error) {
+	message.Attempts++
+	message.NextAttempt = time.Now().Add(outboxBackoff(message.Attempts, core.outboxMaxBackoff))
+	message.LastError = err.Error()
+	if message.Attempts >= core.outboxFailedAttempts {
+		message.DeadLetter = true
+		context.Logger.Errorf("Message [%d] with topic %s for lobby [%v] could not be delivered after %d attempts and is moved to the dead letters: %v", message.ID, message.Topic, message.LobbyId, message.Attempts, err)
+		return
+	}
+	context.Logger.Warnf("Message [%d] with topic %s for lobby [%v] could not be delivered. Retry at %v: %v", message.ID, message.Topic, message.LobbyId, message.NextAttempt, err)
+}
+
+func outboxBackoff(attempts int, maxBackoff time.Duration) time.Duration {
+	backoff := outbox_base_backoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func (core CoreFacade) GetFailedMessages(context *util.Context) ([]*OutboxMessage, error) {
+	context.Logger.Debug("Get failed messages")
+	tx, err := core.startTransaction()
+	if err != nil {
+		return nil, err
+	}
+	defer core.rollback(tx)
+
+	messages, err := tx.dbTx.GetFailedOutboxMessages()
+	if err != nil {
+		return nil, fmt.Errorf("something went wrong while loading failed messages from database: %v", err)
+	}
+
+	return mapToOutboxMessages(messages), core.commit(tx, context)
+}
+
+func mapToOutboxMessages(dbMessages []*db.OutboxMessage) []*OutboxMessage {
+	messages := make([]*OutboxMessage, len(dbMessages))
+	for index, message := range dbMessages {
+		messages[index] = &OutboxMessage{ID: message.ID, LobbyId: message.LobbyId, SenderPlayerId: message.SenderPlayerId, CorrelationId: message.CorrelationId, Topic: message.Topic, Payload: message.Payload, CreatedAt: message.CreatedAt, Attempts: message.Attempts, NextAttempt: message.NextAttempt, LastError: message.LastError}
+	}
+	return messages
+}
@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/adapter"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingSink struct {
+	mutex   sync.Mutex
+	failing bool
+	sink    *adapter.RecordingSink
+}
+
+func newFailingSink() *failingSink {
+	return &failingSink{sink: adapter.NewRecordingSink()}
+}
+
+func (sink *failingSink) SendMessage(context *util.Context, message *adapter.Message, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if sink.failing {
+		return errors.New("sink not reachable")
+	}
+	return sink.sink.SendMessage(context, message, lobbyId, senderPlayerId)
+}
+
+func (sink *failingSink) Ping(context *util.Context) error {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	if sink.failing {
+		return errors.New("sink not reachable")
+	}
+	return nil
+}
+
+func (sink *failingSink) setFailing(failing bool) {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.failing = failing
+}
+
+func dispatchAll(t *testing.T, core *CoreFacade) {
+	for {
+		delivered, err := core.dispatchOutboxMessages(newTestContext())
+		assert.Nil(t, err)
+		if delivered == 0 {
+			return
+		}
+	}
+}
+
+func TestOutboxBackoff_FirstAttempt(t *testing.T) {
+	assert.Equal(t, outbox_base_backoff, outboxBackoff(1, time.Minute))
+}
+
+func TestOutboxBackoff_Doubles(t *testing.T) {
+	assert.Equal(t, 8*outbox_base_backoff, outboxBackoff(4, time.Minute))
+}
+
+func TestOutboxBackoff_Capped(t *testing.T) {
+	assert.Equal(t, time.Minute, outboxBackoff(100, time.Minute))
+}
+
+func TestDispatchOutboxMessages_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	dispatchAll(t, core)
+
+	sink := core.messageSink.(*adapter.RecordingSink)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY}, sink.Topics())
+	assert.Equal(t, lobby.Owner.ID, sink.Messages()[0].SenderPlayerId)
+	assert.Empty(t, storedMessages(t, core))
+}
+
+func TestDispatchOutboxMessages_RetryKeepsOrder(t *testing.T) {
+	core := newTestCore(t)
+	sink := newFailingSink()
+	core.messageSink = sink
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	sink.setFailing(true)
+	dispatchAll(t, core)
+
+	messages := storedMessages(t, core)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, 1, messages[0].Attempts)
+	assert.NotEmpty(t, messages[0].LastError)
+	assert.Equal(t, 0, messages[1].Attempts)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), player.ID))
+	sink.setFailing(false)
+	dispatchAll(t, core)
+	assert.Empty(t, sink.sink.Topics())
+
+	tx, err := core.db.StartTransaction()
+	assert.Nil(t, err)
+	messages[0].NextAttempt = time.Now()
+	assert.Nil(t, tx.UpdateOutboxMessage(messages[0]))
+	assert.Nil(t, tx.Commit())
+
+	dispatchAll(t, core)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LEAVES_LOBBY}, sink.sink.Topics())
+}
+
+func TestClaimOutboxMessages_ClaimedMessagesAreSkipped(t *testing.T) {
+	core := newTestCore(t)
+	createTestLobby(t, core, 4)
+
+	claimed, err := core.claimOutboxMessages()
+	assert.Nil(t, err)
+	assert.Len(t, claimed, 1)
+
+	claimedAgain, err := core.claimOutboxMessages()
+	assert.Nil(t, err)
+	assert.Empty(t, claimedAgain)
+
+	messages := storedMessages(t, core)
+	assert.Len(t, messages, 1)
+	assert.True(t, messages[0].NextAttempt.After(time.Now()))
+	assert.Equal(t, 0, messages[0].Attempts)
+
+	assert.Nil(t, core.settleOutboxMessage(newTestContext(), claimed[0], nil))
+	assert.Empty(t, storedMessages(t, core))
+}
+
+func TestGetFailedMessages(t *testing.T) {
+	core := newTestCore(t)
+	sink := newFailingSink()
+	core.messageSink = sink
+	core.outboxFailedAttempts = 1
+	createTestLobby(t, core, 4)
+
+	messages, err := core.GetFailedMessages(newTestContext())
+	assert.Nil(t, err)
+	assert.Empty(t, messages)
+
+	sink.setFailing(true)
+	dispatchAll(t, core)
+
+	messages, err = core.GetFailedMessages(newTestContext())
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, PLAYER_JOINS_LOBBY, messages[0].Topic)
+}
+
+func TestDispatchOutboxMessages_DeadLetterDoesNotBlockLobby(t *testing.T) {
+	core := newTestCore(t)
+	sink := newFailingSink()
+	core.messageSink = sink
+	core.outboxFailedAttempts = 1
+	lobby := createTestLobby(t, core, 4)
+
+	sink.setFailing(true)
+	dispatchAll(t, core)
+	sink.setFailing(false)
+	joinTestPlayer(t, core, lobby.ID, false)
+	dispatchAll(t, core)
+
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY}, sink.sink.Topics())
+	messages := storedMessages(t, core)
+	assert.Len(t, messages, 1)
+	assert.True(t, messages[0].DeadLetter)
+	assert.Equal(t, lobby.Owner.ID, messages[0].SenderPlayerId)
+}
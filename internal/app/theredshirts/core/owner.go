@@ -0,0 +1,93 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+const (
+	// succession_longest_present hands the lobby to the longest present player, spectators only if no player is left
+	succession_longest_present = "longest-present"
+	// succession_players_only never hands the lobby to a spectator, it is closed instead
+	succession_players_only = "players-only"
+)
+
+func (core CoreFacade) TransferOwnership(context *util.Context, lobbyId uuid.UUID, newOwnerId uuid.UUID, ownerId uuid.UUID) error {
+	context.Logger.Debugf("Transfer ownership of lobby [%v] from [%v] to [%v]", lobbyId, ownerId, newOwnerId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+
+	if _, err := core.getOwnedLobby(tx, lobbyId, ownerId); err != nil {
+		return err
+	}
+
+	if newOwnerId == ownerId {
+		return nil
+	}
+
+	newOwner, err := tx.dbTx.GetPlayerById(newOwnerId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading player [%v] from database: %v", newOwnerId, err)
+	}
+
+	if newOwner == nil || newOwner.LobbyId != lobbyId {
+		return fmt.Errorf("player [%v] can't become owner of lobby [%v]: %w", newOwnerId, lobbyId, ErrPlayerNotInLobby)
+	}
+
+	if err := core.changeOwner(tx, lobbyId, newOwnerId, ownerId); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+func (core CoreFacade) changeOwner(tx *transaction, lobbyId uuid.UUID, newOwnerId uuid.UUID, senderPlayerId uuid.UUID) error {
+	lobby, err := tx.dbTx.GetLobbyById(lobbyId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading lobby [%v] from database: %v", lobbyId, err)
+	}
+
+	if lobby == nil {
+		return ErrLobbyNotFound
+	}
+
+	oldOwnerId := lobby.Owner
+	lobby.Owner = newOwnerId
+	if err := tx.dbTx.UpdateLobby(lobby); err != nil {
+		return fmt.Errorf("something went wrong while updating owner of lobby [%v]: %w", lobbyId, wrapVersionConflict(err))
+	}
+
+	tx.messages = append(tx.messages, &message{senderPlayerId: senderPlayerId, lobbyId: lobbyId, topic: OWNER_CHANGED, payload: map[string]interface{}{"old_owner_id": oldOwnerId, "new_owner_id": newOwnerId}})
+	return nil
+}
+
+// findSuccessor picks the next owner of the lobby. Players are preferred over spectators, the longest present one wins
+func findSuccessor(players []*Player, ownerId uuid.UUID, succession string) *Player {
+	candidates := make([]*Player, 0, len(players))
+	for _, player := range players {
+		if player.ID == ownerId || (player.Spectator && succession == succession_players_only) {
+			continue
+		}
+		candidates = append(candidates, player)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Spectator != candidates[j].Spectator {
+			return !candidates[i].Spectator
+		}
+		if !candidates[i].JoinedAt.Equal(candidates[j].JoinedAt) {
+			return candidates[i].JoinedAt.Before(candidates[j].JoinedAt)
+		}
+		return candidates[i].ID.String() < candidates[j].ID.String()
+	})
+
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[0]
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setJoinedAt(t *testing.T, core *CoreFacade, player *Player, joinedAt time.Time) {
+	tx, err := core.db.StartTransaction()
+	assert.Nil(t, err)
+	foundPlayer, err := tx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	foundPlayer.JoinedAt = joinedAt
+	assert.Nil(t, tx.UpdatePlayer(foundPlayer))
+	assert.Nil(t, tx.Commit())
+}
+
+func TestTransferOwnership_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, true)
+
+	assert.Nil(t, core.TransferOwnership(newTestContext(), lobby.ID, player.ID, lobby.Owner.ID))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, foundLobby.Owner.ID)
+	messages := storedMessages(t, core)
+	changed := messages[len(messages)-1]
+	assert.Equal(t, OWNER_CHANGED, changed.Topic)
+	assert.Equal(t, lobby.Owner.ID, changed.SenderPlayerId)
+	assert.Equal(t, map[string]interface{}{"old_owner_id": lobby.Owner.ID, "new_owner_id": player.ID}, changed.Payload)
+}
+
+func TestTransferOwnership_NotOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.TransferOwnership(newTestContext(), lobby.ID, player.ID, player.ID)
+	assert.ErrorIs(t, err, ErrNotOwner)
+}
+
+func TestTransferOwnership_PlayerInOtherLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	otherLobby := createTestLobby(t, core, 4)
+
+	err := core.TransferOwnership(newTestContext(), lobby.ID, otherLobby.Owner.ID, lobby.Owner.ID)
+	assert.ErrorIs(t, err, ErrPlayerNotInLobby)
+}
+
+func TestDeletePlayer_OwnerLeavesToLongestPresentPlayer(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+	first := joinTestPlayer(t, core, lobby.ID, false)
+	second := joinTestPlayer(t, core, lobby.ID, false)
+	setJoinedAt(t, core, second, time.Now().Add(-time.Hour))
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, second.ID, foundLobby.Owner.ID)
+	assert.NotEqual(t, spectator.ID, foundLobby.Owner.ID)
+	assert.NotEqual(t, first.ID, foundLobby.Owner.ID)
+	assert.Equal(t, []string{OWNER_CHANGED, PLAYER_LEAVES_LOBBY}, storedTopics(t, core)[4:])
+}
+
+func TestDeletePlayer_OwnerLeavesToSpectator(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, spectator.ID, foundLobby.Owner.ID)
+}
+
+func TestDeletePlayer_OwnerLeavesPlayersOnly(t *testing.T) {
+	core := newTestCore(t)
+	core.ownerSuccession = succession_players_only
+	lobby := createTestLobby(t, core, 4)
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+
+	_, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.ErrorIs(t, err, ErrLobbyNotFound)
+	foundSpectator, err := core.GetPlayer(newTestContext(), spectator.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundSpectator)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LEAVES_LOBBY, PLAYER_LEAVES_LOBBY}, storedTopics(t, core))
+}
+
+func TestFindSuccessor_SameJoinTime(t *testing.T) {
+	joinedAt := time.Now()
+	first := &Player{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), JoinedAt: joinedAt}
+	second := &Player{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), JoinedAt: joinedAt}
+
+	assert.Equal(t, first, findSuccessor([]*Player{second, first}, uuid.New(), succession_longest_present))
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// hashPassword salts and hashes the password of a lobby. A lobby without password keeps an empty password
+func (core CoreFacade) hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), core.passwordCost)
+	if err != nil {
+		return "", fmt.Errorf("error while hashing password: %v", err)
+	}
+	return string(hash), nil
+}
+
+func checkPassword(hash string, password string) bool {
+	if hash == "" {
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(password)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// checkJoinPassword compares the password and counts failed attempts. After too many failed attempts the player
+// has to wait for the lockout before the password is checked again
+func (core CoreFacade) checkJoinPassword(tx *transaction, lobby *db.Lobby, playerId uuid.UUID, password string) error {
+	attempt, err := tx.dbTx.GetJoinAttempt(lobby.ID, playerId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading join attempts of player %v in lobby %v from database: %v", playerId, lobby.ID, err)
+	}
+
+	now := time.Now()
+	if attempt != nil && attempt.LastFailed.Add(core.joinLockout).Before(now) {
+		attempt.FailedAttempts = 0
+	}
+
+	if attempt != nil && attempt.FailedAttempts >= core.joinMaxFailedAttempts {
+		return fmt.Errorf("player %v is locked out of lobby %v until %v: %w", playerId, lobby.ID, attempt.LastFailed.Add(core.joinLockout), ErrTooManyAttempts)
+	}
+
+	if checkPassword(lobby.Password, password) {
+		if attempt != nil {
+			if err := tx.dbTx.DeleteJoinAttempt(lobby.ID, playerId); err != nil {
+				return fmt.Errorf("something went wrong while deleting join attempts of player %v in lobby %v: %v", playerId, lobby.ID, err)
+			}
+		}
+		return nil
+	}
+
+	if attempt == nil {
+		attempt = &db.JoinAttempt{LobbyId: lobby.ID, PlayerId: playerId}
+	}
+	attempt.FailedAttempts++
+	attempt.LastFailed = now
+	if err := tx.dbTx.UpsertJoinAttempt(attempt); err != nil {
+		return fmt.Errorf("something went wrong while storing join attempt of player %v in lobby %v: %v", playerId, lobby.ID, err)
+	}
+	return ErrWrongLobbyPassword
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	core := newTestCore(t)
+
+	hash, err := core.hashPassword(test_password)
+	assert.Nil(t, err)
+	assert.NotEqual(t, test_password, hash)
+	assert.True(t, checkPassword(hash, test_password))
+	assert.False(t, checkPassword(hash, "wrong"))
+}
+
+func TestHashPassword_Empty(t *testing.T) {
+	core := newTestCore(t)
+
+	hash, err := core.hashPassword("")
+	assert.Nil(t, err)
+	assert.Empty(t, hash)
+	assert.True(t, checkPassword(hash, ""))
+	assert.False(t, checkPassword(hash, "wrong"))
+}
+
+func TestCreateLobby_StoresHashedPassword(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	tx, err := core.db.StartTransaction()
+	assert.Nil(t, err)
+	defer tx.Rollback()
+	dbLobby, err := tx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	assert.NotEqual(t, test_password, dbLobby.Password)
+	assert.True(t, checkPassword(dbLobby.Password, test_password))
+}
+
+func TestCreatePlayer_LockedOut(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+
+	for attempt := 0; attempt < core.joinMaxFailedAttempts; attempt++ {
+		assert.ErrorIs(t, joinWithPassword(core, player, "wrong"), ErrWrongLobbyPassword)
+	}
+	assert.ErrorIs(t, joinWithPassword(core, player, test_password), ErrTooManyAttempts)
+
+	otherPlayer := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	assert.Nil(t, joinWithPassword(core, otherPlayer, test_password))
+}
+
+func TestCreatePlayer_LockoutExpires(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+
+	for attempt := 0; attempt < core.joinMaxFailedAttempts; attempt++ {
+		assert.ErrorIs(t, joinWithPassword(core, player, "wrong"), ErrWrongLobbyPassword)
+	}
+	core.joinLockout = -time.Second
+
+	assert.Nil(t, joinWithPassword(core, player, test_password))
+
+	tx, err := core.db.StartTransaction()
+	assert.Nil(t, err)
+	defer tx.Rollback()
+	attempt, err := tx.GetJoinAttempt(lobby.ID, player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, attempt)
+}
+
+func TestCreatePlayer_PasswordCheckedFirst(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	bannedPlayer := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	assert.Nil(t, core.KickPlayer(newTestContext(), lobby.ID, bannedPlayer.ID, lobby.Owner.ID, "", true))
+	joinTestPlayer(t, core, lobby.ID, false)
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_starting))
+
+	assert.ErrorIs(t, joinWithPassword(core, bannedPlayer, "wrong"), ErrWrongLobbyPassword)
+	assert.ErrorIs(t, joinWithPassword(core, bannedPlayer, test_password), ErrPlayerBanned)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	for _, player := range foundLobby.Players {
+		assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+	}
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_playing))
+
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	assert.ErrorIs(t, joinWithPassword(core, player, "wrong"), ErrWrongLobbyPassword)
+	assert.ErrorIs(t, joinWithPassword(core, player, test_password), ErrLobbyPlaying)
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,65 +10,134 @@ import (
 	"github.com/google/uuid"
 )
 
-func (core CoreFacade) CreatePlayer(context *util.Context, player *Player, password string) error {
+// CreatePlayer joins the lobby and returns a token to reconnect, after the player was dropped. Every join replaces the token
+func (core CoreFacade) CreatePlayer(context *util.Context, player *Player, password string) (string, error) {
 	context.Logger.Debugf("Creating Player: %+v", *player)
 	tx, err := core.startTransaction()
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer core.rollback(tx)
 
 	if err := core.createPlayer(context, tx, player.ID, player.Name, player.LobbyId, password, player.Spectator, player.Payload); err != nil {
-		return err
+		if errors.Is(err, ErrWrongLobbyPassword) {
+			// the failed attempt has to be stored, although the player can't join
+			if err := core.commit(tx, context); err != nil {
+				return "", err
+			}
+		}
+		return "", err
 	}
-	return core.commit(tx, context)
+
+	token, err := core.issueReconnectToken(tx, player.ID, player.LobbyId)
+	if err != nil {
+		return "", err
+	}
+	return token, core.commit(tx, context)
 }
 
 func (core CoreFacade) createPlayer(context *util.Context, tx *transaction, playerId uuid.UUID, playerName string, lobbyId uuid.UUID, password string, spectator bool, payload map[string]interface{}) error {
 
-	player, err := core.getPlayer(tx, playerId)
+	joined, err := core.isAlreadyJoined(tx, playerId, playerName, lobbyId, spectator)
+	if err != nil || joined {
+		return err
+	}
+
+	lobby, err := tx.dbTx.GetLobbyByIdForUpdate(lobbyId)
 	if err != nil {
+		return fmt.Errorf("something went wrong while loading lobby %v from database: %v", lobbyId, err)
+	}
+
+	if lobby == nil {
+		return ErrLobbyNotFound
+	}
+
+	// the password is checked first, so the other rejections don't reveal the state of the lobby without it
+	if err := core.checkJoinPassword(tx, lobby, playerId, password); err != nil {
 		return err
 	}
 
-	if player != nil {
-		if player.Name != playerName || player.LobbyId != lobbyId || player.Spectator != spectator {
-			return fmt.Errorf("Player already exists with different parameters")
-		}
-		return nil
+	if err := core.checkBan(tx, lobbyId, playerId); err != nil {
+		return err
 	}
 
-	lobby, err := tx.dbTx.GetLobbyById(lobbyId)
+	if lobby.Visibility == visibility_private && lobby.Owner != playerId {
+		return ErrLobbyPrivate
+	}
+
+	if lobby.Status == lobby_playing && !spectator {
+		return ErrLobbyPlaying
+	}
+
+	return core.joinLobby(tx, lobby, playerId, playerName, spectator, payload)
+}
+
+// isAlreadyJoined reports whether the player is already part of the lobby, so repeated joins are idempotent
+func (core CoreFacade) isAlreadyJoined(tx *transaction, playerId uuid.UUID, playerName string, lobbyId uuid.UUID, spectator bool) (bool, error) {
+	player, err := core.getPlayer(tx, playerId)
 	if err != nil {
-		return fmt.Errorf("something went wrong while loading lobby %v from database: %v", lobbyId, err)
+		return false, err
 	}
 
-	if lobby == nil {
-		return fmt.Errorf("lobby not found")
+	if player == nil {
+		return false, nil
 	}
 
-	if lobby.Password != password {
-		return ErrWrongLobbyPassword
+	if player.Name != playerName || player.LobbyId != lobbyId || player.Spectator != spectator {
+		return false, fmt.Errorf("player [%v] already exists in lobby [%v]: %w", playerId, player.LobbyId, ErrPlayerConflict)
 	}
+	return true, nil
+}
 
-	playerCount, err := tx.dbTx.GetNumberOfPlayersInLobby(lobbyId)
+func (core CoreFacade) checkBan(tx *transaction, lobbyId uuid.UUID, playerId uuid.UUID) error {
+	ban, err := tx.dbTx.GetLobbyBan(lobbyId, playerId)
 	if err != nil {
-		return fmt.Errorf("something went wrong while loading number of players from lobby %v from database: %v", lobbyId, err)
+		return fmt.Errorf("something went wrong while loading ban of player %v in lobby %v from database: %v", playerId, lobbyId, err)
 	}
 
-	if lobby.MaxPlayers <= playerCount {
-		return ErrLobbyFull
+	if ban != nil {
+		return ErrPlayerBanned
+	}
+	return nil
+}
+
+// joinLobby adds the player to the lobby once all access checks have passed
+func (core CoreFacade) joinLobby(tx *transaction, lobby *db.Lobby, playerId uuid.UUID, playerName string, spectator bool, payload map[string]interface{}) error {
+	if err := core.checkCapacity(tx, lobby); err != nil {
+		return err
 	}
 
-	if err := tx.dbTx.CreatePlayer(&db.Player{ID: playerId, Name: playerName, LobbyId: lobbyId, LastRefresh: time.Now(), Spectator: spectator, Payload: payload}); err != nil {
+	now := time.Now()
+	if err := tx.dbTx.CreatePlayer(&db.Player{ID: playerId, Name: playerName, LobbyId: lobby.ID, LastRefresh: now, JoinedAt: now, Spectator: spectator, Payload: payload}); err != nil {
 		return fmt.Errorf("something went wrong while creating player %v from database: %v", playerId, err)
 	}
+	if err := core.touchLobby(tx, lobby.ID); err != nil {
+		return err
+	}
 
-	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: lobbyId, topic: PLAYER_JOINS_LOBBY, payload: map[string]interface{}{"player_id": playerId, "player_name": playerName, "spectator": spectator}})
+	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: lobby.ID, topic: PLAYER_JOINS_LOBBY, payload: map[string]interface{}{"player_id": playerId, "player_name": playerName, "spectator": spectator}})
 
 	return nil
 }
 
+// checkCapacity counts the players and the seats held for dropped players against the maximum of the lobby
+func (core CoreFacade) checkCapacity(tx *transaction, lobby *db.Lobby) error {
+	playerCount, err := tx.dbTx.GetNumberOfPlayersInLobby(lobby.ID)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading number of players from lobby %v from database: %v", lobby.ID, err)
+	}
+
+	heldSeats, err := tx.dbTx.GetNumberOfHeldSeats(lobby.ID, time.Now())
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading number of held seats from lobby %v from database: %v", lobby.ID, err)
+	}
+
+	if lobby.MaxPlayers <= playerCount+heldSeats {
+		return ErrLobbyFull
+	}
+	return nil
+}
+
 func (core CoreFacade) UpdatePlayer(context *util.Context, player *Player, playerId uuid.UUID) error {
 	context.Logger.Debugf("Updating Player: %+v", *player)
 	tx, err := core.startTransaction()
@@ -89,7 +159,7 @@ func (core CoreFacade) updatePlayer(context *util.Context, tx *transaction, play
 	}
 
 	if ownerPlayer == nil {
-		return fmt.Errorf("player [%v] not found", playerId)
+		return fmt.Errorf("player [%v]: %w", playerId, ErrPlayerNotFound)
 	}
 
 	foundPlayer, err := tx.dbTx.GetPlayerById(player.ID)
@@ -98,7 +168,7 @@ func (core CoreFacade) updatePlayer(context *util.Context, tx *transaction, play
 	}
 
 	if foundPlayer == nil {
-		return fmt.Errorf("player [%v] not found", player.ID)
+		return fmt.Errorf("player [%v]: %w", player.ID, ErrPlayerNotFound)
 	}
 
 	if ownerPlayer.ID != foundPlayer.ID {
@@ -108,41 +178,48 @@ func (core CoreFacade) updatePlayer(context *util.Context, tx *transaction, play
 		}
 
 		if lobby == nil {
-			return fmt.Errorf("lobby not found")
+			return ErrLobbyNotFound
 		}
 
 		if lobby.Owner != ownerPlayer.ID {
-			return fmt.Errorf("player [%v] is not owner of lobby [%v]", ownerPlayer.ID, lobby.ID)
+			return fmt.Errorf("player [%v] is not owner of lobby [%v]: %w", ownerPlayer.ID, lobby.ID, ErrNotOwner)
 		}
 	}
 
 	if foundPlayer.Spectator != player.Spectator && !player.Spectator {
-		playerCount, err := tx.dbTx.GetNumberOfPlayersInLobby(foundPlayer.LobbyId)
+		lobby, err := tx.dbTx.GetLobbyByIdForUpdate(foundPlayer.LobbyId)
 		if err != nil {
-			return fmt.Errorf("something went wrong while loading number of players from lobby %v from database: %v", player.LobbyId, err)
+			return fmt.Errorf("something went wrong while loading lobby %v from database: %v", foundPlayer.LobbyId, err)
 		}
 
-		lobby, err := tx.dbTx.GetLobbyById(player.LobbyId)
-		if err != nil {
-			return fmt.Errorf("something went wrong while loading lobby %v from database: %v", player.LobbyId, err)
+		if lobby == nil {
+			return ErrLobbyNotFound
 		}
 
-		if lobby == nil {
-			return fmt.Errorf("lobby not found")
+		if lobby.Status == lobby_playing {
+			return ErrLobbyPlaying
 		}
 
-		if lobby.MaxPlayers <= playerCount {
-			return ErrLobbyFull
+		if err := core.checkCapacity(tx, lobby); err != nil {
+			return err
 		}
 	}
 
+	if err := checkVersion(player.ID, player.Version, foundPlayer.Version); err != nil {
+		return err
+	}
+
 	foundPlayer.LastRefresh = time.Now()
+	foundPlayer.AfkNotice = ""
 	foundPlayer.Name = player.Name
 	foundPlayer.Spectator = player.Spectator
 	foundPlayer.Payload = player.Payload
 
 	if err := tx.dbTx.UpdatePlayer(foundPlayer); err != nil {
-		return fmt.Errorf("something went wrong while updating player [%v]: %v", player.ID, err)
+		return fmt.Errorf("something went wrong while updating player [%v]: %w", player.ID, wrapVersionConflict(err))
+	}
+	if err := core.touchLobby(tx, foundPlayer.LobbyId); err != nil {
+		return err
 	}
 	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: foundPlayer.LobbyId, topic: PLAYER_UPDATED,
 		payload: map[string]interface{}{
@@ -155,22 +232,7 @@ func (core CoreFacade) updatePlayer(context *util.Context, tx *transaction, play
 
 func (core CoreFacade) UpdatePlayerLastRefresh(context *util.Context, playerId uuid.UUID) error {
 	context.Logger.Debugf("Updating Player last refresh [%v]", playerId)
-	tx, err := core.startTransaction()
-	if err != nil {
-		return err
-	}
-	defer core.rollback(tx)
-	if err := core.updatePlayerLastRefresh(tx, playerId); err != nil {
-		return err
-	}
-	return core.commit(tx, context)
-}
-
-func (core CoreFacade) updatePlayerLastRefresh(tx *transaction, playerId uuid.UUID) error {
-
-	if err := tx.dbTx.UpdatePlayerLastRefresh(playerId, time.Now()); err != nil {
-		return fmt.Errorf("something went wrong while updating last refresh of player [%v]: %v", playerId, err)
-	}
+	core.presence.refresh(playerId, time.Now())
 	return nil
 }
 
@@ -184,6 +246,10 @@ func (core CoreFacade) DeletePlayer(context *util.Context, playerId uuid.UUID) e
 	if err := core.deletePlayer(context, tx, playerId); err != nil {
 		return err
 	}
+	// a player who left on their own can't reconnect
+	if err := tx.dbTx.DeleteReconnectToken(playerId); err != nil {
+		return fmt.Errorf("error while deleting reconnect token of player [%v]: %v", playerId, err)
+	}
 	return core.commit(tx, context)
 }
 
@@ -205,13 +271,13 @@ func (core CoreFacade) deletePlayer(context *util.Context, tx *transaction, play
 	}
 
 	if lobby == nil {
-		return fmt.Errorf("lobby not found")
+		return ErrLobbyNotFound
 	}
 
 	if lobby.Owner.ID == playerId {
 		context.Logger.Debugf("Player who is leaving the lobby is also owner of lobby [%s]", player.LobbyId)
-		foundNewOwner := findPlayerNot(lobby.Players, playerId)
-		if foundNewOwner == nil {
+		successor := findSuccessor(lobby.Players, playerId, core.ownerSuccession)
+		if successor == nil {
 			context.Logger.Debugf("No new owner found. Deleting lobby [%s]", player.LobbyId)
 			if err := tx.dbTx.DeletePlayer(playerId); err != nil {
 				return fmt.Errorf("error while deleting player [%v] from database: %v", playerId, err)
@@ -223,18 +289,18 @@ func (core CoreFacade) deletePlayer(context *util.Context, tx *transaction, play
 				return err
 			}
 			return nil
-		} else {
-			context.Logger.Debugf("Player [%s] found to be the new owner of lobby [%s]", foundNewOwner.ID, player.LobbyId)
-			lobby.Owner = foundNewOwner
-			if err := core.updateLobby(context, tx, lobby, playerId); err != nil {
-				return err
-			}
-			tx.messages = append(tx.messages, &message{senderPlayerId: uuid.Nil, lobbyId: player.LobbyId, topic: PLAYER_LEAVES_LOBBY, payload: map[string]interface{}{"player_id": playerId}})
+		}
+		context.Logger.Debugf("Player [%s] found to be the new owner of lobby [%s]", successor.ID, player.LobbyId)
+		if err := core.changeOwner(tx, player.LobbyId, successor.ID, uuid.Nil); err != nil {
+			return err
 		}
 	}
 	if err := tx.dbTx.DeletePlayer(playerId); err != nil {
 		return fmt.Errorf("error while deleting player [%v] from database: %v", playerId, err)
 	}
+	if err := core.touchLobby(tx, player.LobbyId); err != nil {
+		return err
+	}
 
 	tx.messages = append(tx.messages, &message{senderPlayerId: uuid.Nil, lobbyId: player.LobbyId, topic: PLAYER_LEAVES_LOBBY, payload: map[string]interface{}{"player_id": playerId}})
 
@@ -267,7 +333,7 @@ func mapToPlayer(player *db.Player) *Player {
 	if player == nil {
 		return nil
 	}
-	return &Player{ID: player.ID, Name: player.Name, LastRefresh: player.LastRefresh, LobbyId: player.LobbyId, Spectator: player.Spectator, Payload: player.Payload}
+	return &Player{ID: player.ID, Name: player.Name, LastRefresh: player.LastRefresh, JoinedAt: player.JoinedAt, LobbyId: player.LobbyId, Spectator: player.Spectator, Ready: player.Ready, Payload: player.Payload, Version: player.Version}
 }
 
 func mapToPlayers(dbPlayers []*db.Player) []*Player {
@@ -277,12 +343,3 @@ func mapToPlayers(dbPlayers []*db.Player) []*Player {
 	}
 	return players
 }
-
-func findPlayerNot(players []*Player, notPlayerId uuid.UUID) *Player {
-	for _, player := range players {
-		if player.ID != notPlayerId {
-			return player
-		}
-	}
-	return nil
-}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreatePlayer_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.ID, foundPlayer.LobbyId)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY}, storedTopics(t, core))
+}
+
+func TestCreatePlayer_WrongPassword(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	_, err := core.CreatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}, "wrong")
+	assert.ErrorIs(t, err, ErrWrongLobbyPassword)
+}
+
+func TestCreatePlayer_LobbyFull(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 1)
+
+	_, err := core.CreatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}, test_password)
+	assert.ErrorIs(t, err, ErrLobbyFull)
+}
+
+func TestCreatePlayer_AlreadyInOtherLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	otherLobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	player.LobbyId = otherLobby.ID
+	_, err := core.CreatePlayer(newTestContext(), player, test_password)
+	assert.ErrorIs(t, err, ErrPlayerConflict)
+}
+
+func TestUpdatePlayer_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	player.Name = "Renamed"
+	assert.Nil(t, core.UpdatePlayer(newTestContext(), player, player.ID))
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", foundPlayer.Name)
+}
+
+func TestUpdatePlayer_ByOtherPlayer(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	otherPlayer := joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.UpdatePlayer(newTestContext(), player, otherPlayer.ID)
+	assert.ErrorIs(t, err, ErrNotOwner)
+}
+
+func TestUpdatePlayer_NotFound(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	err := core.UpdatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Unknown"}, lobby.Owner.ID)
+	assert.ErrorIs(t, err, ErrPlayerNotFound)
+}
+
+func TestUpdatePlayer_SpectatorIntoFullLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 2)
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	spectator.Spectator = false
+	err := core.UpdatePlayer(newTestContext(), spectator, spectator.ID)
+	assert.ErrorIs(t, err, ErrLobbyFull)
+}
+
+func TestUpdatePlayer_SpectatorCantPlayWhilePlaying(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	startTestGame(t, core, lobby, player)
+
+	spectator.Spectator = false
+	err := core.UpdatePlayer(newTestContext(), spectator, spectator.ID)
+	assert.ErrorIs(t, err, ErrLobbyPlaying)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), spectator.ID)
+	assert.Nil(t, err)
+	assert.True(t, foundPlayer.Spectator)
+}
+
+func TestDeletePlayer_OwnerLeaves(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), lobby.Owner.ID))
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, foundLobby.Owner.ID)
+	assert.Len(t, foundLobby.Players, 1)
+}
+
+func TestDeletePlayer_Unknown(t *testing.T) {
+	core := newTestCore(t)
+
+	assert.Nil(t, core.DeletePlayer(newTestContext(), uuid.New()))
+}
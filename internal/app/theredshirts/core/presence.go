@@ -0,0 +1,144 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/go-co-op/gocron"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// presence collects heartbeats and open connections of players, so their last refresh can be written in batches
+type presence struct {
+	mutex     sync.Mutex
+	connected map[uuid.UUID]int
+	refreshes map[uuid.UUID]time.Time
+}
+
+func newPresence() *presence {
+	return &presence{connected: make(map[uuid.UUID]int), refreshes: make(map[uuid.UUID]time.Time)}
+}
+
+func (presence *presence) refresh(playerId uuid.UUID, lastRefresh time.Time) {
+	presence.mutex.Lock()
+	defer presence.mutex.Unlock()
+	presence.refreshLocked(playerId, lastRefresh)
+}
+
+func (presence *presence) refreshLocked(playerId uuid.UUID, lastRefresh time.Time) {
+	if pending, ok := presence.refreshes[playerId]; !ok || pending.Before(lastRefresh) {
+		presence.refreshes[playerId] = lastRefresh
+	}
+}
+
+func (presence *presence) connect(playerId uuid.UUID) {
+	presence.mutex.Lock()
+	defer presence.mutex.Unlock()
+	presence.connected[playerId]++
+}
+
+// disconnect closes one connection of the player. After the last connection is closed the player has to refresh
+// in time again, otherwise the scavenger treats them as lagging
+func (presence *presence) disconnect(playerId uuid.UUID, disconnectedAt time.Time) {
+	presence.mutex.Lock()
+	defer presence.mutex.Unlock()
+	presence.connected[playerId]--
+	if presence.connected[playerId] <= 0 {
+		delete(presence.connected, playerId)
+		presence.refreshLocked(playerId, disconnectedAt)
+	}
+}
+
+func (presence *presence) isConnected(playerId uuid.UUID) bool {
+	presence.mutex.Lock()
+	defer presence.mutex.Unlock()
+	return presence.connected[playerId] > 0
+}
+
+// take returns all pending refreshes, connected players are refreshed with now
+func (presence *presence) take(now time.Time) map[uuid.UUID]time.Time {
+	presence.mutex.Lock()
+	defer presence.mutex.Unlock()
+	for playerId := range presence.connected {
+		presence.refreshLocked(playerId, now)
+	}
+	refreshes := presence.refreshes
+	presence.refreshes = make(map[uuid.UUID]time.Time)
+	return refreshes
+}
+
+// restore puts refreshes back which could not be written
+func (presence *presence) restore(refreshes map[uuid.UUID]time.Time) {
+	presence.mutex.Lock()
+	defer presence.mutex.Unlock()
+	for playerId, lastRefresh := range refreshes {
+		presence.refreshLocked(playerId, lastRefresh)
+	}
+}
+
+func (core CoreFacade) startPresenceFlush() *gocron.Scheduler {
+	log.Info("Start flush of player presence")
+	s := gocron.NewScheduler(time.UTC)
+
+	s.Every(1).Seconds().SingletonMode().Do(func() {
+		correlationId := uuid.NewString()
+		logger := log.WithFields(log.Fields{
+			"Presence": correlationId,
+		})
+		context := &util.Context{CorrelationId: correlationId, Logger: logger}
+		if err := core.flushPresence(context); err != nil {
+			logger.Warnf("Error while flushing presence: %v", err)
+		}
+	})
+
+	s.StartAsync()
+	return s
+}
+
+func (core CoreFacade) flushPresence(context *util.Context) error {
+	refreshes := core.presence.take(time.Now())
+	if len(refreshes) == 0 {
+		return nil
+	}
+	context.Logger.Debugf("Flush last refresh of %d players", len(refreshes))
+
+	if err := core.writeLastRefreshes(context, refreshes); err != nil {
+		core.presence.restore(refreshes)
+		return err
+	}
+	return nil
+}
+
+func (core CoreFacade) writeLastRefreshes(context *util.Context, refreshes map[uuid.UUID]time.Time) error {
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+	if err := tx.dbTx.UpdatePlayersLastRefresh(refreshes); err != nil {
+		return fmt.Errorf("something went wrong while updating last refresh of players: %v", err)
+	}
+	return core.commit(tx, context)
+}
+
+// ConnectPlayer marks the player as present as long as the connection is open, if the player is part of the lobby.
+// The returned function has to be called when the connection is closed.
+func (core CoreFacade) ConnectPlayer(context *util.Context, lobbyId uuid.UUID, playerId uuid.UUID) (func(), error) {
+	player, err := core.GetPlayer(context, playerId)
+	if err != nil {
+		return nil, err
+	}
+	if player == nil || player.LobbyId != lobbyId {
+		return func() {}, nil
+	}
+
+	context.Logger.Debugf("Player [%v] connected to lobby [%v]", playerId, lobbyId)
+	core.presence.connect(playerId)
+	return func() {
+		context.Logger.Debugf("Player [%v] disconnected from lobby [%v]", playerId, lobbyId)
+		core.presence.disconnect(playerId, time.Now())
+	}, nil
+}
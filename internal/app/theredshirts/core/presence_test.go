@@ -0,0 +1,85 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence_KeepsLatestRefresh(t *testing.T) {
+	presence := newPresence()
+	playerId := uuid.New()
+	now := time.Now()
+
+	presence.refresh(playerId, now)
+	presence.refresh(playerId, now.Add(-time.Second))
+
+	refreshes := presence.take(now)
+	assert.Equal(t, map[uuid.UUID]time.Time{playerId: now}, refreshes)
+	assert.Empty(t, presence.take(now))
+}
+
+func TestPresence_ConnectedPlayerIsRefreshed(t *testing.T) {
+	presence := newPresence()
+	playerId := uuid.New()
+	now := time.Now()
+
+	presence.connect(playerId)
+	presence.connect(playerId)
+	presence.disconnect(playerId, now)
+	assert.True(t, presence.isConnected(playerId))
+	assert.Equal(t, now, presence.take(now)[playerId])
+
+	presence.disconnect(playerId, now)
+	assert.False(t, presence.isConnected(playerId))
+	assert.Equal(t, now, presence.take(now.Add(time.Minute))[playerId])
+}
+
+func TestUpdatePlayerLastRefresh_FlushedInBatch(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-time.Minute))
+	setLastRefresh(t, core, lobby.Owner, time.Now().Add(-time.Minute))
+
+	assert.Nil(t, core.UpdatePlayerLastRefresh(newTestContext(), player.ID))
+	assert.Nil(t, core.UpdatePlayerLastRefresh(newTestContext(), lobby.Owner.ID))
+	assert.Nil(t, core.flushPresence(newTestContext()))
+
+	for _, playerId := range []uuid.UUID{player.ID, lobby.Owner.ID} {
+		foundPlayer, err := core.GetPlayer(newTestContext(), playerId)
+		assert.Nil(t, err)
+		assert.WithinDuration(t, time.Now(), foundPlayer.LastRefresh, 5*time.Second)
+	}
+}
+
+func TestConnectPlayer_ScavengerSkipsConnectedPlayer(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-time.Minute))
+
+	disconnect, err := core.ConnectPlayer(newTestContext(), lobby.ID, player.ID)
+	assert.Nil(t, err)
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, foundPlayer)
+
+	disconnect()
+	assert.False(t, core.presence.isConnected(player.ID))
+}
+
+func TestConnectPlayer_OtherLobby(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	otherLobby := createTestLobby(t, core, 4)
+
+	disconnect, err := core.ConnectPlayer(newTestContext(), otherLobby.ID, lobby.Owner.ID)
+	assert.Nil(t, err)
+	defer disconnect()
+	assert.False(t, core.presence.isConnected(lobby.Owner.ID))
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+func (core CoreFacade) UpdatePlayerReady(context *util.Context, playerId uuid.UUID, ready bool) error {
+	context.Logger.Debugf("Updating ready of player [%v] to [%t]", playerId, ready)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return err
+	}
+	defer core.rollback(tx)
+	if err := core.updatePlayerReady(tx, playerId, ready); err != nil {
+		return err
+	}
+	return core.commit(tx, context)
+}
+
+func (core CoreFacade) updatePlayerReady(tx *transaction, playerId uuid.UUID, ready bool) error {
+	player, err := tx.dbTx.GetPlayerById(playerId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading player [%v] from database: %v", playerId, err)
+	}
+
+	if player == nil {
+		return fmt.Errorf("player [%v]: %w", playerId, ErrPlayerNotFound)
+	}
+
+	if player.Ready == ready {
+		return nil
+	}
+
+	player.Ready = ready
+	if err := tx.dbTx.UpdatePlayer(player); err != nil {
+		return fmt.Errorf("something went wrong while updating ready of player [%v]: %w", playerId, wrapVersionConflict(err))
+	}
+	if err := core.touchLobby(tx, player.LobbyId); err != nil {
+		return err
+	}
+
+	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: player.LobbyId, topic: PLAYER_READY_CHANGED, payload: map[string]interface{}{"player_id": playerId, "ready": ready}})
+	return nil
+}
+
+// resetPlayersReady sets all players of the lobby to not ready, every reset player gets its own ready changed message
+func (core CoreFacade) resetPlayersReady(tx *transaction, lobbyId uuid.UUID, senderPlayerId uuid.UUID) error {
+	playerIds, err := tx.dbTx.ResetPlayersReady(lobbyId)
+	if err != nil {
+		return fmt.Errorf("something went wrong while resetting ready of players in lobby [%v]: %v", lobbyId, err)
+	}
+	for _, playerId := range playerIds {
+		tx.messages = append(tx.messages, &message{senderPlayerId: senderPlayerId, lobbyId: lobbyId, topic: PLAYER_READY_CHANGED, payload: map[string]interface{}{"player_id": playerId, "ready": false}})
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePlayerReady_Successfully(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), lobby.Owner.ID)
+	assert.Nil(t, err)
+	assert.True(t, foundPlayer.Ready)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_READY_CHANGED}, storedTopics(t, core))
+}
+
+func TestUpdateLobbyStatus_PlayersNotReady(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, false)
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_starting))
+
+	assert.ErrorIs(t, updateTestLobbyStatus(core, lobby, lobby_playing), ErrPlayersNotReady)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	for _, player := range foundLobby.Players {
+		if player.ID != spectator.ID {
+			assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+		}
+	}
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_playing))
+}
+
+func TestUpdateLobby_ResetsReady(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+
+	lobby.Difficulty = 2
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.False(t, foundPlayer.Ready)
+	messages := storedMessages(t, core)
+	assert.Equal(t, PLAYER_UPDATES_LOBBY, messages[len(messages)-2].Topic)
+	resetMessage := messages[len(messages)-1]
+	assert.Equal(t, PLAYER_READY_CHANGED, resetMessage.Topic)
+	assert.Equal(t, player.ID, resetMessage.Payload["player_id"])
+	assert.Equal(t, false, resetMessage.Payload["ready"])
+}
+
+func TestUpdateLobbyStatus_OpenResetsReady(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_starting))
+
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_open))
+
+	resetPlayers := []interface{}{}
+	for _, message := range storedMessages(t, core)[5:] {
+		if message.Topic == PLAYER_READY_CHANGED {
+			assert.Equal(t, false, message.Payload["ready"])
+			resetPlayers = append(resetPlayers, message.Payload["player_id"])
+		}
+	}
+	assert.ElementsMatch(t, []interface{}{lobby.Owner.ID, player.ID}, resetPlayers)
+}
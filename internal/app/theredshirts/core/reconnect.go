@@ -0,0 +1,169 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/google/uuid"
+)
+
+const (
+	reconnect_token_bytes = 32
+)
+
+// ReconnectPlayer lets a dropped player rejoin their lobby with the token of their last join. They get back their payload,
+// spectator flag and ownership. A seat held for them is taken even if the lobby is full or playing. Returns a new token
+func (core CoreFacade) ReconnectPlayer(context *util.Context, playerId uuid.UUID, token string) (string, error) {
+	context.Logger.Debugf("Reconnecting player [%v]", playerId)
+	tx, err := core.startTransaction()
+	if err != nil {
+		return "", err
+	}
+	defer core.rollback(tx)
+
+	newToken, err := core.reconnectPlayer(context, tx, playerId, token)
+	if err != nil {
+		if errors.Is(err, ErrReconnectTokenInvalid) {
+			// a stale token is deleted, although the player can't reconnect with it
+			if err := core.commit(tx, context); err != nil {
+				return "", err
+			}
+		}
+		return "", err
+	}
+	return newToken, core.commit(tx, context)
+}
+
+func (core CoreFacade) reconnectPlayer(context *util.Context, tx *transaction, playerId uuid.UUID, token string) (string, error) {
+	reconnectToken, err := tx.dbTx.GetReconnectToken(playerId)
+	if err != nil {
+		return "", fmt.Errorf("something went wrong while loading reconnect token of player [%v] from database: %v", playerId, err)
+	}
+
+	if reconnectToken == nil || subtle.ConstantTimeCompare([]byte(reconnectToken.TokenHash), []byte(hashReconnectToken(token))) != 1 {
+		return "", fmt.Errorf("reconnect token of player [%v] doesn't match: %w", playerId, ErrReconnectTokenInvalid)
+	}
+
+	if reconnectToken.DroppedAt == nil {
+		context.Logger.Debugf("Player [%v] was not dropped yet", playerId)
+		player, err := tx.dbTx.GetPlayerById(playerId)
+		if err != nil {
+			return "", fmt.Errorf("something went wrong while loading player [%v] from database: %v", playerId, err)
+		}
+		if player == nil || player.LobbyId != reconnectToken.LobbyId {
+			if err := tx.dbTx.DeleteReconnectToken(playerId); err != nil {
+				return "", fmt.Errorf("something went wrong while deleting reconnect token of player [%v]: %v", playerId, err)
+			}
+			return "", fmt.Errorf("player [%v] is not part of lobby [%v] anymore: %w", playerId, reconnectToken.LobbyId, ErrReconnectTokenInvalid)
+		}
+		core.presence.refresh(playerId, time.Now())
+		return core.issueReconnectToken(tx, playerId, reconnectToken.LobbyId)
+	}
+
+	now := time.Now()
+	if reconnectToken.ExpiresAt != nil && !now.Before(*reconnectToken.ExpiresAt) {
+		return "", fmt.Errorf("reconnect token of player [%v] expired at %v: %w", playerId, reconnectToken.ExpiresAt, ErrReconnectTokenExpired)
+	}
+
+	lobby, err := tx.dbTx.GetLobbyByIdForUpdate(reconnectToken.LobbyId)
+	if err != nil {
+		return "", fmt.Errorf("something went wrong while loading lobby %v from database: %v", reconnectToken.LobbyId, err)
+	}
+
+	if lobby == nil {
+		return "", ErrLobbyNotFound
+	}
+
+	if err := core.checkBan(tx, lobby.ID, playerId); err != nil {
+		return "", err
+	}
+
+	if reconnectToken.SeatHeldUntil == nil || !now.Before(*reconnectToken.SeatHeldUntil) {
+		if lobby.Status == lobby_playing && !reconnectToken.Spectator {
+			return "", ErrLobbyPlaying
+		}
+		if err := core.checkCapacity(tx, lobby); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.dbTx.CreatePlayer(&db.Player{ID: playerId, Name: reconnectToken.Name, LobbyId: lobby.ID, LastRefresh: now, JoinedAt: now, Spectator: reconnectToken.Spectator, Payload: reconnectToken.Payload}); err != nil {
+		return "", fmt.Errorf("something went wrong while creating player %v from database: %v", playerId, err)
+	}
+	if err := core.touchLobby(tx, lobby.ID); err != nil {
+		return "", err
+	}
+
+	tx.messages = append(tx.messages, &message{senderPlayerId: playerId, lobbyId: lobby.ID, topic: PLAYER_RECONNECTED, payload: map[string]interface{}{"player_id": playerId, "player_name": reconnectToken.Name, "spectator": reconnectToken.Spectator}})
+
+	if reconnectToken.Owner && lobby.Owner != playerId {
+		if err := core.changeOwner(tx, lobby.ID, playerId, uuid.Nil); err != nil {
+			return "", err
+		}
+	}
+
+	return core.issueReconnectToken(tx, playerId, lobby.ID)
+}
+
+// issueReconnectToken replaces the token of the player with a new one
+func (core CoreFacade) issueReconnectToken(tx *transaction, playerId uuid.UUID, lobbyId uuid.UUID) (string, error) {
+	data := make([]byte, reconnect_token_bytes)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("error while generating reconnect token: %v", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(data)
+
+	if err := tx.dbTx.UpsertReconnectToken(&db.ReconnectToken{PlayerId: playerId, LobbyId: lobbyId, TokenHash: hashReconnectToken(token)}); err != nil {
+		return "", fmt.Errorf("something went wrong while storing reconnect token of player [%v]: %v", playerId, err)
+	}
+	return token, nil
+}
+
+// dropPlayer removes an afk player, but remembers them for the reconnect window. If they were playing, their seat is
+// held for the rest of its reservation. Once the reservation is over, the seat is free again
+func (core CoreFacade) dropPlayer(context *util.Context, tx *transaction, player *db.Player, lobby *db.Lobby, seatReservedUntil time.Time) error {
+	reconnectToken, err := tx.dbTx.GetReconnectToken(player.ID)
+	if err != nil {
+		return fmt.Errorf("something went wrong while loading reconnect token of player [%v] from database: %v", player.ID, err)
+	}
+
+	if reconnectToken != nil && lobby != nil && reconnectToken.LobbyId == lobby.ID {
+		now := time.Now()
+		expiresAt := now.Add(core.reconnectWindow)
+		reconnectToken.Name = player.Name
+		reconnectToken.Spectator = player.Spectator
+		reconnectToken.Payload = player.Payload
+		reconnectToken.Owner = lobby.Owner == player.ID
+		reconnectToken.SeatHeldUntil = nil
+		if lobby.Status == lobby_playing && !player.Spectator && now.Before(seatReservedUntil) {
+			reconnectToken.SeatHeldUntil = &seatReservedUntil
+		}
+		reconnectToken.DroppedAt = &now
+		reconnectToken.ExpiresAt = &expiresAt
+		if err := tx.dbTx.UpsertReconnectToken(reconnectToken); err != nil {
+			return fmt.Errorf("something went wrong while storing reconnect token of player [%v]: %v", player.ID, err)
+		}
+	}
+
+	return core.deletePlayer(context, tx, player.ID)
+}
+
+func (core CoreFacade) cleanUpReconnectTokens(tx *transaction) error {
+	if err := tx.dbTx.DeleteReconnectTokensBefore(time.Now()); err != nil {
+		return fmt.Errorf("error while cleaning up reconnect tokens: %v", err)
+	}
+	return nil
+}
+
+func hashReconnectToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
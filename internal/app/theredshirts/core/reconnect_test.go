@@ -0,0 +1,172 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func joinWithToken(t *testing.T, core *CoreFacade, player *Player) string {
+	token, err := core.CreatePlayer(newTestContext(), player, test_password)
+	if err != nil {
+		t.Fatalf("error while joining lobby: %v", err)
+	}
+	assert.NotEmpty(t, token)
+	return token
+}
+
+func dropTestPlayer(t *testing.T, core *CoreFacade, player *Player) {
+	setLastRefresh(t, core, player, time.Now().Add(-time.Minute))
+	runCleanUp(t, core)
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+}
+
+func TestReconnectPlayer_AfterDrop(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID, Spectator: true, Payload: map[string]interface{}{"color": "red"}}
+	token := joinWithToken(t, core, player)
+	dropTestPlayer(t, core, player)
+
+	newToken, err := core.ReconnectPlayer(newTestContext(), player.ID, token)
+
+	assert.Nil(t, err)
+	assert.NotEqual(t, token, newToken)
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.ID, foundPlayer.LobbyId)
+	assert.True(t, foundPlayer.Spectator)
+	assert.Equal(t, player.Payload, foundPlayer.Payload)
+	assert.Equal(t, PLAYER_RECONNECTED, lastTopic(t, core))
+
+	_, err = core.ReconnectPlayer(newTestContext(), player.ID, token)
+	assert.ErrorIs(t, err, ErrReconnectTokenInvalid)
+}
+
+func TestReconnectPlayer_RestoresOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	token := joinWithToken(t, core, &Player{ID: lobby.Owner.ID, Name: lobby.Owner.Name, LobbyId: lobby.ID})
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now())
+	dropTestPlayer(t, core, lobby.Owner)
+
+	_, err := core.ReconnectPlayer(newTestContext(), lobby.Owner.ID, token)
+
+	assert.Nil(t, err)
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.Owner.ID, foundLobby.Owner.ID)
+	assert.Equal(t, OWNER_CHANGED, lastTopic(t, core))
+}
+
+// dropReservedPlayer drops the player while their seat is still reserved
+func dropReservedPlayer(t *testing.T, core *CoreFacade, player *Player, reservedUntil time.Time) {
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	defer core.rollback(tx)
+	dbPlayer, err := tx.dbTx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	lobby, err := tx.dbTx.GetLobbyById(player.LobbyId)
+	assert.Nil(t, err)
+	assert.Nil(t, core.dropPlayer(newTestContext(), tx, dbPlayer, lobby, reservedUntil))
+	assert.Nil(t, core.commit(tx, newTestContext()))
+}
+
+func TestReconnectPlayer_HeldSeat(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 2)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	token := joinWithToken(t, core, player)
+	startTestGame(t, core, lobby, player)
+	dropReservedPlayer(t, core, player, time.Now().Add(time.Minute))
+
+	_, err := core.CreatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Spectator", LobbyId: lobby.ID, Spectator: true}, test_password)
+	assert.ErrorIs(t, err, ErrLobbyFull)
+	lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{FreeSlots: 1})
+	assert.Nil(t, err)
+	assert.Empty(t, lobbies)
+
+	_, err = core.ReconnectPlayer(newTestContext(), player.ID, token)
+	assert.Nil(t, err)
+}
+
+func TestReconnectPlayer_SeatNotHeldAfterReservation(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 2)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	token := joinWithToken(t, core, player)
+	startTestGame(t, core, lobby, player)
+	setLastRefresh(t, core, lobby.Owner, time.Now())
+	dropTestPlayer(t, core, player)
+
+	lobbies, _, err := core.GetLobbies(newTestContext(), &LobbyFilter{FreeSlots: 1})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+
+	_, err = core.ReconnectPlayer(newTestContext(), player.ID, token)
+	assert.ErrorIs(t, err, ErrLobbyPlaying)
+}
+
+func TestReconnectPlayer_Expired(t *testing.T) {
+	core := newTestCore(t)
+	core.reconnectWindow = 0
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	token := joinWithToken(t, core, player)
+	dropTestPlayer(t, core, player)
+
+	_, err := core.ReconnectPlayer(newTestContext(), player.ID, token)
+
+	assert.ErrorIs(t, err, ErrReconnectTokenExpired)
+}
+
+func TestReconnectPlayer_AfterLeaving(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	token := joinWithToken(t, core, player)
+	assert.Nil(t, core.DeletePlayer(newTestContext(), player.ID))
+
+	_, err := core.ReconnectPlayer(newTestContext(), player.ID, token)
+
+	assert.ErrorIs(t, err, ErrReconnectTokenInvalid)
+}
+
+func TestReconnectPlayer_WrongToken(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	joinWithToken(t, core, player)
+	dropTestPlayer(t, core, player)
+
+	_, err := core.ReconnectPlayer(newTestContext(), player.ID, "wrong")
+
+	assert.ErrorIs(t, err, ErrReconnectTokenInvalid)
+}
+
+func TestReconnectPlayer_RemovedWithoutDrop(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}
+	token := joinWithToken(t, core, player)
+
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	assert.Nil(t, tx.dbTx.DeletePlayer(player.ID))
+	assert.Nil(t, core.commit(tx, newTestContext()))
+
+	_, err = core.ReconnectPlayer(newTestContext(), player.ID, token)
+	assert.ErrorIs(t, err, ErrReconnectTokenInvalid)
+
+	tx, err = core.startTransaction()
+	assert.Nil(t, err)
+	defer core.rollback(tx)
+	reconnectToken, err := tx.dbTx.GetReconnectToken(player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, reconnectToken)
+}
@@ -4,58 +4,130 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
 	"github.com/go-co-op/gocron"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
-func (core CoreFacade) startCleanUp() {
+func (core CoreFacade) startCleanUp() *gocron.Scheduler {
 	log.Info("Start auto cleanup of lobbies")
 	s := gocron.NewScheduler(time.UTC)
 
-	s.Every(10).Seconds().Do(func() {
-		correlationId := uuid.NewString()
-		logger := log.WithFields(log.Fields{
-			"Scavenger": correlationId,
-		})
-		context := &util.Context{CorrelationId: correlationId, Logger: logger}
-
-		tx, err := core.startTransaction()
-		if err != nil {
-			logger.Warnf("something went wrong while creating transaction: %v", err)
-			return
-		}
-		defer core.rollback(tx)
+	s.Every(core.scavengerInterval).Do(core.scavenge)
 
-		if err := core.cleanUpAfkPlayers(context, tx); err != nil {
-			log.Warn("Error while scheduling: %v", err)
-			return
-		}
-		if err := core.commit(tx, context); err != nil {
-			log.Warn("Error while committing changes: %v", err)
-		}
+	s.StartAsync()
+	return s
+}
+
+// scavenge cleans up afk players, old events, expired idempotency keys and reconnect tokens. Only the leader of all instances scavenges
+func (core CoreFacade) scavenge() {
+	if !core.isLeader() {
+		return
+	}
+
+	correlationId := uuid.NewString()
+	logger := log.WithFields(log.Fields{
+		"Scavenger": correlationId,
 	})
+	context := &util.Context{CorrelationId: correlationId, Logger: logger}
 
-	s.StartAsync()
+	tx, err := core.startTransaction()
+	if err != nil {
+		logger.Warnf("something went wrong while creating transaction: %v", err)
+		return
+	}
+	defer core.rollback(tx)
+
+	fenced, err := tx.dbTx.TryTransactionLock(scavenger_fence_lock)
+	if err != nil {
+		logger.Warnf("Error while fencing scavenger: %v", err)
+		return
+	}
+	if !fenced || !core.holdsLeaderLock() {
+		logger.Info("Skip scavenging, the scavenger leader changed")
+		return
+	}
+
+	if err := core.cleanUpAfkPlayers(context, tx); err != nil {
+		log.Warnf("Error while scheduling: %v", err)
+		return
+	}
+	if err := core.cleanUpLobbyEvents(tx); err != nil {
+		log.Warnf("Error while scheduling: %v", err)
+		return
+	}
+	if err := core.cleanUpIdempotencyKeys(tx); err != nil {
+		log.Warnf("Error while scheduling: %v", err)
+		return
+	}
+	if err := core.cleanUpReconnectTokens(tx); err != nil {
+		log.Warnf("Error while scheduling: %v", err)
+		return
+	}
+	if err := core.commit(tx, context); err != nil {
+		log.Warnf("Error while committing changes: %v", err)
+	}
 }
 
+// cleanUpAfkPlayers warns and removes players, which didn't refresh for the time of the afk policy of their lobby.
+// A player of a playing lobby with reserved seats keeps their seat for the grace period before they are removed
 func (core CoreFacade) cleanUpAfkPlayers(context *util.Context, tx *transaction) error {
-	warningTime := time.Now().Add(time.Second * -5)
-	deleteTime := warningTime.Add(time.Second * -10)
-	players, err := tx.dbTx.GetPlayersLastRefresh(warningTime)
+	now := time.Now()
+	players, err := tx.dbTx.GetPlayersLastRefresh(now.Add(-core.minAfkWarning()))
 	if err != nil {
 		return fmt.Errorf("error while cleaning up afk players: %v", err)
 	}
+	lobbies := make(map[uuid.UUID]*db.Lobby)
 	for _, player := range players {
-		if player.LastRefresh.Before(deleteTime) {
-			err := core.deletePlayer(context, tx, player.ID)
+		if core.presence.isConnected(player.ID) {
+			continue
+		}
+		lobby, ok := lobbies[player.LobbyId]
+		if !ok {
+			lobby, err = tx.dbTx.GetLobbyById(player.LobbyId)
 			if err != nil {
+				return fmt.Errorf("error while loading lobby [%v] of afk player [%v]: %v", player.LobbyId, player.ID, err)
+			}
+			lobbies[player.LobbyId] = lobby
+		}
+
+		policy := core.afkPolicy(lobby)
+		warningTime := player.LastRefresh.Add(time.Duration(policy.WarningSeconds) * time.Second)
+		removalTime := player.LastRefresh.Add(time.Duration(policy.RemovalSeconds) * time.Second)
+		reservedUntil := removalTime.Add(time.Duration(policy.ReservedSeatSeconds) * time.Second)
+		switch {
+		case now.Before(warningTime):
+			continue
+		case now.Before(removalTime):
+			if err := core.noticeAfkPlayer(tx, player, PLAYER_LAGGING, map[string]interface{}{"player_id": player.ID}); err != nil {
+				return err
+			}
+		case now.Before(reservedUntil):
+			if err := core.noticeAfkPlayer(tx, player, PLAYER_SEAT_RESERVED, map[string]interface{}{"player_id": player.ID, "reserved_until": reservedUntil}); err != nil {
+				return err
+			}
+		default:
+			if err := core.dropPlayer(context, tx, player, lobby, reservedUntil); err != nil {
 				return err
 			}
-		} else {
-			tx.messages = append(tx.messages, &message{senderPlayerId: uuid.Nil, lobbyId: player.LobbyId, topic: PLAYER_LAGGING, payload: map[string]interface{}{"player_id": player.ID}})
 		}
 	}
 	return nil
 }
+
+// noticeAfkPlayer sends the afk message once when the player reaches the stage. The notice is cleared when the player refreshes
+func (core CoreFacade) noticeAfkPlayer(tx *transaction, player *db.Player, topic string, payload map[string]interface{}) error {
+	if player.AfkNotice == topic {
+		return nil
+	}
+	noticed, err := tx.dbTx.UpdatePlayerAfkNotice(player.ID, player.LastRefresh, topic)
+	if err != nil {
+		return fmt.Errorf("error while updating afk notice of player [%v]: %v", player.ID, err)
+	}
+	if noticed {
+		tx.messages = append(tx.messages, &message{senderPlayerId: uuid.Nil, lobbyId: player.LobbyId, topic: topic, payload: payload})
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setLastRefresh(t *testing.T, core *CoreFacade, player *Player, lastRefresh time.Time) {
+	tx, err := core.db.StartTransaction()
+	assert.Nil(t, err)
+	assert.Nil(t, tx.UpdatePlayerLastRefresh(player.ID, lastRefresh))
+	assert.Nil(t, tx.Commit())
+}
+
+func runCleanUp(t *testing.T, core *CoreFacade) {
+	context := newTestContext()
+	tx, err := core.startTransaction()
+	assert.Nil(t, err)
+	defer core.rollback(tx)
+	assert.Nil(t, core.cleanUpAfkPlayers(context, tx))
+	assert.Nil(t, core.commit(tx, context))
+}
+
+func TestCleanUpAfkPlayers_Lagging(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-10*time.Second))
+
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, foundPlayer)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LAGGING}, storedTopics(t, core))
+}
+
+func TestCleanUpAfkPlayers_LaggingOncePerRefresh(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-10*time.Second))
+
+	runCleanUp(t, core)
+	runCleanUp(t, core)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LAGGING}, storedTopics(t, core))
+
+	setLastRefresh(t, core, player, time.Now().Add(-10*time.Second))
+	runCleanUp(t, core)
+	assert.Equal(t, []string{PLAYER_JOINS_LOBBY, PLAYER_JOINS_LOBBY, PLAYER_LAGGING, PLAYER_LAGGING}, storedTopics(t, core))
+}
+
+func TestNewCoreFacade_InvalidScavengerInterval(t *testing.T) {
+	t.Setenv("DATABASE", "inmemory")
+	t.Setenv("MESSAGE_SINK", "inmemory")
+	t.Setenv("LOBBY_USER", uuid.NewString())
+	t.Setenv("SCAVENGER_INTERVAL_SECONDS", "0")
+
+	_, err := newCoreFacade()
+
+	assert.ErrorContains(t, err, "scavenger interval")
+}
+
+func TestCleanUpAfkPlayers_Removed(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	setLastRefresh(t, core, player, time.Now().Add(-time.Minute))
+
+	runCleanUp(t, core)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, foundPlayer)
+}
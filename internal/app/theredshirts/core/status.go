@@ -0,0 +1,84 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/google/uuid"
+)
+
+// lobbyTransitions lists for every status the statuses a lobby can move on to
+var lobbyTransitions = map[string][]string{
+	lobby_open:     {lobby_starting, lobby_aborted},
+	lobby_starting: {lobby_open, lobby_playing, lobby_aborted},
+	lobby_playing:  {lobby_finished, lobby_aborted},
+	lobby_finished: {lobby_open},
+	lobby_aborted:  {lobby_open},
+}
+
+// lobbyStatusTopics is the topic of the message sent when a lobby enters the status
+var lobbyStatusTopics = map[string]string{
+	lobby_open:     LOBBY_OPENED,
+	lobby_starting: LOBBY_STARTING,
+	lobby_playing:  LOBBY_PLAYING,
+	lobby_finished: LOBBY_FINISHED,
+	lobby_aborted:  LOBBY_ABORTED,
+}
+
+func isTransitionAllowed(from string, to string) bool {
+	for _, status := range lobbyTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransition validates the transition and the preconditions of the new status
+func (core CoreFacade) checkTransition(tx *transaction, lobby *db.Lobby, status string) error {
+	if !isTransitionAllowed(lobby.Status, status) {
+		return fmt.Errorf("lobby [%v] can't change from status %s to %s: %w", lobby.ID, lobby.Status, status, ErrInvalidStatusTransition)
+	}
+
+	if status == lobby_starting || status == lobby_playing {
+		playerCount, err := tx.dbTx.GetNumberOfPlayersInLobby(lobby.ID)
+		if err != nil {
+			return fmt.Errorf("something went wrong while loading number of players from lobby %v from database: %v", lobby.ID, err)
+		}
+		if playerCount < core.minPlayers {
+			return fmt.Errorf("lobby [%v] has %d of %d needed players: %w", lobby.ID, playerCount, core.minPlayers, ErrNotEnoughPlayers)
+		}
+	}
+
+	if status == lobby_playing {
+		players, err := tx.dbTx.GetAllPlayersInLobby(lobby.ID)
+		if err != nil {
+			return fmt.Errorf("something went wrong while loading players of lobby [%v] from database: %v", lobby.ID, err)
+		}
+		for _, player := range players {
+			if !player.Spectator && !player.Ready {
+				return fmt.Errorf("player [%v] in lobby [%v] is not ready: %w", player.ID, lobby.ID, ErrPlayersNotReady)
+			}
+		}
+	}
+	return nil
+}
+
+func (core CoreFacade) changeLobbyStatus(tx *transaction, lobby *db.Lobby, status string, senderPlayerId uuid.UUID) error {
+	if err := core.checkTransition(tx, lobby, status); err != nil {
+		return err
+	}
+
+	oldStatus := lobby.Status
+	lobby.Status = status
+	if err := tx.dbTx.UpdateLobby(lobby); err != nil {
+		return fmt.Errorf("something went wrong while updating state of lobby [%v]: %w", lobby.ID, wrapVersionConflict(err))
+	}
+
+	tx.messages = append(tx.messages, &message{senderPlayerId: senderPlayerId, lobbyId: lobby.ID, topic: lobbyStatusTopics[status], payload: map[string]interface{}{"old_status": oldStatus, "new_status": status}})
+
+	if status == lobby_open {
+		return core.resetPlayersReady(tx, lobby.ID, senderPlayerId)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func updateTestLobbyStatus(core *CoreFacade, lobby *Lobby, status string) error {
+	return core.UpdateLobbyStatus(newTestContext(), &Lobby{ID: lobby.ID, Status: status}, lobby.Owner.ID)
+}
+
+func TestUpdateLobbyStatus_Lifecycle(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+
+	for _, status := range []string{lobby_starting, lobby_playing, lobby_finished, lobby_open} {
+		assert.Nil(t, updateTestLobbyStatus(core, lobby, status))
+	}
+
+	assert.Equal(t, []string{LOBBY_STARTING, LOBBY_PLAYING, LOBBY_FINISHED, LOBBY_OPENED, PLAYER_READY_CHANGED, PLAYER_READY_CHANGED}, storedTopics(t, core)[4:])
+	messages := storedMessages(t, core)
+	assert.Equal(t, map[string]interface{}{"old_status": lobby_open, "new_status": lobby_starting}, messages[4].Payload)
+}
+
+func TestUpdateLobbyStatus_InvalidTransition(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, false)
+
+	assert.ErrorIs(t, updateTestLobbyStatus(core, lobby, lobby_playing), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, updateTestLobbyStatus(core, lobby, lobby_finished), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, updateTestLobbyStatus(core, lobby, "UNKNOWN"), ErrInvalidStatusTransition)
+}
+
+func TestUpdateLobbyStatus_NotEnoughPlayers(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	joinTestPlayer(t, core, lobby.ID, true)
+
+	assert.ErrorIs(t, updateTestLobbyStatus(core, lobby, lobby_starting), ErrNotEnoughPlayers)
+}
+
+func TestUpdateLobbyStatus_NotOwner(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	err := core.UpdateLobbyStatus(newTestContext(), &Lobby{ID: lobby.ID, Status: lobby_starting}, player.ID)
+	assert.ErrorIs(t, err, ErrNotOwner)
+}
+
+func TestCreatePlayer_LobbyPlaying(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), lobby.Owner.ID, true))
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_starting))
+	assert.Nil(t, updateTestLobbyStatus(core, lobby, lobby_playing))
+
+	_, err := core.CreatePlayer(newTestContext(), &Player{ID: uuid.New(), Name: "Player", LobbyId: lobby.ID}, test_password)
+	assert.ErrorIs(t, err, ErrLobbyPlaying)
+
+	spectator := joinTestPlayer(t, core, lobby.ID, true)
+	spectator.Spectator = false
+	assert.ErrorIs(t, core.UpdatePlayer(newTestContext(), spectator, spectator.ID), ErrLobbyPlaying)
+}
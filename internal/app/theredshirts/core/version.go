@@ -0,0 +1,25 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/db"
+	"github.com/google/uuid"
+)
+
+// checkVersion compares the version the client has seen with the stored version. Version 0 means the client didn't send one
+func checkVersion(id uuid.UUID, expected int, actual int) error {
+	if expected != 0 && expected != actual {
+		return fmt.Errorf("version %d of [%v] was expected, but it is %d: %w", expected, id, actual, ErrVersionMismatch)
+	}
+	return nil
+}
+
+// wrapVersionConflict marks errors of rows, which were changed by another transaction, as version mismatch
+func wrapVersionConflict(err error) error {
+	if errors.Is(err, db.ErrVersionConflict) {
+		return fmt.Errorf("%v: %w", err, ErrVersionMismatch)
+	}
+	return err
+}
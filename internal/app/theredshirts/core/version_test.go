@@ -0,0 +1,76 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateLobby_IncreasesVersion(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, foundLobby.Version)
+
+	lobby.Version = foundLobby.Version
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	foundLobby, err = core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, foundLobby.Version)
+}
+
+func TestJoinLobby_IncreasesVersion(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+
+	player := joinTestPlayer(t, core, lobby.ID, false)
+	joinedLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, foundLobby.Version+1, joinedLobby.Version)
+
+	assert.Nil(t, core.UpdatePlayerReady(newTestContext(), player.ID, true))
+	readyLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, joinedLobby.Version+1, readyLobby.Version)
+}
+
+func TestUpdateLobby_StaleVersion(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	assert.Nil(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID))
+
+	lobby.Name = "Stale Lobby"
+	lobby.Version = 1
+	assert.ErrorIs(t, core.UpdateLobby(newTestContext(), lobby, lobby.Owner.ID), ErrVersionMismatch)
+
+	err := core.UpdateLobbyStatus(newTestContext(), &Lobby{ID: lobby.ID, Status: lobby_aborted, Version: 1}, lobby.Owner.ID)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+
+	foundLobby, err := core.GetLobby(newTestContext(), lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Some Lobby", foundLobby.Name)
+	assert.Equal(t, lobby_open, foundLobby.Status)
+}
+
+func TestUpdatePlayer_StaleVersion(t *testing.T) {
+	core := newTestCore(t)
+	lobby := createTestLobby(t, core, 4)
+	player := joinTestPlayer(t, core, lobby.ID, false)
+
+	player.Name = "Renamed"
+	player.Version = 1
+	assert.Nil(t, core.UpdatePlayer(newTestContext(), player, player.ID))
+
+	player.Name = "Stale"
+	assert.ErrorIs(t, core.UpdatePlayer(newTestContext(), player, player.ID), ErrVersionMismatch)
+
+	foundPlayer, err := core.GetPlayer(newTestContext(), player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", foundPlayer.Name)
+	assert.Equal(t, 2, foundPlayer.Version)
+}
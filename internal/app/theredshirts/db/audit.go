@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	lobby_audit_table_name = "lobby_audit"
+	// next_lobby_audit_sequence_sql counts up the audit sequence of a lobby in the same row as the event sequence,
+	// so the audit entries of a lobby are committed in order as well
+	next_lobby_audit_sequence_sql = "WITH next AS (INSERT INTO %[1]s.%[2]s AS s(lobby_id, last_sequence, last_audit_sequence) VALUES($1, 0, 1) ON CONFLICT (lobby_id) DO UPDATE SET last_audit_sequence = s.last_audit_sequence + 1 RETURNING last_audit_sequence) "
+	create_lobby_audit_sql        = "INSERT INTO %[1]s.%[3]s(lobby_id, sequence, topic, actor_id, correlation_id, payload, created_at) VALUES($1, (SELECT last_audit_sequence FROM next), $2, $3, $4, $5, $6) RETURNING id, sequence"
+	select_lobby_audit_after_sql  = "SELECT id, lobby_id, sequence, topic, actor_id, correlation_id, payload, created_at FROM %s.%s WHERE lobby_id = $1 AND sequence > $2 ORDER BY sequence LIMIT $3"
+)
+
+func (tx *postgresTransaction) CreateLobbyAuditEntry(entry *LobbyAuditEntry) error {
+	if err := tx.tx.QueryRow(context.Background(), fmt.Sprintf(next_lobby_audit_sequence_sql+create_lobby_audit_sql, schema_name, lobby_sequence_table_name, lobby_audit_table_name), entry.LobbyId, entry.Topic, entry.ActorId, entry.CorrelationId, entry.Payload, entry.CreatedAt).Scan(&entry.ID, &entry.Sequence); err != nil {
+		return fmt.Errorf("unknown error when inserting lobby audit entry: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetLobbyAuditEntriesAfter(lobbyId uuid.UUID, afterSequence int64, limit int) ([]*LobbyAuditEntry, error) {
+	var entries []*LobbyAuditEntry
+	if err := pgxscan.Select(context.Background(), tx.tx, &entries, fmt.Sprintf(select_lobby_audit_after_sql, schema_name, lobby_audit_table_name), lobbyId, afterSequence, limit); err != nil {
+		return nil, fmt.Errorf("error while selecting lobby audit entries: %v", err)
+	}
+	return entries, nil
+}
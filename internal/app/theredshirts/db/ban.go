@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	lobby_ban_table_name     = "lobby_ban"
+	create_lobby_ban_sql     = "INSERT INTO %s.%s(lobby_id, player_id, reason, created_at) VALUES($1, $2, $3, $4) ON CONFLICT (lobby_id, player_id) DO UPDATE SET reason = $3, created_at = $4"
+	select_lobby_ban_sql     = "SELECT lobby_id, player_id, reason, created_at FROM %s.%s WHERE lobby_id = $1 AND player_id = $2"
+	select_lobby_ban_all_sql = "SELECT lobby_id, player_id, reason, created_at FROM %s.%s WHERE lobby_id = $1 ORDER BY created_at"
+)
+
+func (tx *postgresTransaction) CreateLobbyBan(ban *LobbyBan) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_lobby_ban_sql, schema_name, lobby_ban_table_name), ban.LobbyId, ban.PlayerId, ban.Reason, ban.CreatedAt); err != nil {
+		return fmt.Errorf("unknown error when inserting lobby ban: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetLobbyBan(lobbyId uuid.UUID, playerId uuid.UUID) (*LobbyBan, error) {
+	var bans []*LobbyBan
+	if err := pgxscan.Select(context.Background(), tx.tx, &bans, fmt.Sprintf(select_lobby_ban_sql, schema_name, lobby_ban_table_name), lobbyId, playerId); err != nil {
+		return nil, fmt.Errorf("error while selecting ban of player [%v] in lobby [%v]: %v", playerId, lobbyId, err)
+	}
+
+	if len(bans) == 0 {
+		return nil, nil
+	}
+	return bans[0], nil
+}
+
+func (tx *postgresTransaction) GetLobbyBans(lobbyId uuid.UUID) ([]*LobbyBan, error) {
+	var bans []*LobbyBan
+	if err := pgxscan.Select(context.Background(), tx.tx, &bans, fmt.Sprintf(select_lobby_ban_all_sql, schema_name, lobby_ban_table_name), lobbyId); err != nil {
+		return nil, fmt.Errorf("error while selecting bans of lobby [%v]: %v", lobbyId, err)
+	}
+	return bans, nil
+}
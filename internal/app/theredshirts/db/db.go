@@ -2,6 +2,7 @@ package db
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -22,6 +23,40 @@ type (
 		MaxPlayers          int                    `db:"max_players"`
 		ExpansionPacks      []string               `db:"expansion_packs"`
 		Payload             map[string]interface{} `db:"payload"`
+		Visibility          string                 `db:"visibility"`
+		AfkPolicies         map[string]*AfkPolicy  `db:"afk_policies"`
+		CreatedAt           time.Time              `db:"created_at"`
+		Version             int                    `db:"version"`
+	}
+
+	// AfkPolicy overrides for one lobby status when an afk player is warned and removed. Zero values keep the default
+	AfkPolicy struct {
+		WarningSeconds      int `json:"warning_seconds"`
+		RemovalSeconds      int `json:"removal_seconds"`
+		ReservedSeatSeconds int `json:"reserved_seat_seconds"`
+	}
+
+	// LobbyQuery filters, sorts and pages lobbies. Zero values don't filter
+	LobbyQuery struct {
+		Visibility        string
+		Status            string
+		Difficulty        int
+		MissionLength     int
+		ExpansionPacks    []string
+		FreeSlots         int
+		PasswordProtected *bool
+		Sort              string
+		Descending        bool
+		Limit             int
+		Cursor            *LobbyCursor
+		// Now decides which held seats are still taken from the free slots
+		Now time.Time
+	}
+
+	// LobbyCursor points to the last lobby of a page by its sort value and id
+	LobbyCursor struct {
+		Value string    `json:"value"`
+		ID    uuid.UUID `json:"id"`
 	}
 
 	Player struct {
@@ -29,47 +64,240 @@ type (
 		Name        string                 `db:"name"`
 		LobbyId     uuid.UUID              `db:"lobby_id"`
 		LastRefresh time.Time              `db:"last_refresh"`
+		JoinedAt    time.Time              `db:"joined_at"`
 		Spectator   bool                   `db:"spectator"`
+		Ready       bool                   `db:"ready"`
 		Payload     map[string]interface{} `db:"payload"`
+		AfkNotice   string                 `db:"afk_notice"`
+		Version     int                    `db:"version"`
+	}
+
+	OutboxMessage struct {
+		ID             int64                  `db:"id"`
+		LobbyId        uuid.UUID              `db:"lobby_id"`
+		SenderPlayerId uuid.UUID              `db:"sender_player_id"`
+		CorrelationId  string                 `db:"correlation_id"`
+		Topic          string                 `db:"topic"`
+		Payload        map[string]interface{} `db:"payload"`
+		CreatedAt      time.Time              `db:"created_at"`
+		Attempts       int                    `db:"attempts"`
+		NextAttempt    time.Time              `db:"next_attempt"`
+		LastError      string                 `db:"last_error"`
+		// DeadLetter messages gave up on delivery and don't hold back the later messages of their lobby anymore
+		DeadLetter bool `db:"dead_letter"`
+	}
+
+	// LobbyEvent is numbered by its sequence within the lobby, which is assigned in the order the events are committed
+	LobbyEvent struct {
+		ID        int64                  `db:"id"`
+		LobbyId   uuid.UUID              `db:"lobby_id"`
+		Sequence  int64                  `db:"sequence"`
+		Topic     string                 `db:"topic"`
+		Payload   map[string]interface{} `db:"payload"`
+		Snapshot  *LobbySnapshot         `db:"snapshot"`
+		CreatedAt time.Time              `db:"created_at"`
+	}
+
+	// LobbyAuditEntry records what happened in a lobby. Entries are never changed or deleted, also not together with their lobby
+	LobbyAuditEntry struct {
+		ID            int64                  `db:"id"`
+		LobbyId       uuid.UUID              `db:"lobby_id"`
+		Sequence      int64                  `db:"sequence"`
+		Topic         string                 `db:"topic"`
+		ActorId       uuid.UUID              `db:"actor_id"`
+		CorrelationId string                 `db:"correlation_id"`
+		Payload       map[string]interface{} `db:"payload"`
+		CreatedAt     time.Time              `db:"created_at"`
+	}
+
+	LobbyBan struct {
+		LobbyId   uuid.UUID `db:"lobby_id"`
+		PlayerId  uuid.UUID `db:"player_id"`
+		Reason    string    `db:"reason"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+
+	JoinAttempt struct {
+		LobbyId        uuid.UUID `db:"lobby_id"`
+		PlayerId       uuid.UUID `db:"player_id"`
+		FailedAttempts int       `db:"failed_attempts"`
+		LastFailed     time.Time `db:"last_failed"`
+	}
+
+	Invite struct {
+		Code      string     `db:"code"`
+		LobbyId   uuid.UUID  `db:"lobby_id"`
+		CreatedAt time.Time  `db:"created_at"`
+		ExpiresAt *time.Time `db:"expires_at"`
+		MaxUses   int        `db:"max_uses"`
+		Uses      int        `db:"uses"`
+	}
+
+	// IdempotencyKey stores the response of a request, to replay it when the request is retried with the same key.
+	// A status code of 0 marks a request which is still in progress
+	IdempotencyKey struct {
+		PlayerId    uuid.UUID `db:"player_id"`
+		Key         string    `db:"key"`
+		RequestHash string    `db:"request_hash"`
+		StatusCode  int       `db:"status_code"`
+		ContentType string    `db:"content_type"`
+		Body        []byte    `db:"body"`
+		CreatedAt   time.Time `db:"created_at"`
+		ExpiresAt   time.Time `db:"expires_at"`
+	}
+
+	// ReconnectToken lets a player rejoin their lobby after they were dropped. The token itself is only stored as hash.
+	// The player is remembered when they are dropped, until then DroppedAt and ExpiresAt are empty
+	ReconnectToken struct {
+		PlayerId  uuid.UUID              `db:"player_id"`
+		LobbyId   uuid.UUID              `db:"lobby_id"`
+		TokenHash string                 `db:"token_hash"`
+		Name      string                 `db:"name"`
+		Spectator bool                   `db:"spectator"`
+		Payload   map[string]interface{} `db:"payload"`
+		Owner     bool                   `db:"owner"`
+		// SeatHeldUntil is set when the player was dropped during the reserved seat time of a playing lobby
+		SeatHeldUntil *time.Time `db:"seat_held_until"`
+		DroppedAt     *time.Time `db:"dropped_at"`
+		ExpiresAt     *time.Time `db:"expires_at"`
+	}
+
+	// LobbySnapshot is the state of a lobby after an event. It is stored as json, the password is never part of it
+	LobbySnapshot struct {
+		Lobby   *Lobby    `json:"lobby"`
+		Players []*Player `json:"players"`
 	}
 
 	DB interface {
 		Close()
+		// Ping checks that the database can be reached
+		Ping() error
 		StartTransaction() (DBTx, error)
+		// TryLeaderLock returns nil if another instance holds the lock
+		TryLeaderLock(name string) (LeaderLock, error)
+	}
+
+	// LeaderLock is held by one instance at a time, until it is released or the instance dies
+	LeaderLock interface {
+		// IsHeld reports whether the lock is still held. It is lost when the connection to the database breaks
+		IsHeld() bool
+		Release()
 	}
 
 	DBTx interface {
 		//TX
 		Commit() error
 		Rollback() error
+		// TryTransactionLock returns false if another transaction holds the lock, it is released when the transaction ends
+		TryTransactionLock(name string) (bool, error)
 		//Lobby
 		CreateLobby(lobby *Lobby) error
 		UpdateLobby(lobby *Lobby) error
+		// IncrementLobbyVersion marks a change of the players of the lobby, which are part of its representation
+		IncrementLobbyVersion(id uuid.UUID) error
 		DeleteLobby(id uuid.UUID) error
 		GetLobbyById(id uuid.UUID) (*Lobby, error)
-		GetAllLobbies() ([]*Lobby, error)
+		GetLobbyByIdForUpdate(id uuid.UUID) (*Lobby, error)
+		GetLobbies(query *LobbyQuery) ([]*Lobby, *LobbyCursor, error)
 		//Player
 		CreatePlayer(player *Player) error
 		DeletePlayer(id uuid.UUID) error
 		DeleteAllPlayerInLobby(lobbyId uuid.UUID) error
 		UpdatePlayer(player *Player) error
 		UpdatePlayerLastRefresh(playerId uuid.UUID, lastRefresh time.Time) error
+		UpdatePlayersLastRefresh(lastRefreshes map[uuid.UUID]time.Time) error
+		// UpdatePlayerAfkNotice remembers the last afk message sent for the player. It returns false if the player refreshed
+		// since the given last refresh, a refresh clears the notice
+		UpdatePlayerAfkNotice(playerId uuid.UUID, lastRefresh time.Time, afkNotice string) (bool, error)
+		ResetPlayersReady(lobbyId uuid.UUID) ([]uuid.UUID, error)
 		GetPlayerById(id uuid.UUID) (*Player, error)
 		GetAllPlayersInLobby(lobbyId uuid.UUID) ([]*Player, error)
+		GetAllPlayersInLobbies(lobbyIds []uuid.UUID) ([]*Player, error)
 		GetPlayersLastRefresh(lastRefresh time.Time) ([]*Player, error)
 		GetNumberOfPlayersInLobby(lobbyId uuid.UUID) (int, error)
+		//Ban
+		CreateLobbyBan(ban *LobbyBan) error
+		GetLobbyBan(lobbyId uuid.UUID, playerId uuid.UUID) (*LobbyBan, error)
+		GetLobbyBans(lobbyId uuid.UUID) ([]*LobbyBan, error)
+		//JoinAttempt
+		UpsertJoinAttempt(attempt *JoinAttempt) error
+		DeleteJoinAttempt(lobbyId uuid.UUID, playerId uuid.UUID) error
+		GetJoinAttempt(lobbyId uuid.UUID, playerId uuid.UUID) (*JoinAttempt, error)
+		//Invite
+		CreateInvite(invite *Invite) error
+		UseInvite(code string) (bool, error)
+		DeleteInvite(code string) error
+		GetInviteByCode(code string) (*Invite, error)
+		GetInvitesInLobby(lobbyId uuid.UUID) ([]*Invite, error)
+		//IdempotencyKey
+		CreateIdempotencyKey(key *IdempotencyKey) error
+		UpdateIdempotencyKey(key *IdempotencyKey) error
+		DeleteIdempotencyKey(playerId uuid.UUID, key string) error
+		DeleteIdempotencyKeysBefore(expiresAt time.Time) error
+		GetIdempotencyKey(playerId uuid.UUID, key string) (*IdempotencyKey, error)
+		//ReconnectToken
+		UpsertReconnectToken(token *ReconnectToken) error
+		DeleteReconnectToken(playerId uuid.UUID) error
+		DeleteReconnectTokensBefore(expiresAt time.Time) error
+		GetReconnectToken(playerId uuid.UUID) (*ReconnectToken, error)
+		// GetNumberOfHeldSeats counts the seats which are still held for dropped players
+		GetNumberOfHeldSeats(lobbyId uuid.UUID, now time.Time) (int, error)
+		//Outbox
+		CreateOutboxMessage(message *OutboxMessage) error
+		UpdateOutboxMessage(message *OutboxMessage) error
+		DeleteOutboxMessage(id int64) error
+		GetDueOutboxMessages(now time.Time, limit int) ([]*OutboxMessage, error)
+		GetOutboxMessages() ([]*OutboxMessage, error)
+		GetFailedOutboxMessages() ([]*OutboxMessage, error)
+		//Event
+		CreateLobbyEvent(event *LobbyEvent) error
+		// DeleteLobbyEventsBefore also forgets the sequences of deleted lobbies which have no events left
+		DeleteLobbyEventsBefore(createdAt time.Time) error
+		GetLobbyEventsAfter(lobbyId uuid.UUID, afterSequence int64, limit int) ([]*LobbyEvent, error)
+		GetLastLobbyEventId(lobbyId uuid.UUID) (int64, error)
+		// GetLastPlayerLobbyEventId returns the last event of the lobby with one of the topics about the player, or 0 without such an event
+		GetLastPlayerLobbyEventId(lobbyId uuid.UUID, playerId uuid.UUID, topics []string) (int64, error)
+		//Audit
+		CreateLobbyAuditEntry(entry *LobbyAuditEntry) error
+		GetLobbyAuditEntriesAfter(lobbyId uuid.UUID, afterSequence int64, limit int) ([]*LobbyAuditEntry, error)
 	}
 )
 
+var (
+	// ErrVersionConflict is returned when a row was changed since it was loaded
+	ErrVersionConflict = errors.New("version conflict")
+)
+
 const (
 	schema_name = "theredshirts_lobby"
+
+	//Lobby sorting
+	LobbySortCreatedAt  = "created_at"
+	LobbySortName       = "name"
+	LobbySortDifficulty = "difficulty"
+	LobbySortFreeSlots  = "free_slots"
 )
 
 func NewConnection() (DB, error) {
 	switch db := strings.ToLower(util.GetEnvWithFallback("DATABASE", "postgresql")); db {
 	case "postgresql":
 		return newPostgresConnection()
+	case "inmemory":
+		return newInmemoryConnection()
+	default:
+		return nil, fmt.Errorf("no configuration for %s found", db)
+	}
+}
+
+// Migrate brings the schema of the database up to date. It also happens on every new connection,
+// so it is only needed to migrate before the services are started
+func Migrate() error {
+	switch db := strings.ToLower(util.GetEnvWithFallback("DATABASE", "postgresql")); db {
+	case "postgresql":
+		return migratePostgres()
+	case "inmemory":
+		return nil
 	default:
-		return nil, errors.New("no configuration for %s found")
+		return fmt.Errorf("no configuration for %s found", db)
 	}
 }
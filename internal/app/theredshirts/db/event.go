@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	lobby_event_table_name    = "lobby_event"
+	lobby_sequence_table_name = "lobby_sequence"
+	// next_lobby_sequence_sql counts up the sequence of a lobby. The row stays locked until the transaction ends,
+	// so the sequences of a lobby are committed in order and readers never skip a sequence which is committed later
+	next_lobby_sequence_sql        = "WITH next AS (INSERT INTO %[1]s.%[2]s AS s(lobby_id, last_sequence) VALUES($1, 1) ON CONFLICT (lobby_id) DO UPDATE SET last_sequence = s.last_sequence + 1 RETURNING last_sequence) "
+	create_lobby_event_sql         = "INSERT INTO %[1]s.%[3]s(lobby_id, sequence, topic, payload, snapshot, created_at) VALUES($1, (SELECT last_sequence FROM next), $2, $3, $4, $5) RETURNING id, sequence"
+	delete_lobby_event_before_sql  = "DELETE FROM %s.%s WHERE created_at < $1"
+	delete_lobby_sequence_sql      = "DELETE FROM %[1]s.%[2]s s WHERE s.last_audit_sequence = 0 AND NOT EXISTS (SELECT 1 FROM %[1]s.%[3]s e WHERE e.lobby_id = s.lobby_id) AND NOT EXISTS (SELECT 1 FROM %[1]s.%[4]s l WHERE l.id = s.lobby_id)"
+	select_lobby_event_after_sql   = "SELECT id, lobby_id, sequence, topic, payload, snapshot, created_at FROM %s.%s WHERE lobby_id = $1 AND sequence > $2 ORDER BY sequence LIMIT $3"
+	select_lobby_event_last_id_sql = "SELECT coalesce(max(sequence), 0) AS id FROM %s.%s WHERE lobby_id = $1"
+	select_player_event_last_sql   = "SELECT coalesce(max(sequence), 0) AS id FROM %s.%s WHERE lobby_id = $1 AND payload->>'player_id' = $2 AND topic = ANY($3)"
+)
+
+type eventId struct {
+	ID int64 `db:"id"`
+}
+
+func (tx *postgresTransaction) CreateLobbyEvent(event *LobbyEvent) error {
+	if err := tx.tx.QueryRow(context.Background(), fmt.Sprintf(next_lobby_sequence_sql+create_lobby_event_sql, schema_name, lobby_sequence_table_name, lobby_event_table_name), event.LobbyId, event.Topic, event.Payload, event.Snapshot, event.CreatedAt).Scan(&event.ID, &event.Sequence); err != nil {
+		return fmt.Errorf("unknown error when inserting lobby event: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteLobbyEventsBefore(createdAt time.Time) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_lobby_event_before_sql, schema_name, lobby_event_table_name), createdAt); err != nil {
+		return fmt.Errorf("unknown error when deleting lobby events: %v", err)
+	}
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_lobby_sequence_sql, schema_name, lobby_sequence_table_name, lobby_event_table_name, lobby_table_name)); err != nil {
+		return fmt.Errorf("unknown error when deleting lobby sequences: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetLobbyEventsAfter(lobbyId uuid.UUID, afterSequence int64, limit int) ([]*LobbyEvent, error) {
+	var events []*LobbyEvent
+	if err := pgxscan.Select(context.Background(), tx.tx, &events, fmt.Sprintf(select_lobby_event_after_sql, schema_name, lobby_event_table_name), lobbyId, afterSequence, limit); err != nil {
+		return nil, fmt.Errorf("error while selecting lobby events: %v", err)
+	}
+	return events, nil
+}
+
+func (tx *postgresTransaction) GetLastLobbyEventId(lobbyId uuid.UUID) (int64, error) {
+	var ids []*eventId
+	if err := pgxscan.Select(context.Background(), tx.tx, &ids, fmt.Sprintf(select_lobby_event_last_id_sql, schema_name, lobby_event_table_name), lobbyId); err != nil {
+		return 0, fmt.Errorf("error while selecting last lobby event id: %v", err)
+	}
+	if len(ids) != 1 {
+		return 0, fmt.Errorf("cant find only one last lobby event id. Found ids: %+v", ids)
+	}
+	return ids[0].ID, nil
+}
+
+func (tx *postgresTransaction) GetLastPlayerLobbyEventId(lobbyId uuid.UUID, playerId uuid.UUID, topics []string) (int64, error) {
+	var ids []*eventId
+	if err := pgxscan.Select(context.Background(), tx.tx, &ids, fmt.Sprintf(select_player_event_last_sql, schema_name, lobby_event_table_name), lobbyId, playerId.String(), topics); err != nil {
+		return 0, fmt.Errorf("error while selecting last lobby event id of player: %v", err)
+	}
+	if len(ids) != 1 {
+		return 0, fmt.Errorf("cant find only one last lobby event id of player. Found ids: %+v", ids)
+	}
+	return ids[0].ID, nil
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+)
+
+const (
+	idempotency_key_table_name        = "idempotency_key"
+	create_idempotency_key_sql        = "INSERT INTO %s.%s(player_id, key, request_hash, status_code, content_type, body, created_at, expires_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8)"
+	update_idempotency_key_sql        = "UPDATE %s.%s SET request_hash = $3, status_code = $4, content_type = $5, body = $6, created_at = $7, expires_at = $8 WHERE player_id = $1 AND key = $2"
+	delete_idempotency_key_sql        = "DELETE FROM %s.%s WHERE player_id = $1 AND key = $2"
+	delete_idempotency_key_before_sql = "DELETE FROM %s.%s WHERE expires_at < $1"
+	select_idempotency_key_by_key_sql = "SELECT player_id, key, request_hash, status_code, content_type, body, created_at, expires_at FROM %s.%s WHERE player_id = $1 AND key = $2"
+)
+
+var (
+	ErrIdempotencyKeyAlreadyExists = errors.New("idempotency key already exists")
+)
+
+func (tx *postgresTransaction) CreateIdempotencyKey(key *IdempotencyKey) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_idempotency_key_sql, schema_name, idempotency_key_table_name), key.PlayerId, key.Key, key.RequestHash, key.StatusCode, key.ContentType, key.Body, key.CreatedAt, key.ExpiresAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return ErrIdempotencyKeyAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("unknown error when inserting idempotency key: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) UpdateIdempotencyKey(key *IdempotencyKey) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_idempotency_key_sql, schema_name, idempotency_key_table_name), key.PlayerId, key.Key, key.RequestHash, key.StatusCode, key.ContentType, key.Body, key.CreatedAt, key.ExpiresAt); err != nil {
+		return fmt.Errorf("unknown error when updating idempotency key: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteIdempotencyKey(playerId uuid.UUID, key string) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_idempotency_key_sql, schema_name, idempotency_key_table_name), playerId, key); err != nil {
+		return fmt.Errorf("unknown error when deleting idempotency key: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteIdempotencyKeysBefore(expiresAt time.Time) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_idempotency_key_before_sql, schema_name, idempotency_key_table_name), expiresAt); err != nil {
+		return fmt.Errorf("unknown error when deleting expired idempotency keys: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetIdempotencyKey(playerId uuid.UUID, key string) (*IdempotencyKey, error) {
+	var keys []*IdempotencyKey
+	if err := pgxscan.Select(context.Background(), tx.tx, &keys, fmt.Sprintf(select_idempotency_key_by_key_sql, schema_name, idempotency_key_table_name), playerId, key); err != nil {
+		return nil, fmt.Errorf("error while selecting idempotency key: %v", err)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	if len(keys) != 1 {
+		return nil, fmt.Errorf("cant find only one idempotency key. Keys: %v", keys)
+	}
+
+	return keys[0], nil
+}
@@ -0,0 +1,377 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTransactionClosed = errors.New("transaction already closed")
+)
+
+type (
+	inmemoryConnection struct {
+		mutex           sync.RWMutex
+		lobbies         map[uuid.UUID]*Lobby
+		players         map[uuid.UUID]*Player
+		bans            map[lobbyBanKey]*LobbyBan
+		joinAttempts    map[joinAttemptKey]*JoinAttempt
+		invites         map[string]*Invite
+		idempotencyKeys map[idempotencyKeyKey]*IdempotencyKey
+		reconnectTokens map[uuid.UUID]*ReconnectToken
+		outbox          map[int64]*OutboxMessage
+		nextOutboxId    int64
+		events          []*LobbyEvent
+		nextEventId     int64
+		lobbySequences  map[uuid.UUID]int64
+		audit           []*LobbyAuditEntry
+		nextAuditId     int64
+		auditSequences  map[uuid.UUID]int64
+		// lobbyLocks emulate the row locks of postgres, a locked lobby stays locked until the transaction ends
+		lobbyLocksMutex  sync.Mutex
+		lobbyLocks       map[uuid.UUID]*lobbyLock
+		leaderLocksMutex sync.Mutex
+		leaderLocks      map[string]bool
+		transactionLocks map[string]bool
+	}
+
+	// lobbyLock counts the transactions holding or waiting for it, it is removed when the last one is done
+	lobbyLock struct {
+		mutex   sync.Mutex
+		holders int
+	}
+
+	// inmemoryTransaction collects all writes in its own overlay, which is applied to the connection on commit.
+	// Reads see the committed state merged with the own writes. A nil value in an overlay marks a deleted row.
+	inmemoryTransaction struct {
+		connection             *inmemoryConnection
+		closed                 bool
+		lobbies                map[uuid.UUID]*Lobby
+		createdLobbies         map[uuid.UUID]bool
+		players                map[uuid.UUID]*Player
+		createdPlayers         map[uuid.UUID]bool
+		bans                   map[lobbyBanKey]*LobbyBan
+		joinAttempts           map[joinAttemptKey]*JoinAttempt
+		invites                map[string]*Invite
+		createdInvites         map[string]bool
+		idempotencyKeys        map[idempotencyKeyKey]*IdempotencyKey
+		createdIdempotencyKeys map[idempotencyKeyKey]bool
+		reconnectTokens        map[uuid.UUID]*ReconnectToken
+		outbox                 map[int64]*OutboxMessage
+		// createdOutbox get their final id on commit, so the ids follow the commit order like the sequences of the events
+		createdOutbox []*OutboxMessage
+		events        []*LobbyEvent
+		audit         []*LobbyAuditEntry
+		// deleteEventsBefore is set when the transaction removes all events created before that time
+		deleteEventsBefore *time.Time
+		lockedLobbies      []uuid.UUID
+		transactionLocks   []string
+		// versions holds the version of each lobby and player when the transaction first changed it
+		versions map[uuid.UUID]int
+		// lastRefreshes are only applied to the last refresh of committed players
+		lastRefreshes map[uuid.UUID]time.Time
+		// afkNotices are only applied to committed players which didn't refresh in the meantime
+		afkNotices map[uuid.UUID]*afkNotice
+	}
+
+	afkNotice struct {
+		lastRefresh time.Time
+		notice      string
+	}
+)
+
+func newInmemoryConnection() (DB, error) {
+	return &inmemoryConnection{lobbies: make(map[uuid.UUID]*Lobby), players: make(map[uuid.UUID]*Player), bans: make(map[lobbyBanKey]*LobbyBan), joinAttempts: make(map[joinAttemptKey]*JoinAttempt), invites: make(map[string]*Invite), idempotencyKeys: make(map[idempotencyKeyKey]*IdempotencyKey), reconnectTokens: make(map[uuid.UUID]*ReconnectToken), outbox: make(map[int64]*OutboxMessage), events: make([]*LobbyEvent, 0), lobbySequences: make(map[uuid.UUID]int64), auditSequences: make(map[uuid.UUID]int64), lobbyLocks: make(map[uuid.UUID]*lobbyLock), leaderLocks: make(map[string]bool), transactionLocks: make(map[string]bool)}, nil
+}
+
+func (connection *inmemoryConnection) Close() {
+}
+
+func (connection *inmemoryConnection) Ping() error {
+	return nil
+}
+
+func (connection *inmemoryConnection) StartTransaction() (DBTx, error) {
+	return &inmemoryTransaction{
+		connection:             connection,
+		lobbies:                make(map[uuid.UUID]*Lobby),
+		createdLobbies:         make(map[uuid.UUID]bool),
+		players:                make(map[uuid.UUID]*Player),
+		createdPlayers:         make(map[uuid.UUID]bool),
+		bans:                   make(map[lobbyBanKey]*LobbyBan),
+		joinAttempts:           make(map[joinAttemptKey]*JoinAttempt),
+		invites:                make(map[string]*Invite),
+		createdInvites:         make(map[string]bool),
+		idempotencyKeys:        make(map[idempotencyKeyKey]*IdempotencyKey),
+		createdIdempotencyKeys: make(map[idempotencyKeyKey]bool),
+		reconnectTokens:        make(map[uuid.UUID]*ReconnectToken),
+		outbox:                 make(map[int64]*OutboxMessage),
+		versions:               make(map[uuid.UUID]int),
+		lastRefreshes:          make(map[uuid.UUID]time.Time),
+		afkNotices:             make(map[uuid.UUID]*afkNotice),
+	}, nil
+}
+
+func (tx *inmemoryTransaction) Commit() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+	tx.closed = true
+	defer tx.unlockLobbies()
+	defer tx.unlockTransactionLocks()
+
+	connection := tx.connection
+	connection.mutex.Lock()
+	defer connection.mutex.Unlock()
+
+	for id := range tx.createdLobbies {
+		if _, ok := connection.lobbies[id]; ok {
+			return fmt.Errorf("error while commiting lobby [%v]: %w", id, ErrLobbyAlreadyExists)
+		}
+	}
+	for id := range tx.createdPlayers {
+		if _, ok := connection.players[id]; ok {
+			return fmt.Errorf("error while commiting player [%v]: %w", id, ErrPlayerAlreadyExists)
+		}
+	}
+	for id, version := range tx.versions {
+		if lobby, ok := connection.lobbies[id]; ok && lobby.Version != version {
+			return fmt.Errorf("error while commiting lobby [%v]: %w", id, ErrVersionConflict)
+		}
+		if player, ok := connection.players[id]; ok && player.Version != version {
+			return fmt.Errorf("error while commiting player [%v]: %w", id, ErrVersionConflict)
+		}
+	}
+	for code := range tx.createdInvites {
+		if _, ok := connection.invites[code]; ok {
+			return fmt.Errorf("error while commiting invite [%v]: %w", code, ErrInviteAlreadyExists)
+		}
+	}
+	for key := range tx.createdIdempotencyKeys {
+		if _, ok := connection.idempotencyKeys[key]; ok {
+			return fmt.Errorf("error while commiting idempotency key [%v]: %w", key.key, ErrIdempotencyKeyAlreadyExists)
+		}
+	}
+
+	for id, lobby := range tx.lobbies {
+		_, exists := connection.lobbies[id]
+		switch {
+		case lobby == nil:
+			delete(connection.lobbies, id)
+		case exists || tx.createdLobbies[id]:
+			connection.lobbies[id] = lobby
+		}
+	}
+	for id, player := range tx.players {
+		_, exists := connection.players[id]
+		switch {
+		case player == nil:
+			delete(connection.players, id)
+		case exists || tx.createdPlayers[id]:
+			connection.players[id] = player
+		}
+	}
+	for id, notice := range tx.afkNotices {
+		if player, ok := connection.players[id]; ok && player.LastRefresh.Equal(notice.lastRefresh) {
+			player.AfkNotice = notice.notice
+		}
+	}
+	for id, lastRefresh := range tx.lastRefreshes {
+		if player, ok := connection.players[id]; ok {
+			player.LastRefresh = lastRefresh
+			player.AfkNotice = ""
+		}
+	}
+	for key, ban := range tx.bans {
+		if ban == nil {
+			delete(connection.bans, key)
+		} else if _, exists := connection.lobbies[key.lobbyId]; exists {
+			connection.bans[key] = ban
+		}
+	}
+	for key, attempt := range tx.joinAttempts {
+		if attempt == nil {
+			delete(connection.joinAttempts, key)
+		} else if _, exists := connection.lobbies[key.lobbyId]; exists {
+			connection.joinAttempts[key] = attempt
+		}
+	}
+	for code, invite := range tx.invites {
+		_, exists := connection.invites[code]
+		switch {
+		case invite == nil:
+			delete(connection.invites, code)
+		case exists || tx.createdInvites[code]:
+			connection.invites[code] = invite
+		}
+	}
+	for mapKey, key := range tx.idempotencyKeys {
+		_, exists := connection.idempotencyKeys[mapKey]
+		switch {
+		case key == nil:
+			delete(connection.idempotencyKeys, mapKey)
+		case exists || tx.createdIdempotencyKeys[mapKey]:
+			connection.idempotencyKeys[mapKey] = key
+		}
+	}
+	for playerId, token := range tx.reconnectTokens {
+		if token == nil {
+			delete(connection.reconnectTokens, playerId)
+		} else if _, exists := connection.lobbies[token.LobbyId]; exists {
+			connection.reconnectTokens[playerId] = token
+		}
+	}
+	for id, lobby := range tx.lobbies {
+		if lobby == nil {
+			for code, invite := range connection.invites {
+				if invite.LobbyId == id {
+					delete(connection.invites, code)
+				}
+			}
+			for key := range connection.joinAttempts {
+				if key.lobbyId == id {
+					delete(connection.joinAttempts, key)
+				}
+			}
+			for playerId, token := range connection.reconnectTokens {
+				if token.LobbyId == id {
+					delete(connection.reconnectTokens, playerId)
+				}
+			}
+		}
+	}
+	for id, message := range tx.outbox {
+		_, exists := connection.outbox[id]
+		switch {
+		case message == nil:
+			delete(connection.outbox, id)
+		case exists:
+			connection.outbox[id] = message
+		}
+	}
+	for _, message := range tx.createdOutbox {
+		connection.nextOutboxId++
+		message.ID = connection.nextOutboxId
+		connection.outbox[message.ID] = message
+	}
+	if tx.deleteEventsBefore != nil {
+		events := make([]*LobbyEvent, 0, len(connection.events))
+		for _, event := range connection.events {
+			if !event.CreatedAt.Before(*tx.deleteEventsBefore) {
+				events = append(events, event)
+			}
+		}
+		connection.events = events
+		connection.deleteLobbySequences()
+	}
+	for _, event := range tx.events {
+		connection.lobbySequences[event.LobbyId]++
+		event.Sequence = connection.lobbySequences[event.LobbyId]
+	}
+	connection.events = append(connection.events, tx.events...)
+	for _, entry := range tx.audit {
+		connection.auditSequences[entry.LobbyId]++
+		entry.Sequence = connection.auditSequences[entry.LobbyId]
+	}
+	connection.audit = append(connection.audit, tx.audit...)
+	return nil
+}
+
+// deleteLobbySequences forgets the sequences of deleted lobbies which have no events left
+func (connection *inmemoryConnection) deleteLobbySequences() {
+	lobbiesWithEvents := make(map[uuid.UUID]bool)
+	for _, event := range connection.events {
+		lobbiesWithEvents[event.LobbyId] = true
+	}
+	for lobbyId := range connection.lobbySequences {
+		if _, exists := connection.lobbies[lobbyId]; !exists && !lobbiesWithEvents[lobbyId] {
+			delete(connection.lobbySequences, lobbyId)
+		}
+	}
+}
+
+func (tx *inmemoryTransaction) Rollback() error {
+	if tx.closed {
+		return ErrTransactionClosed
+	}
+	tx.closed = true
+	tx.unlockLobbies()
+	tx.unlockTransactionLocks()
+	return nil
+}
+
+// lockVersion remembers the version a row had before the first change, to detect changes of other transactions on commit
+func (tx *inmemoryTransaction) lockVersion(id uuid.UUID, version int) {
+	if _, ok := tx.versions[id]; !ok {
+		tx.versions[id] = version
+	}
+}
+
+// lockLobby blocks until no other transaction holds the lock of the lobby
+func (tx *inmemoryTransaction) lockLobby(id uuid.UUID) {
+	for _, locked := range tx.lockedLobbies {
+		if locked == id {
+			return
+		}
+	}
+
+	connection := tx.connection
+	connection.lobbyLocksMutex.Lock()
+	lock, ok := connection.lobbyLocks[id]
+	if !ok {
+		lock = new(lobbyLock)
+		connection.lobbyLocks[id] = lock
+	}
+	lock.holders++
+	connection.lobbyLocksMutex.Unlock()
+
+	lock.mutex.Lock()
+	tx.lockedLobbies = append(tx.lockedLobbies, id)
+}
+
+// unlockLobbies releases the locks of the transaction. Locks nobody waits for are removed, so locks of deleted lobbies don't pile up
+func (tx *inmemoryTransaction) unlockLobbies() {
+	connection := tx.connection
+	connection.lobbyLocksMutex.Lock()
+	defer connection.lobbyLocksMutex.Unlock()
+	for _, id := range tx.lockedLobbies {
+		lock := connection.lobbyLocks[id]
+		lock.holders--
+		if lock.holders == 0 {
+			delete(connection.lobbyLocks, id)
+		}
+		lock.mutex.Unlock()
+	}
+	tx.lockedLobbies = nil
+}
+
+// copyPayload copies a json payload with all nested objects and arrays, so changes of a caller don't reach the stored rows
+func copyPayload(payload map[string]interface{}) map[string]interface{} {
+	if payload == nil {
+		return nil
+	}
+	copiedPayload := make(map[string]interface{}, len(payload))
+	for key, value := range payload {
+		copiedPayload[key] = copyPayloadValue(value)
+	}
+	return copiedPayload
+}
+
+func copyPayloadValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return copyPayload(typedValue)
+	case []interface{}:
+		copiedValues := make([]interface{}, len(typedValue))
+		for index, element := range typedValue {
+			copiedValues[index] = copyPayloadValue(element)
+		}
+		return copiedValues
+	default:
+		return value
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// CreateLobbyAuditEntry assigns a provisional sequence, the final sequence is assigned on commit in the order the entries are committed
+func (tx *inmemoryTransaction) CreateLobbyAuditEntry(entry *LobbyAuditEntry) error {
+	tx.connection.mutex.Lock()
+	tx.connection.nextAuditId++
+	entry.ID = tx.connection.nextAuditId
+	entry.Sequence = tx.connection.auditSequences[entry.LobbyId] + 1
+	tx.connection.mutex.Unlock()
+	for _, createdEntry := range tx.audit {
+		if createdEntry.LobbyId == entry.LobbyId {
+			entry.Sequence++
+		}
+	}
+
+	tx.audit = append(tx.audit, copyLobbyAuditEntry(entry))
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetLobbyAuditEntriesAfter(lobbyId uuid.UUID, afterSequence int64, limit int) ([]*LobbyAuditEntry, error) {
+	tx.connection.mutex.RLock()
+	entries := filterLobbyAuditEntries(make([]*LobbyAuditEntry, 0), tx.connection.audit, lobbyId, afterSequence)
+	tx.connection.mutex.RUnlock()
+	entries = filterLobbyAuditEntries(entries, tx.audit, lobbyId, afterSequence)
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Sequence < entries[j].Sequence })
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func filterLobbyAuditEntries(entries []*LobbyAuditEntry, audit []*LobbyAuditEntry, lobbyId uuid.UUID, afterSequence int64) []*LobbyAuditEntry {
+	for _, entry := range audit {
+		if entry.LobbyId == lobbyId && entry.Sequence > afterSequence {
+			entries = append(entries, copyLobbyAuditEntry(entry))
+		}
+	}
+	return entries
+}
+
+func copyLobbyAuditEntry(entry *LobbyAuditEntry) *LobbyAuditEntry {
+	copiedEntry := *entry
+	copiedEntry.Payload = copyPayload(entry.Payload)
+	return &copiedEntry
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type lobbyBanKey struct {
+	lobbyId  uuid.UUID
+	playerId uuid.UUID
+}
+
+func (tx *inmemoryTransaction) CreateLobbyBan(ban *LobbyBan) error {
+	lobby, err := tx.GetLobbyById(ban.LobbyId)
+	if err != nil {
+		return err
+	}
+	if lobby == nil {
+		return fmt.Errorf("unknown error when inserting lobby ban: lobby [%v] does not exist", ban.LobbyId)
+	}
+	copiedBan := *ban
+	tx.bans[lobbyBanKey{lobbyId: ban.LobbyId, playerId: ban.PlayerId}] = &copiedBan
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetLobbyBan(lobbyId uuid.UUID, playerId uuid.UUID) (*LobbyBan, error) {
+	for _, ban := range tx.allLobbyBans() {
+		if ban.LobbyId == lobbyId && ban.PlayerId == playerId {
+			return ban, nil
+		}
+	}
+	return nil, nil
+}
+
+func (tx *inmemoryTransaction) GetLobbyBans(lobbyId uuid.UUID) ([]*LobbyBan, error) {
+	bans := make([]*LobbyBan, 0)
+	for _, ban := range tx.allLobbyBans() {
+		if ban.LobbyId == lobbyId {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].CreatedAt.Before(bans[j].CreatedAt) })
+	return bans, nil
+}
+
+// deleteLobbyBans removes the bans together with their lobby, like the cascade of the foreign key in postgres
+func (tx *inmemoryTransaction) deleteLobbyBans(lobbyId uuid.UUID) {
+	for _, ban := range tx.allLobbyBans() {
+		if ban.LobbyId == lobbyId {
+			tx.bans[lobbyBanKey{lobbyId: ban.LobbyId, playerId: ban.PlayerId}] = nil
+		}
+	}
+}
+
+func (tx *inmemoryTransaction) allLobbyBans() []*LobbyBan {
+	tx.connection.mutex.RLock()
+	bans := make([]*LobbyBan, 0, len(tx.connection.bans))
+	for key, ban := range tx.connection.bans {
+		if _, ok := tx.bans[key]; !ok {
+			copiedBan := *ban
+			bans = append(bans, &copiedBan)
+		}
+	}
+	tx.connection.mutex.RUnlock()
+
+	for _, ban := range tx.bans {
+		if ban != nil {
+			copiedBan := *ban
+			bans = append(bans, &copiedBan)
+		}
+	}
+	return bans
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateLobbyEvent assigns a provisional sequence, the final sequence is assigned on commit in the order the events are committed
+func (tx *inmemoryTransaction) CreateLobbyEvent(event *LobbyEvent) error {
+	tx.connection.mutex.Lock()
+	tx.connection.nextEventId++
+	event.ID = tx.connection.nextEventId
+	event.Sequence = tx.connection.lobbySequences[event.LobbyId] + 1
+	tx.connection.mutex.Unlock()
+	for _, createdEvent := range tx.events {
+		if createdEvent.LobbyId == event.LobbyId {
+			event.Sequence++
+		}
+	}
+
+	tx.events = append(tx.events, copyLobbyEvent(event))
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteLobbyEventsBefore(createdAt time.Time) error {
+	tx.deleteEventsBefore = &createdAt
+	events := make([]*LobbyEvent, 0, len(tx.events))
+	for _, event := range tx.events {
+		if !event.CreatedAt.Before(createdAt) {
+			events = append(events, event)
+		}
+	}
+	tx.events = events
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetLobbyEventsAfter(lobbyId uuid.UUID, afterSequence int64, limit int) ([]*LobbyEvent, error) {
+	events := make([]*LobbyEvent, 0)
+	for _, event := range tx.allLobbyEvents() {
+		if event.LobbyId == lobbyId && event.Sequence > afterSequence && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (tx *inmemoryTransaction) GetLastLobbyEventId(lobbyId uuid.UUID) (int64, error) {
+	var lastId int64
+	for _, event := range tx.allLobbyEvents() {
+		if event.LobbyId == lobbyId && event.Sequence > lastId {
+			lastId = event.Sequence
+		}
+	}
+	return lastId, nil
+}
+
+func (tx *inmemoryTransaction) GetLastPlayerLobbyEventId(lobbyId uuid.UUID, playerId uuid.UUID, topics []string) (int64, error) {
+	var lastId int64
+	for _, event := range tx.allLobbyEvents() {
+		if event.LobbyId != lobbyId || event.Sequence <= lastId || fmt.Sprint(event.Payload["player_id"]) != playerId.String() {
+			continue
+		}
+		for _, topic := range topics {
+			if event.Topic == topic {
+				lastId = event.Sequence
+			}
+		}
+	}
+	return lastId, nil
+}
+
+func (tx *inmemoryTransaction) allLobbyEvents() []*LobbyEvent {
+	tx.connection.mutex.RLock()
+	events := make([]*LobbyEvent, 0, len(tx.connection.events)+len(tx.events))
+	for _, event := range tx.connection.events {
+		if tx.deleteEventsBefore == nil || !event.CreatedAt.Before(*tx.deleteEventsBefore) {
+			events = append(events, copyLobbyEvent(event))
+		}
+	}
+	tx.connection.mutex.RUnlock()
+
+	for _, event := range tx.events {
+		events = append(events, copyLobbyEvent(event))
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Sequence < events[j].Sequence })
+	return events
+}
+
+func copyLobbyEvent(event *LobbyEvent) *LobbyEvent {
+	copiedEvent := *event
+	copiedEvent.Payload = copyPayload(event.Payload)
+	if event.Snapshot != nil {
+		copiedEvent.Snapshot = &LobbySnapshot{Lobby: copyLobby(event.Snapshot.Lobby), Players: make([]*Player, len(event.Snapshot.Players))}
+		for index, player := range event.Snapshot.Players {
+			copiedEvent.Snapshot.Players[index] = copyPlayer(player)
+		}
+	}
+	return &copiedEvent
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type idempotencyKeyKey struct {
+	playerId uuid.UUID
+	key      string
+}
+
+func (tx *inmemoryTransaction) CreateIdempotencyKey(key *IdempotencyKey) error {
+	found, err := tx.GetIdempotencyKey(key.PlayerId, key.Key)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrIdempotencyKeyAlreadyExists
+	}
+	mapKey := idempotencyKeyKey{playerId: key.PlayerId, key: key.Key}
+	tx.idempotencyKeys[mapKey] = copyIdempotencyKey(key)
+	tx.createdIdempotencyKeys[mapKey] = true
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdateIdempotencyKey(key *IdempotencyKey) error {
+	found, err := tx.GetIdempotencyKey(key.PlayerId, key.Key)
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		return nil
+	}
+	tx.idempotencyKeys[idempotencyKeyKey{playerId: key.PlayerId, key: key.Key}] = copyIdempotencyKey(key)
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteIdempotencyKey(playerId uuid.UUID, key string) error {
+	tx.idempotencyKeys[idempotencyKeyKey{playerId: playerId, key: key}] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteIdempotencyKeysBefore(expiresAt time.Time) error {
+	for _, key := range tx.allIdempotencyKeys() {
+		if key.ExpiresAt.Before(expiresAt) {
+			tx.idempotencyKeys[idempotencyKeyKey{playerId: key.PlayerId, key: key.Key}] = nil
+		}
+	}
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetIdempotencyKey(playerId uuid.UUID, key string) (*IdempotencyKey, error) {
+	mapKey := idempotencyKeyKey{playerId: playerId, key: key}
+	if found, ok := tx.idempotencyKeys[mapKey]; ok {
+		return copyIdempotencyKey(found), nil
+	}
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+	return copyIdempotencyKey(tx.connection.idempotencyKeys[mapKey]), nil
+}
+
+func (tx *inmemoryTransaction) allIdempotencyKeys() []*IdempotencyKey {
+	tx.connection.mutex.RLock()
+	keys := make([]*IdempotencyKey, 0, len(tx.connection.idempotencyKeys))
+	for mapKey, key := range tx.connection.idempotencyKeys {
+		if _, ok := tx.idempotencyKeys[mapKey]; !ok {
+			keys = append(keys, copyIdempotencyKey(key))
+		}
+	}
+	tx.connection.mutex.RUnlock()
+
+	for _, key := range tx.idempotencyKeys {
+		if key != nil {
+			keys = append(keys, copyIdempotencyKey(key))
+		}
+	}
+	return keys
+}
+
+func copyIdempotencyKey(key *IdempotencyKey) *IdempotencyKey {
+	if key == nil {
+		return nil
+	}
+	copiedKey := *key
+	copiedKey.Body = append([]byte(nil), key.Body...)
+	return &copiedKey
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+func (tx *inmemoryTransaction) CreateInvite(invite *Invite) error {
+	found, err := tx.GetInviteByCode(invite.Code)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrInviteAlreadyExists
+	}
+	lobby, err := tx.GetLobbyById(invite.LobbyId)
+	if err != nil {
+		return err
+	}
+	if lobby == nil {
+		return fmt.Errorf("unknown error when inserting invite: lobby [%v] does not exist", invite.LobbyId)
+	}
+	tx.invites[invite.Code] = copyInvite(invite)
+	tx.createdInvites[invite.Code] = true
+	return nil
+}
+
+// UseInvite locks the lobby of the invite like the row lock of the update in postgres, so concurrent uses are counted one after the other
+func (tx *inmemoryTransaction) UseInvite(code string) (bool, error) {
+	found, err := tx.GetInviteByCode(code)
+	if err != nil || found == nil {
+		return false, err
+	}
+	tx.lockLobby(found.LobbyId)
+
+	found, err = tx.GetInviteByCode(code)
+	if err != nil || found == nil {
+		return false, err
+	}
+	if found.MaxUses > 0 && found.Uses >= found.MaxUses {
+		return false, nil
+	}
+	found.Uses++
+	tx.invites[code] = found
+	return true, nil
+}
+
+func (tx *inmemoryTransaction) DeleteInvite(code string) error {
+	tx.invites[code] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetInviteByCode(code string) (*Invite, error) {
+	if invite, ok := tx.invites[code]; ok {
+		return copyInvite(invite), nil
+	}
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+	return copyInvite(tx.connection.invites[code]), nil
+}
+
+func (tx *inmemoryTransaction) GetInvitesInLobby(lobbyId uuid.UUID) ([]*Invite, error) {
+	invites := make([]*Invite, 0)
+	for _, invite := range tx.allInvites() {
+		if invite.LobbyId == lobbyId {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].CreatedAt.Before(invites[j].CreatedAt) })
+	return invites, nil
+}
+
+// deleteLobbyInvites removes the invites together with their lobby, like the cascade of the foreign key in postgres
+func (tx *inmemoryTransaction) deleteLobbyInvites(lobbyId uuid.UUID) {
+	for _, invite := range tx.allInvites() {
+		if invite.LobbyId == lobbyId {
+			tx.invites[invite.Code] = nil
+		}
+	}
+}
+
+func (tx *inmemoryTransaction) allInvites() []*Invite {
+	tx.connection.mutex.RLock()
+	invites := make([]*Invite, 0, len(tx.connection.invites))
+	for code, invite := range tx.connection.invites {
+		if _, ok := tx.invites[code]; !ok {
+			invites = append(invites, copyInvite(invite))
+		}
+	}
+	tx.connection.mutex.RUnlock()
+
+	for _, invite := range tx.invites {
+		if invite != nil {
+			invites = append(invites, copyInvite(invite))
+		}
+	}
+	return invites
+}
+
+func copyInvite(invite *Invite) *Invite {
+	if invite == nil {
+		return nil
+	}
+	copiedInvite := *invite
+	if invite.ExpiresAt != nil {
+		expiresAt := *invite.ExpiresAt
+		copiedInvite.ExpiresAt = &expiresAt
+	}
+	return &copiedInvite
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"github.com/google/uuid"
+)
+
+type joinAttemptKey struct {
+	lobbyId  uuid.UUID
+	playerId uuid.UUID
+}
+
+func (tx *inmemoryTransaction) UpsertJoinAttempt(attempt *JoinAttempt) error {
+	copiedAttempt := *attempt
+	tx.joinAttempts[joinAttemptKey{lobbyId: attempt.LobbyId, playerId: attempt.PlayerId}] = &copiedAttempt
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteJoinAttempt(lobbyId uuid.UUID, playerId uuid.UUID) error {
+	tx.joinAttempts[joinAttemptKey{lobbyId: lobbyId, playerId: playerId}] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetJoinAttempt(lobbyId uuid.UUID, playerId uuid.UUID) (*JoinAttempt, error) {
+	key := joinAttemptKey{lobbyId: lobbyId, playerId: playerId}
+	attempt, ok := tx.joinAttempts[key]
+	if !ok {
+		tx.connection.mutex.RLock()
+		attempt = tx.connection.joinAttempts[key]
+		tx.connection.mutex.RUnlock()
+	}
+	if attempt == nil {
+		return nil, nil
+	}
+	copiedAttempt := *attempt
+	return &copiedAttempt, nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import "sync"
+
+type inmemoryLeaderLock struct {
+	connection *inmemoryConnection
+	name       string
+	once       sync.Once
+}
+
+func (connection *inmemoryConnection) TryLeaderLock(name string) (LeaderLock, error) {
+	connection.leaderLocksMutex.Lock()
+	defer connection.leaderLocksMutex.Unlock()
+	if connection.leaderLocks[name] {
+		return nil, nil
+	}
+	connection.leaderLocks[name] = true
+	return &inmemoryLeaderLock{connection: connection, name: name}, nil
+}
+
+func (lock *inmemoryLeaderLock) IsHeld() bool {
+	lock.connection.leaderLocksMutex.Lock()
+	defer lock.connection.leaderLocksMutex.Unlock()
+	return lock.connection.leaderLocks[lock.name]
+}
+
+func (lock *inmemoryLeaderLock) Release() {
+	lock.once.Do(func() {
+		lock.connection.leaderLocksMutex.Lock()
+		defer lock.connection.leaderLocksMutex.Unlock()
+		delete(lock.connection.leaderLocks, lock.name)
+	})
+}
+
+func (tx *inmemoryTransaction) TryTransactionLock(name string) (bool, error) {
+	for _, locked := range tx.transactionLocks {
+		if locked == name {
+			return true, nil
+		}
+	}
+
+	tx.connection.leaderLocksMutex.Lock()
+	defer tx.connection.leaderLocksMutex.Unlock()
+	if tx.connection.transactionLocks[name] {
+		return false, nil
+	}
+	tx.connection.transactionLocks[name] = true
+	tx.transactionLocks = append(tx.transactionLocks, name)
+	return true, nil
+}
+
+func (tx *inmemoryTransaction) unlockTransactionLocks() {
+	tx.connection.leaderLocksMutex.Lock()
+	defer tx.connection.leaderLocksMutex.Unlock()
+	for _, name := range tx.transactionLocks {
+		delete(tx.connection.transactionLocks, name)
+	}
+	tx.transactionLocks = nil
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (tx *inmemoryTransaction) CreateLobby(lobby *Lobby) error {
+	found, err := tx.GetLobbyById(lobby.ID)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrLobbyAlreadyExists
+	}
+	lobby.Version = 1
+	tx.lobbies[lobby.ID] = copyLobby(lobby)
+	tx.createdLobbies[lobby.ID] = true
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdateLobby(lobby *Lobby) error {
+	found, err := tx.GetLobbyById(lobby.ID)
+	if err != nil {
+		return err
+	}
+	if found == nil || found.Version != lobby.Version {
+		return fmt.Errorf("lobby [%v] with version %d not found: %w", lobby.ID, lobby.Version, ErrVersionConflict)
+	}
+	tx.lockVersion(lobby.ID, lobby.Version)
+	lobby.Version++
+	tx.lobbies[lobby.ID] = copyLobby(lobby)
+	return nil
+}
+
+func (tx *inmemoryTransaction) IncrementLobbyVersion(id uuid.UUID) error {
+	found, err := tx.GetLobbyById(id)
+	if err != nil || found == nil {
+		return err
+	}
+	tx.lockVersion(id, found.Version)
+	found.Version++
+	tx.lobbies[id] = found
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteLobby(id uuid.UUID) error {
+	for _, player := range tx.allPlayers() {
+		if player.LobbyId == id {
+			return fmt.Errorf("unknown error when deliting lobby: player [%v] still references lobby [%v]", player.ID, id)
+		}
+	}
+	tx.deleteLobbyBans(id)
+	tx.deleteLobbyInvites(id)
+	tx.deleteReconnectTokens(id)
+	tx.lobbies[id] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetLobbyById(id uuid.UUID) (*Lobby, error) {
+	if lobby, ok := tx.lobbies[id]; ok {
+		return copyLobby(lobby), nil
+	}
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+	return copyLobby(tx.connection.lobbies[id]), nil
+}
+
+func (tx *inmemoryTransaction) GetLobbyByIdForUpdate(id uuid.UUID) (*Lobby, error) {
+	tx.lockLobby(id)
+	return tx.GetLobbyById(id)
+}
+
+func (tx *inmemoryTransaction) GetLobbies(query *LobbyQuery) ([]*Lobby, *LobbyCursor, error) {
+	if _, err := getLobbySort(query.Sort, ""); err != nil {
+		return nil, nil, err
+	}
+
+	players := tx.allPlayers()
+	tokens := tx.allReconnectTokens()
+	freeSlots := func(lobby *Lobby) int {
+		slots := lobby.MaxPlayers
+		for _, player := range players {
+			if player.LobbyId == lobby.ID && !player.Spectator {
+				slots--
+			}
+		}
+		for _, token := range tokens {
+			if token.LobbyId == lobby.ID && token.SeatHeldUntil != nil && token.SeatHeldUntil.After(query.Now) {
+				slots--
+			}
+		}
+		return slots
+	}
+	sortKey := func(lobby *Lobby) inmemoryLobbySortKey {
+		switch query.Sort {
+		case LobbySortName:
+			return inmemoryLobbySortKey{text: lobby.Name, id: lobby.ID}
+		case LobbySortDifficulty:
+			return inmemoryLobbySortKey{number: int64(lobby.Difficulty), id: lobby.ID}
+		case LobbySortFreeSlots:
+			return inmemoryLobbySortKey{number: int64(freeSlots(lobby)), id: lobby.ID}
+		default:
+			return inmemoryLobbySortKey{number: lobby.CreatedAt.UnixNano(), id: lobby.ID}
+		}
+	}
+
+	var cursorKey *inmemoryLobbySortKey
+	if query.Cursor != nil {
+		if err := ValidateLobbyCursor(query.Sort, query.Cursor); err != nil {
+			return nil, nil, err
+		}
+		key := inmemoryLobbySortKey{text: query.Cursor.Value, id: query.Cursor.ID}
+		switch query.Sort {
+		case LobbySortName:
+		case LobbySortDifficulty, LobbySortFreeSlots:
+			number, _ := strconv.ParseInt(query.Cursor.Value, 10, 64)
+			key = inmemoryLobbySortKey{number: number, id: query.Cursor.ID}
+		default:
+			createdAt, _ := time.Parse(lobby_cursor_time_layout, query.Cursor.Value)
+			key = inmemoryLobbySortKey{number: createdAt.UnixNano(), id: query.Cursor.ID}
+		}
+		cursorKey = &key
+	}
+
+	lobbies := make([]*Lobby, 0)
+	for _, lobby := range tx.allLobbies() {
+		if !matchesLobbyQuery(lobby, query, freeSlots(lobby)) {
+			continue
+		}
+		if cursorKey != nil && !cursorKey.before(sortKey(lobby), query.Descending) {
+			continue
+		}
+		lobbies = append(lobbies, lobby)
+	}
+	sort.Slice(lobbies, func(i, j int) bool { return sortKey(lobbies[i]).before(sortKey(lobbies[j]), query.Descending) })
+
+	var next *LobbyCursor
+	if query.Limit > 0 && len(lobbies) > query.Limit {
+		lobbies = lobbies[:query.Limit]
+		last := sortKey(lobbies[len(lobbies)-1])
+		next = &LobbyCursor{Value: last.value(query.Sort), ID: last.id}
+	}
+	return lobbies, next, nil
+}
+
+type inmemoryLobbySortKey struct {
+	number int64
+	text   string
+	id     uuid.UUID
+}
+
+// before reports whether the key comes before the other key in the requested order. The id breaks ties like in postgres
+func (key inmemoryLobbySortKey) before(other inmemoryLobbySortKey, descending bool) bool {
+	compare := 0
+	switch {
+	case key.number != other.number:
+		compare = compareInt64(key.number, other.number)
+	case key.text != other.text:
+		compare = strings.Compare(key.text, other.text)
+	default:
+		compare = strings.Compare(key.id.String(), other.id.String())
+	}
+	if descending {
+		return compare > 0
+	}
+	return compare < 0
+}
+
+func (key inmemoryLobbySortKey) value(sort string) string {
+	switch sort {
+	case LobbySortName:
+		return key.text
+	case LobbySortDifficulty, LobbySortFreeSlots:
+		return strconv.FormatInt(key.number, 10)
+	default:
+		return time.Unix(0, key.number).UTC().Format(lobby_cursor_time_layout)
+	}
+}
+
+func compareInt64(a int64, b int64) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+func matchesLobbyQuery(lobby *Lobby, query *LobbyQuery, freeSlots int) bool {
+	if query.Visibility != "" && lobby.Visibility != query.Visibility {
+		return false
+	}
+	if query.Status != "" && lobby.Status != query.Status {
+		return false
+	}
+	if query.Difficulty != 0 && lobby.Difficulty != query.Difficulty {
+		return false
+	}
+	if query.MissionLength != 0 && lobby.MissionLength != query.MissionLength {
+		return false
+	}
+	for _, expansionPack := range query.ExpansionPacks {
+		found := false
+		for _, lobbyExpansionPack := range lobby.ExpansionPacks {
+			found = found || lobbyExpansionPack == expansionPack
+		}
+		if !found {
+			return false
+		}
+	}
+	if query.FreeSlots > 0 && freeSlots < query.FreeSlots {
+		return false
+	}
+	if query.PasswordProtected != nil && *query.PasswordProtected != (lobby.Password != "") {
+		return false
+	}
+	return true
+}
+
+func (tx *inmemoryTransaction) allLobbies() []*Lobby {
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+
+	lobbies := make([]*Lobby, 0, len(tx.connection.lobbies))
+	for id, lobby := range tx.connection.lobbies {
+		if _, ok := tx.lobbies[id]; !ok {
+			lobbies = append(lobbies, copyLobby(lobby))
+		}
+	}
+	for _, lobby := range tx.lobbies {
+		if lobby != nil {
+			lobbies = append(lobbies, copyLobby(lobby))
+		}
+	}
+	return lobbies
+}
+
+func copyLobby(lobby *Lobby) *Lobby {
+	if lobby == nil {
+		return nil
+	}
+	copiedLobby := *lobby
+	copiedLobby.Payload = copyPayload(lobby.Payload)
+	if lobby.ExpansionPacks != nil {
+		copiedLobby.ExpansionPacks = append([]string{}, lobby.ExpansionPacks...)
+	}
+	if lobby.AfkPolicies != nil {
+		copiedLobby.AfkPolicies = make(map[string]*AfkPolicy, len(lobby.AfkPolicies))
+		for status, policy := range lobby.AfkPolicies {
+			copiedPolicy := *policy
+			copiedLobby.AfkPolicies[status] = &copiedPolicy
+		}
+	}
+	return &copiedLobby
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (tx *inmemoryTransaction) CreateOutboxMessage(message *OutboxMessage) error {
+	tx.connection.mutex.RLock()
+	message.ID = tx.connection.nextOutboxId + int64(len(tx.createdOutbox)) + 1
+	tx.connection.mutex.RUnlock()
+
+	tx.createdOutbox = append(tx.createdOutbox, copyOutboxMessage(message))
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdateOutboxMessage(message *OutboxMessage) error {
+	tx.outbox[message.ID] = copyOutboxMessage(message)
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteOutboxMessage(id int64) error {
+	tx.outbox[id] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetDueOutboxMessages(now time.Time, limit int) ([]*OutboxMessage, error) {
+	seenLobbies := make(map[uuid.UUID]bool)
+	messages := make([]*OutboxMessage, 0)
+	for _, message := range tx.allOutboxMessages() {
+		if message.DeadLetter || seenLobbies[message.LobbyId] {
+			continue
+		}
+		seenLobbies[message.LobbyId] = true
+		if !message.NextAttempt.After(now) && len(messages) < limit {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (tx *inmemoryTransaction) GetOutboxMessages() ([]*OutboxMessage, error) {
+	return tx.allOutboxMessages(), nil
+}
+
+func (tx *inmemoryTransaction) GetFailedOutboxMessages() ([]*OutboxMessage, error) {
+	messages := make([]*OutboxMessage, 0)
+	for _, message := range tx.allOutboxMessages() {
+		if message.DeadLetter {
+			messages = append(messages, message)
+		}
+	}
+	return messages, nil
+}
+
+func (tx *inmemoryTransaction) allOutboxMessages() []*OutboxMessage {
+	tx.connection.mutex.RLock()
+	messages := make([]*OutboxMessage, 0, len(tx.connection.outbox))
+	for id, message := range tx.connection.outbox {
+		if _, ok := tx.outbox[id]; !ok {
+			messages = append(messages, copyOutboxMessage(message))
+		}
+	}
+	tx.connection.mutex.RUnlock()
+
+	for _, message := range tx.outbox {
+		if message != nil {
+			messages = append(messages, copyOutboxMessage(message))
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].ID < messages[j].ID })
+	for _, message := range tx.createdOutbox {
+		messages = append(messages, copyOutboxMessage(message))
+	}
+	return messages
+}
+
+func copyOutboxMessage(message *OutboxMessage) *OutboxMessage {
+	if message == nil {
+		return nil
+	}
+	copiedMessage := *message
+	copiedMessage.Payload = copyPayload(message.Payload)
+	return &copiedMessage
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (tx *inmemoryTransaction) CreatePlayer(player *Player) error {
+	found, err := tx.GetPlayerById(player.ID)
+	if err != nil {
+		return err
+	}
+	if found != nil {
+		return ErrPlayerAlreadyExists
+	}
+	lobby, err := tx.GetLobbyById(player.LobbyId)
+	if err != nil {
+		return err
+	}
+	if lobby == nil {
+		return fmt.Errorf("unknown error when inserting player: lobby [%v] does not exist", player.LobbyId)
+	}
+	player.Version = 1
+	tx.players[player.ID] = copyPlayer(player)
+	tx.createdPlayers[player.ID] = true
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdatePlayer(player *Player) error {
+	found, err := tx.GetPlayerById(player.ID)
+	if err != nil {
+		return err
+	}
+	if found == nil || found.Version != player.Version {
+		return fmt.Errorf("player [%v] with version %d not found: %w", player.ID, player.Version, ErrVersionConflict)
+	}
+	tx.lockVersion(player.ID, player.Version)
+	player.Version++
+	tx.players[player.ID] = copyPlayer(player)
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdatePlayerLastRefresh(playerId uuid.UUID, lastRefresh time.Time) error {
+	player, err := tx.GetPlayerById(playerId)
+	if err != nil {
+		return err
+	}
+	if player == nil {
+		return nil
+	}
+	if _, ok := tx.players[playerId]; ok {
+		player.LastRefresh = lastRefresh
+		player.AfkNotice = ""
+		tx.players[playerId] = player
+		return nil
+	}
+	// like the update in postgres only the column is changed, so concurrent changes of the player are kept
+	tx.lastRefreshes[playerId] = lastRefresh
+	delete(tx.afkNotices, playerId)
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdatePlayersLastRefresh(lastRefreshes map[uuid.UUID]time.Time) error {
+	for playerId, lastRefresh := range lastRefreshes {
+		if err := tx.UpdatePlayerLastRefresh(playerId, lastRefresh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *inmemoryTransaction) UpdatePlayerAfkNotice(playerId uuid.UUID, lastRefresh time.Time, notice string) (bool, error) {
+	player, err := tx.GetPlayerById(playerId)
+	if err != nil {
+		return false, err
+	}
+	if player == nil || !player.LastRefresh.Equal(lastRefresh) {
+		return false, nil
+	}
+	if _, ok := tx.players[playerId]; ok {
+		player.AfkNotice = notice
+		tx.players[playerId] = player
+		return true, nil
+	}
+	tx.afkNotices[playerId] = &afkNotice{lastRefresh: lastRefresh, notice: notice}
+	return true, nil
+}
+
+func (tx *inmemoryTransaction) DeletePlayer(id uuid.UUID) error {
+	tx.players[id] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) ResetPlayersReady(lobbyId uuid.UUID) ([]uuid.UUID, error) {
+	playerIds := []uuid.UUID{}
+	for _, player := range tx.allPlayers() {
+		if player.LobbyId == lobbyId && player.Ready {
+			tx.lockVersion(player.ID, player.Version)
+			player.Ready = false
+			player.Version++
+			tx.players[player.ID] = player
+			playerIds = append(playerIds, player.ID)
+		}
+	}
+	return playerIds, nil
+}
+
+func (tx *inmemoryTransaction) DeleteAllPlayerInLobby(lobbyId uuid.UUID) error {
+	for _, player := range tx.allPlayers() {
+		if player.LobbyId == lobbyId {
+			tx.players[player.ID] = nil
+		}
+	}
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetPlayerById(id uuid.UUID) (*Player, error) {
+	if player, ok := tx.players[id]; ok {
+		return copyPlayer(player), nil
+	}
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+	return tx.withLastRefresh(copyPlayer(tx.connection.players[id])), nil
+}
+
+func (tx *inmemoryTransaction) GetAllPlayersInLobby(lobbyId uuid.UUID) ([]*Player, error) {
+	players := make([]*Player, 0)
+	for _, player := range tx.allPlayers() {
+		if player.LobbyId == lobbyId {
+			players = append(players, player)
+		}
+	}
+	return players, nil
+}
+
+func (tx *inmemoryTransaction) GetAllPlayersInLobbies(lobbyIds []uuid.UUID) ([]*Player, error) {
+	ids := make(map[uuid.UUID]bool, len(lobbyIds))
+	for _, lobbyId := range lobbyIds {
+		ids[lobbyId] = true
+	}
+	players := make([]*Player, 0)
+	for _, player := range tx.allPlayers() {
+		if ids[player.LobbyId] {
+			players = append(players, player)
+		}
+	}
+	return players, nil
+}
+
+func (tx *inmemoryTransaction) GetPlayersLastRefresh(lastRefresh time.Time) ([]*Player, error) {
+	players := make([]*Player, 0)
+	for _, player := range tx.allPlayers() {
+		if player.LastRefresh.Before(lastRefresh) {
+			players = append(players, player)
+		}
+	}
+	return players, nil
+}
+
+func (tx *inmemoryTransaction) GetNumberOfPlayersInLobby(lobbyId uuid.UUID) (int, error) {
+	count := 0
+	for _, player := range tx.allPlayers() {
+		if player.LobbyId == lobbyId && !player.Spectator {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (tx *inmemoryTransaction) allPlayers() []*Player {
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+
+	players := make([]*Player, 0, len(tx.connection.players))
+	for id, player := range tx.connection.players {
+		if _, ok := tx.players[id]; !ok {
+			players = append(players, tx.withLastRefresh(copyPlayer(player)))
+		}
+	}
+	for _, player := range tx.players {
+		if player != nil {
+			players = append(players, copyPlayer(player))
+		}
+	}
+	return players
+}
+
+func (tx *inmemoryTransaction) withLastRefresh(player *Player) *Player {
+	if player == nil {
+		return nil
+	}
+	if lastRefresh, ok := tx.lastRefreshes[player.ID]; ok {
+		player.LastRefresh = lastRefresh
+		player.AfkNotice = ""
+	}
+	if notice, ok := tx.afkNotices[player.ID]; ok {
+		player.AfkNotice = notice.notice
+	}
+	return player
+}
+
+func copyPlayer(player *Player) *Player {
+	if player == nil {
+		return nil
+	}
+	copiedPlayer := *player
+	copiedPlayer.Payload = copyPayload(player.Payload)
+	return &copiedPlayer
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (tx *inmemoryTransaction) UpsertReconnectToken(token *ReconnectToken) error {
+	lobby, err := tx.GetLobbyById(token.LobbyId)
+	if err != nil {
+		return err
+	}
+	if lobby == nil {
+		return fmt.Errorf("unknown error when storing reconnect token: lobby [%v] does not exist", token.LobbyId)
+	}
+	tx.reconnectTokens[token.PlayerId] = copyReconnectToken(token)
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteReconnectToken(playerId uuid.UUID) error {
+	tx.reconnectTokens[playerId] = nil
+	return nil
+}
+
+func (tx *inmemoryTransaction) DeleteReconnectTokensBefore(expiresAt time.Time) error {
+	for _, token := range tx.allReconnectTokens() {
+		if token.ExpiresAt != nil && token.ExpiresAt.Before(expiresAt) {
+			tx.reconnectTokens[token.PlayerId] = nil
+		}
+	}
+	return nil
+}
+
+func (tx *inmemoryTransaction) GetReconnectToken(playerId uuid.UUID) (*ReconnectToken, error) {
+	if token, ok := tx.reconnectTokens[playerId]; ok {
+		return copyReconnectToken(token), nil
+	}
+	tx.connection.mutex.RLock()
+	defer tx.connection.mutex.RUnlock()
+	return copyReconnectToken(tx.connection.reconnectTokens[playerId]), nil
+}
+
+func (tx *inmemoryTransaction) GetNumberOfHeldSeats(lobbyId uuid.UUID, now time.Time) (int, error) {
+	count := 0
+	for _, token := range tx.allReconnectTokens() {
+		if token.LobbyId == lobbyId && token.SeatHeldUntil != nil && token.SeatHeldUntil.After(now) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// deleteReconnectTokens removes the tokens together with their lobby, like the cascade of the foreign key in postgres
+func (tx *inmemoryTransaction) deleteReconnectTokens(lobbyId uuid.UUID) {
+	for _, token := range tx.allReconnectTokens() {
+		if token.LobbyId == lobbyId {
+			tx.reconnectTokens[token.PlayerId] = nil
+		}
+	}
+}
+
+func (tx *inmemoryTransaction) allReconnectTokens() []*ReconnectToken {
+	tx.connection.mutex.RLock()
+	tokens := make([]*ReconnectToken, 0, len(tx.connection.reconnectTokens))
+	for playerId, token := range tx.connection.reconnectTokens {
+		if _, ok := tx.reconnectTokens[playerId]; !ok {
+			tokens = append(tokens, copyReconnectToken(token))
+		}
+	}
+	tx.connection.mutex.RUnlock()
+
+	for _, token := range tx.reconnectTokens {
+		if token != nil {
+			tokens = append(tokens, copyReconnectToken(token))
+		}
+	}
+	return tokens
+}
+
+func copyReconnectToken(token *ReconnectToken) *ReconnectToken {
+	if token == nil {
+		return nil
+	}
+	copiedToken := *token
+	copiedToken.Payload = copyPayload(token.Payload)
+	return &copiedToken
+}
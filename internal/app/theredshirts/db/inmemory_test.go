@@ -0,0 +1,447 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestConnection(t *testing.T) DB {
+	t.Setenv("DATABASE", "inmemory")
+	connection, err := NewConnection()
+	if err != nil {
+		t.Fatalf("error while creating connection: %v", err)
+	}
+	return connection
+}
+
+func startTestTransaction(t *testing.T, connection DB) DBTx {
+	tx, err := connection.StartTransaction()
+	if err != nil {
+		t.Fatalf("error while starting transaction: %v", err)
+	}
+	return tx
+}
+
+func createTestLobby(t *testing.T, connection DB) *Lobby {
+	lobby := &Lobby{ID: uuid.New(), Name: "Some Lobby", Owner: uuid.New(), MaxPlayers: 4}
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreateLobby(lobby))
+	assert.Nil(t, tx.Commit())
+	return lobby
+}
+
+func TestNewConnection_Unknown(t *testing.T) {
+	t.Setenv("DATABASE", "unknown")
+	connection, err := NewConnection()
+	assert.Nil(t, connection)
+	assert.ErrorContains(t, err, "no configuration for unknown found")
+}
+
+func TestMigrate_Inmemory(t *testing.T) {
+	t.Setenv("DATABASE", "inmemory")
+	assert.Nil(t, Migrate())
+}
+
+func TestMigrate_Unknown(t *testing.T) {
+	t.Setenv("DATABASE", "unknown")
+	assert.ErrorContains(t, Migrate(), "no configuration for unknown found")
+}
+
+func TestInmemory_CommitMakesWritesVisible(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	foundLobby, err := tx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby, foundLobby)
+}
+
+func TestInmemory_UncommittedWritesAreIsolated(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	writeTx := startTestTransaction(t, connection)
+	player := &Player{ID: uuid.New(), Name: "Some Player", LobbyId: lobby.ID, LastRefresh: time.Now()}
+	assert.Nil(t, writeTx.CreatePlayer(player))
+
+	ownPlayer, err := writeTx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, ownPlayer.ID)
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	otherPlayer, err := readTx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, otherPlayer)
+	count, err := readTx.GetNumberOfPlayersInLobby(lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.Nil(t, writeTx.Commit())
+	committedPlayer, err := readTx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, committedPlayer.ID)
+}
+
+func TestInmemory_RollbackDiscardsWrites(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.DeleteLobby(lobby.ID))
+	assert.Nil(t, tx.Rollback())
+	assert.ErrorIs(t, tx.Commit(), ErrTransactionClosed)
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	foundLobby, err := readTx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	assert.NotNil(t, foundLobby)
+}
+
+func TestInmemory_ReturnedRowsAreCopies(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	foundLobby, err := tx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	foundLobby.Name = "Changed"
+
+	reloadedLobby, err := tx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, lobby.Name, reloadedLobby.Name)
+}
+
+func TestInmemory_ReturnedPayloadsAreCopies(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := &Lobby{ID: uuid.New(), Name: "Some Lobby", Owner: uuid.New(), MaxPlayers: 4, Payload: map[string]interface{}{"ship": map[string]interface{}{"name": "Enterprise"}, "decks": []interface{}{"bridge"}}}
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreateLobby(lobby))
+	player := &Player{ID: uuid.New(), Name: "Kirk", LobbyId: lobby.ID, Payload: map[string]interface{}{"rank": "captain"}}
+	assert.Nil(t, tx.CreatePlayer(player))
+	assert.Nil(t, tx.Commit())
+	lobby.Payload["ship"].(map[string]interface{})["name"] = "Changed"
+	player.Payload["rank"] = "Changed"
+
+	tx = startTestTransaction(t, connection)
+	defer tx.Rollback()
+	foundLobby, err := tx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	foundLobby.Payload["ship"].(map[string]interface{})["name"] = "Changed"
+	foundLobby.Payload["decks"].([]interface{})[0] = "Changed"
+	foundPlayer, err := tx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	foundPlayer.Payload["rank"] = "Changed"
+
+	reloadedLobby, err := tx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"ship": map[string]interface{}{"name": "Enterprise"}, "decks": []interface{}{"bridge"}}, reloadedLobby.Payload)
+	reloadedPlayer, err := tx.GetPlayerById(player.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "captain", reloadedPlayer.Payload["rank"])
+}
+
+func TestInmemory_LobbyAlreadyExists(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	assert.ErrorIs(t, tx.CreateLobby(lobby), ErrLobbyAlreadyExists)
+}
+
+func TestInmemory_ConcurrentCreateConflictsOnCommit(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+	player := &Player{ID: uuid.New(), Name: "Some Player", LobbyId: lobby.ID, LastRefresh: time.Now()}
+
+	firstTx := startTestTransaction(t, connection)
+	secondTx := startTestTransaction(t, connection)
+	assert.Nil(t, firstTx.CreatePlayer(player))
+	assert.Nil(t, secondTx.CreatePlayer(player))
+
+	assert.Nil(t, firstTx.Commit())
+	assert.ErrorIs(t, secondTx.Commit(), ErrPlayerAlreadyExists)
+}
+
+func TestInmemory_ConcurrentUpdateConflictsOnCommit(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	firstTx := startTestTransaction(t, connection)
+	secondTx := startTestTransaction(t, connection)
+	firstLobby, err := firstTx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	secondLobby, err := secondTx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+
+	firstLobby.Name = "First Lobby"
+	secondLobby.Name = "Second Lobby"
+	assert.Nil(t, firstTx.UpdateLobby(firstLobby))
+	assert.Nil(t, secondTx.UpdateLobby(secondLobby))
+	assert.Equal(t, 2, firstLobby.Version)
+
+	assert.Nil(t, firstTx.Commit())
+	assert.ErrorIs(t, secondTx.Commit(), ErrVersionConflict)
+
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	assert.ErrorIs(t, tx.UpdateLobby(lobby), ErrVersionConflict)
+}
+
+func TestInmemory_PlayerNeedsLobby(t *testing.T) {
+	connection := newTestConnection(t)
+
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	err := tx.CreatePlayer(&Player{ID: uuid.New(), Name: "Some Player", LobbyId: uuid.New(), LastRefresh: time.Now()})
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+func TestInmemory_LobbyWithPlayersCantBeDeleted(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	assert.Nil(t, tx.CreatePlayer(&Player{ID: uuid.New(), Name: "Some Player", LobbyId: lobby.ID, LastRefresh: time.Now()}))
+	assert.ErrorContains(t, tx.DeleteLobby(lobby.ID), "still references lobby")
+}
+
+func TestInmemory_DueOutboxMessagesKeepLobbyOrder(t *testing.T) {
+	connection := newTestConnection(t)
+	now := time.Now()
+	lobbyId := uuid.New()
+	otherLobbyId := uuid.New()
+
+	tx := startTestTransaction(t, connection)
+	first := &OutboxMessage{LobbyId: lobbyId, Topic: "FIRST", NextAttempt: now.Add(time.Minute)}
+	second := &OutboxMessage{LobbyId: lobbyId, Topic: "SECOND", NextAttempt: now}
+	other := &OutboxMessage{LobbyId: otherLobbyId, Topic: "OTHER", NextAttempt: now}
+	for _, message := range []*OutboxMessage{first, second, other} {
+		assert.Nil(t, tx.CreateOutboxMessage(message))
+	}
+	assert.Nil(t, tx.Commit())
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	messages, err := readTx.GetDueOutboxMessages(now, 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, other.ID, messages[0].ID)
+}
+
+func TestInmemory_DueOutboxMessagesSkipDeadLetters(t *testing.T) {
+	connection := newTestConnection(t)
+	now := time.Now()
+	lobbyId := uuid.New()
+
+	tx := startTestTransaction(t, connection)
+	deadLetter := &OutboxMessage{LobbyId: lobbyId, Topic: "DEAD", NextAttempt: now}
+	assert.Nil(t, tx.CreateOutboxMessage(deadLetter))
+	assert.Nil(t, tx.CreateOutboxMessage(&OutboxMessage{LobbyId: lobbyId, Topic: "NEXT", NextAttempt: now}))
+	assert.Nil(t, tx.Commit())
+
+	tx = startTestTransaction(t, connection)
+	deadLetter.DeadLetter = true
+	assert.Nil(t, tx.UpdateOutboxMessage(deadLetter))
+	assert.Nil(t, tx.Commit())
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	messages, err := readTx.GetDueOutboxMessages(now, 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "NEXT", messages[0].Topic)
+	failed, err := readTx.GetFailedOutboxMessages()
+	assert.Nil(t, err)
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "DEAD", failed[0].Topic)
+}
+
+func TestInmemory_OutboxIdsFollowCommitOrder(t *testing.T) {
+	connection := newTestConnection(t)
+	now := time.Now()
+	lobbyId := uuid.New()
+
+	firstTx := startTestTransaction(t, connection)
+	assert.Nil(t, firstTx.CreateOutboxMessage(&OutboxMessage{LobbyId: lobbyId, Topic: "COMMITTED_SECOND", NextAttempt: now}))
+	secondTx := startTestTransaction(t, connection)
+	assert.Nil(t, secondTx.CreateOutboxMessage(&OutboxMessage{LobbyId: lobbyId, Topic: "COMMITTED_FIRST", NextAttempt: now}))
+	assert.Nil(t, secondTx.Commit())
+	assert.Nil(t, firstTx.Commit())
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	messages, err := readTx.GetDueOutboxMessages(now, 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "COMMITTED_FIRST", messages[0].Topic)
+}
+
+func TestInmemory_LobbyBansAreDeletedWithLobby(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+	ban := &LobbyBan{LobbyId: lobby.ID, PlayerId: uuid.New(), Reason: "cheating", CreatedAt: time.Now()}
+
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreateLobbyBan(ban))
+	assert.Nil(t, tx.Commit())
+
+	tx = startTestTransaction(t, connection)
+	foundBan, err := tx.GetLobbyBan(lobby.ID, ban.PlayerId)
+	assert.Nil(t, err)
+	assert.Equal(t, ban.Reason, foundBan.Reason)
+	assert.Nil(t, tx.DeleteLobby(lobby.ID))
+	assert.Nil(t, tx.Commit())
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	bans, err := readTx.GetLobbyBans(lobby.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, bans)
+}
+
+func TestInmemory_EventSequenceFollowsCommitOrder(t *testing.T) {
+	connection := newTestConnection(t)
+	lobbyId := uuid.New()
+
+	firstTx := startTestTransaction(t, connection)
+	assert.Nil(t, firstTx.CreateLobbyEvent(&LobbyEvent{LobbyId: lobbyId, Topic: "FIRST"}))
+	secondTx := startTestTransaction(t, connection)
+	assert.Nil(t, secondTx.CreateLobbyEvent(&LobbyEvent{LobbyId: lobbyId, Topic: "SECOND"}))
+	assert.Nil(t, secondTx.Commit())
+
+	readTx := startTestTransaction(t, connection)
+	events, err := readTx.GetLobbyEventsAfter(lobbyId, 0, 10)
+	assert.Nil(t, err)
+	assert.Nil(t, readTx.Commit())
+	assert.Len(t, events, 1)
+	assert.Equal(t, "SECOND", events[0].Topic)
+	assert.Equal(t, int64(1), events[0].Sequence)
+
+	assert.Nil(t, firstTx.Commit())
+
+	readTx = startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	events, err = readTx.GetLobbyEventsAfter(lobbyId, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "FIRST", events[0].Topic)
+	assert.Equal(t, int64(2), events[0].Sequence)
+}
+
+func TestInmemory_AuditSequenceFollowsCommitOrder(t *testing.T) {
+	connection := newTestConnection(t)
+	lobbyId := uuid.New()
+
+	firstTx := startTestTransaction(t, connection)
+	assert.Nil(t, firstTx.CreateLobbyAuditEntry(&LobbyAuditEntry{LobbyId: lobbyId, Topic: "FIRST"}))
+	secondTx := startTestTransaction(t, connection)
+	assert.Nil(t, secondTx.CreateLobbyAuditEntry(&LobbyAuditEntry{LobbyId: lobbyId, Topic: "SECOND"}))
+	assert.Nil(t, secondTx.CreateLobbyAuditEntry(&LobbyAuditEntry{LobbyId: uuid.New(), Topic: "OTHER"}))
+	assert.Nil(t, secondTx.Commit())
+	assert.Nil(t, firstTx.Commit())
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	entries, err := readTx.GetLobbyAuditEntriesAfter(lobbyId, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "FIRST", entries[0].Topic)
+	assert.Equal(t, int64(2), entries[0].Sequence)
+}
+
+func TestInmemory_UseInviteConcurrently(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreateInvite(&Invite{Code: "ABCDEF", LobbyId: lobby.ID, CreatedAt: time.Now(), MaxUses: 1}))
+	assert.Nil(t, tx.Commit())
+
+	firstTx := startTestTransaction(t, connection)
+	used, err := firstTx.UseInvite("ABCDEF")
+	assert.Nil(t, err)
+	assert.True(t, used)
+
+	secondUsed := make(chan bool)
+	secondTx := startTestTransaction(t, connection)
+	defer secondTx.Rollback()
+	go func() {
+		used, err := secondTx.UseInvite("ABCDEF")
+		assert.Nil(t, err)
+		secondUsed <- used
+	}()
+
+	assert.Nil(t, firstTx.Commit())
+	assert.False(t, <-secondUsed)
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	invite, err := readTx.GetInviteByCode("ABCDEF")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, invite.Uses)
+}
+
+func TestInmemory_GetLobbyByIdForUpdate(t *testing.T) {
+	testGetLobbyByIdForUpdate(t, newTestConnection(t))
+}
+
+func TestInmemory_UpdatePlayerAfkNotice(t *testing.T) {
+	testUpdatePlayerAfkNotice(t, newTestConnection(t))
+}
+
+func TestInmemory_RefreshWinsOverConcurrentAfkNotice(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+	playerId := uuid.New()
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreatePlayer(&Player{ID: playerId, Name: "Player", LobbyId: lobby.ID, LastRefresh: time.Now().Add(-time.Minute)}))
+	assert.Nil(t, tx.Commit())
+	player := getTestPlayer(t, connection, playerId)
+
+	noticeTx := startTestTransaction(t, connection)
+	noticed, err := noticeTx.UpdatePlayerAfkNotice(playerId, player.LastRefresh, "PLAYER_LAGGING")
+	assert.Nil(t, err)
+	assert.True(t, noticed)
+	refreshTx := startTestTransaction(t, connection)
+	assert.Nil(t, refreshTx.UpdatePlayerLastRefresh(playerId, time.Now()))
+	assert.Nil(t, refreshTx.Commit())
+	assert.Nil(t, noticeTx.Commit())
+
+	assert.Empty(t, getTestPlayer(t, connection, playerId).AfkNotice)
+}
+
+func TestInmemory_TryLeaderLock(t *testing.T) {
+	connection := newTestConnection(t)
+	testTryLeaderLock(t, connection, connection)
+}
+
+func TestInmemory_TryTransactionLock(t *testing.T) {
+	testTryTransactionLock(t, newTestConnection(t))
+}
+
+func TestInmemory_LobbyLocksAreRemoved(t *testing.T) {
+	connection := newTestConnection(t)
+	lobby := createTestLobby(t, connection)
+
+	tx := startTestTransaction(t, connection)
+	_, err := tx.GetLobbyByIdForUpdate(lobby.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.DeleteLobby(lobby.ID))
+	assert.Nil(t, tx.Commit())
+
+	tx = startTestTransaction(t, connection)
+	_, err = tx.GetLobbyByIdForUpdate(uuid.New())
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Rollback())
+
+	assert.Empty(t, connection.(*inmemoryConnection).lobbyLocks)
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+)
+
+const (
+	invite_table_name          = "lobby_invite"
+	create_invite_sql          = "INSERT INTO %s.%s(code, lobby_id, created_at, expires_at, max_uses, uses) VALUES($1, $2, $3, $4, $5, $6)"
+	use_invite_sql             = "UPDATE %s.%s SET uses = uses + 1 WHERE code = $1 AND (max_uses = 0 OR uses < max_uses)"
+	delete_invite_sql          = "DELETE FROM %s.%s WHERE code = $1"
+	select_invite_by_code_sql  = "SELECT code, lobby_id, created_at, expires_at, max_uses, uses FROM %s.%s WHERE code = $1"
+	select_invite_by_lobby_sql = "SELECT code, lobby_id, created_at, expires_at, max_uses, uses FROM %s.%s WHERE lobby_id = $1 ORDER BY created_at"
+)
+
+var (
+	ErrInviteAlreadyExists = errors.New("invite already exists")
+)
+
+func (tx *postgresTransaction) CreateInvite(invite *Invite) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_invite_sql, schema_name, invite_table_name), invite.Code, invite.LobbyId, invite.CreatedAt, invite.ExpiresAt, invite.MaxUses, invite.Uses); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case pgerrcode.UniqueViolation:
+				return ErrInviteAlreadyExists
+			}
+		}
+
+		return fmt.Errorf("unknown error when inserting invite: %v", err)
+	}
+	return nil
+}
+
+// UseInvite counts a use of the invite. It returns false if the invite doesn't exist or has no uses left
+func (tx *postgresTransaction) UseInvite(code string) (bool, error) {
+	result, err := tx.tx.Exec(context.Background(), fmt.Sprintf(use_invite_sql, schema_name, invite_table_name), code)
+	if err != nil {
+		return false, fmt.Errorf("unknown error when using invite: %v", err)
+	}
+	return result.RowsAffected() == 1, nil
+}
+
+func (tx *postgresTransaction) DeleteInvite(code string) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_invite_sql, schema_name, invite_table_name), code); err != nil {
+		return fmt.Errorf("unknown error when deleting invite: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetInviteByCode(code string) (*Invite, error) {
+	var invites []*Invite
+	if err := pgxscan.Select(context.Background(), tx.tx, &invites, fmt.Sprintf(select_invite_by_code_sql, schema_name, invite_table_name), code); err != nil {
+		return nil, fmt.Errorf("error while selecting invite: %v", err)
+	}
+
+	if len(invites) == 0 {
+		return nil, nil
+	}
+	return invites[0], nil
+}
+
+func (tx *postgresTransaction) GetInvitesInLobby(lobbyId uuid.UUID) ([]*Invite, error) {
+	var invites []*Invite
+	if err := pgxscan.Select(context.Background(), tx.tx, &invites, fmt.Sprintf(select_invite_by_lobby_sql, schema_name, invite_table_name), lobbyId); err != nil {
+		return nil, fmt.Errorf("error while selecting invites of lobby [%v]: %v", lobbyId, err)
+	}
+	return invites, nil
+}
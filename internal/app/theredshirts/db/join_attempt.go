@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	join_attempt_table_name = "join_attempt"
+	upsert_join_attempt_sql = "INSERT INTO %s.%s(lobby_id, player_id, failed_attempts, last_failed) VALUES($1, $2, $3, $4) ON CONFLICT (lobby_id, player_id) DO UPDATE SET failed_attempts = $3, last_failed = $4"
+	delete_join_attempt_sql = "DELETE FROM %s.%s WHERE lobby_id = $1 AND player_id = $2"
+	select_join_attempt_sql = "SELECT lobby_id, player_id, failed_attempts, last_failed FROM %s.%s WHERE lobby_id = $1 AND player_id = $2"
+)
+
+func (tx *postgresTransaction) UpsertJoinAttempt(attempt *JoinAttempt) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(upsert_join_attempt_sql, schema_name, join_attempt_table_name), attempt.LobbyId, attempt.PlayerId, attempt.FailedAttempts, attempt.LastFailed); err != nil {
+		return fmt.Errorf("unknown error when storing join attempt: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteJoinAttempt(lobbyId uuid.UUID, playerId uuid.UUID) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_join_attempt_sql, schema_name, join_attempt_table_name), lobbyId, playerId); err != nil {
+		return fmt.Errorf("unknown error when deleting join attempt: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetJoinAttempt(lobbyId uuid.UUID, playerId uuid.UUID) (*JoinAttempt, error) {
+	var attempts []*JoinAttempt
+	if err := pgxscan.Select(context.Background(), tx.tx, &attempts, fmt.Sprintf(select_join_attempt_sql, schema_name, join_attempt_table_name), lobbyId, playerId); err != nil {
+		return nil, fmt.Errorf("error while selecting join attempt of player [%v] in lobby [%v]: %v", playerId, lobbyId, err)
+	}
+
+	if len(attempts) == 0 {
+		return nil, nil
+	}
+	return attempts[0], nil
+}
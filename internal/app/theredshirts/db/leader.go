@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	try_advisory_lock_sql      = "SELECT pg_try_advisory_lock($1)"
+	try_advisory_xact_lock_sql = "SELECT pg_try_advisory_xact_lock($1)"
+	advisory_unlock_sql        = "SELECT pg_advisory_unlock($1)"
+)
+
+// postgresLeaderLock holds a session level advisory lock. It keeps its own connection, so the lock is released by postgres
+// as soon as the connection of a crashed instance is closed
+type postgresLeaderLock struct {
+	connection *postgresConnection
+	key        int64
+	mutex      sync.Mutex
+	conn       *pgxpool.Conn
+}
+
+func (connection *postgresConnection) TryLeaderLock(name string) (LeaderLock, error) {
+	conn, err := connection.dbPool.Acquire(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("unknown error while acquiring connection for leader lock: %v", err)
+	}
+
+	key := leaderLockKey(name)
+	var locked bool
+	if err := conn.QueryRow(context.Background(), try_advisory_lock_sql, key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("unknown error while trying leader lock %s: %v", name, err)
+	}
+
+	if !locked {
+		conn.Release()
+		return nil, nil
+	}
+	lock := &postgresLeaderLock{connection: connection, key: key, conn: conn}
+	connection.leaderLocksMutex.Lock()
+	connection.leaderLocks[lock] = true
+	connection.leaderLocksMutex.Unlock()
+	return lock, nil
+}
+
+func (lock *postgresLeaderLock) IsHeld() bool {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+	return lock.conn != nil && lock.conn.Conn().Ping(context.Background()) == nil
+}
+
+func (lock *postgresLeaderLock) Release() {
+	lock.connection.leaderLocksMutex.Lock()
+	delete(lock.connection.leaderLocks, lock)
+	lock.connection.leaderLocksMutex.Unlock()
+	lock.release()
+}
+
+func (lock *postgresLeaderLock) release() {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+	if lock.conn == nil {
+		return
+	}
+	lock.conn.Exec(context.Background(), advisory_unlock_sql, lock.key)
+	lock.conn.Release()
+	lock.conn = nil
+}
+
+func (tx *postgresTransaction) TryTransactionLock(name string) (bool, error) {
+	var locked bool
+	if err := tx.tx.QueryRow(context.Background(), try_advisory_xact_lock_sql, leaderLockKey(name)).Scan(&locked); err != nil {
+		return false, fmt.Errorf("unknown error while trying transaction lock %s: %v", name, err)
+	}
+	return locked, nil
+}
+
+// leaderLockKey maps the name of the lock to the number of the advisory lock
+func leaderLockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTryLeaderLock checks that only one of the connections holds the lock until it is released
+func testTryLeaderLock(t *testing.T, first DB, second DB) {
+	name := uuid.NewString()
+	lock, err := first.TryLeaderLock(name)
+	assert.Nil(t, err)
+	assert.NotNil(t, lock)
+	assert.True(t, lock.IsHeld())
+
+	otherLock, err := second.TryLeaderLock(name)
+	assert.Nil(t, err)
+	assert.Nil(t, otherLock)
+
+	lock.Release()
+	assert.False(t, lock.IsHeld())
+	otherLock, err = second.TryLeaderLock(name)
+	assert.Nil(t, err)
+	assert.NotNil(t, otherLock)
+	otherLock.Release()
+}
+
+// testTryTransactionLock checks that the lock is held until the transaction ends
+func testTryTransactionLock(t *testing.T, connection DB) {
+	name := uuid.NewString()
+	firstTx := startTestTransaction(t, connection)
+	locked, err := firstTx.TryTransactionLock(name)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+
+	secondTx := startTestTransaction(t, connection)
+	defer secondTx.Rollback()
+	locked, err = secondTx.TryTransactionLock(name)
+	assert.Nil(t, err)
+	assert.False(t, locked)
+
+	assert.Nil(t, firstTx.Commit())
+	locked, err = secondTx.TryTransactionLock(name)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/google/uuid"
@@ -12,20 +16,62 @@ import (
 )
 
 const (
-	lobby_table_name       = "lobby"
-	create_lobby_sql       = "INSERT INTO %s.%s(id, status, name, owner, password, difficulty, mission_length, number_of_crew_members, max_players, expansion_packs, payload) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"
-	update_lobby_sql       = "UPDATE %s.%s SET status = $2, name = $3, owner = $4, password = $5, difficulty = $6, mission_length = $7, number_of_crew_members = $8, max_players = $9, expansion_packs = $10, payload = $11 WHERE id = $1"
-	delete_lobby_sql       = "DELETE FROM %s.%s WHERE id = $1"
-	select_lobby_by_id_sql = "SELECT id, status, name, owner, password, difficulty, mission_length, number_of_crew_members, max_players, expansion_packs, payload FROM %s.%s WHERE id = $1"
-	select_lobby_sql       = "SELECT id, status, name, owner, password, difficulty, mission_length, number_of_crew_members, max_players, expansion_packs, payload FROM %s.%s"
+	lobby_table_name                  = "lobby"
+	create_lobby_sql                  = "INSERT INTO %s.%s(id, status, name, owner, password, difficulty, mission_length, number_of_crew_members, max_players, expansion_packs, payload, visibility, afk_policies, created_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)"
+	update_lobby_sql                  = "UPDATE %s.%s SET status = $2, name = $3, owner = $4, password = $5, difficulty = $6, mission_length = $7, number_of_crew_members = $8, max_players = $9, expansion_packs = $10, payload = $11, visibility = $12, afk_policies = $13, version = version + 1 WHERE id = $1 AND version = $14"
+	increment_lobby_version_sql       = "UPDATE %s.%s SET version = version + 1 WHERE id = $1"
+	delete_lobby_sql                  = "DELETE FROM %s.%s WHERE id = $1"
+	select_lobby_by_id_sql            = "SELECT id, status, name, owner, password, difficulty, mission_length, number_of_crew_members, max_players, expansion_packs, payload, visibility, afk_policies, created_at, version FROM %s.%s WHERE id = $1"
+	select_lobby_by_id_for_update_sql = "SELECT id, status, name, owner, password, difficulty, mission_length, number_of_crew_members, max_players, expansion_packs, payload, visibility, afk_policies, created_at, version FROM %s.%s WHERE id = $1 FOR UPDATE"
+	select_lobbies_sql                = "SELECT l.id, l.status, l.name, l.owner, l.password, l.difficulty, l.mission_length, l.number_of_crew_members, l.max_players, l.expansion_packs, l.payload, l.visibility, l.afk_policies, l.created_at, l.version, (%s)::text AS sort_value FROM %s.%s l"
+	free_slots_sql                    = "l.max_players - (SELECT count(*) FROM %[1]s.%[2]s p WHERE p.lobby_id = l.id AND p.spectator = false)::integer - (SELECT count(*) FROM %[1]s.%[3]s r WHERE r.lobby_id = l.id AND r.seat_held_until > $%[4]d)::integer"
+
+	// lobby_cursor_time_layout is the text of a timestamp in postgres, the in-memory database uses it as well
+	lobby_cursor_time_layout = "2006-01-02 15:04:05.999999999"
+)
+
+type (
+	lobbyRow struct {
+		Lobby
+		SortValue string `db:"sort_value"`
+	}
+
+	// lobbySort is the expression a lobby is sorted by and the type its cursor value is casted to
+	lobbySort struct {
+		expression string
+		valueType  string
+	}
 )
 
 var (
 	ErrLobbyAlreadyExists = errors.New("lobby already exists")
 )
 
+// ValidateLobbyCursor checks that the value of the cursor can be casted to the type of the sort
+func ValidateLobbyCursor(sort string, cursor *LobbyCursor) error {
+	lobbySort, err := getLobbySort(sort, "")
+	if err != nil {
+		return err
+	}
+	switch lobbySort.valueType {
+	case "timestamp":
+		if _, err := time.Parse(lobby_cursor_time_layout, cursor.Value); err != nil {
+			return fmt.Errorf("cursor value %s is no timestamp: %v", cursor.Value, err)
+		}
+	case "integer":
+		if _, err := strconv.ParseInt(cursor.Value, 10, 32); err != nil {
+			return fmt.Errorf("cursor value %s is no integer: %v", cursor.Value, err)
+		}
+	default:
+		if !utf8.ValidString(cursor.Value) || strings.ContainsRune(cursor.Value, 0) {
+			return fmt.Errorf("cursor value %q is no valid text", cursor.Value)
+		}
+	}
+	return nil
+}
+
 func (tx *postgresTransaction) CreateLobby(lobby *Lobby) error {
-	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_lobby_sql, schema_name, lobby_table_name), lobby.ID, lobby.Status, lobby.Name, lobby.Owner, lobby.Password, lobby.Difficulty, lobby.MissionLength, lobby.NumberOfCrewMembers, lobby.MaxPlayers, lobby.ExpansionPacks, lobby.Payload); err != nil {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_lobby_sql, schema_name, lobby_table_name), lobby.ID, lobby.Status, lobby.Name, lobby.Owner, lobby.Password, lobby.Difficulty, lobby.MissionLength, lobby.NumberOfCrewMembers, lobby.MaxPlayers, lobby.ExpansionPacks, lobby.Payload, lobby.Visibility, lobby.AfkPolicies, lobby.CreatedAt); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
@@ -36,13 +82,27 @@ func (tx *postgresTransaction) CreateLobby(lobby *Lobby) error {
 
 		return fmt.Errorf("unknown error when inserting lobby: %v", err)
 	}
+	lobby.Version = 1
 	return nil
 }
 
+// UpdateLobby only updates the lobby if its version didn't change since it was loaded and increases the version
 func (tx *postgresTransaction) UpdateLobby(lobby *Lobby) error {
-	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_lobby_sql, schema_name, lobby_table_name), lobby.ID, lobby.Status, lobby.Name, lobby.Owner, lobby.Password, lobby.Difficulty, lobby.MissionLength, lobby.NumberOfCrewMembers, lobby.MaxPlayers, lobby.ExpansionPacks, lobby.Payload); err != nil {
+	tag, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_lobby_sql, schema_name, lobby_table_name), lobby.ID, lobby.Status, lobby.Name, lobby.Owner, lobby.Password, lobby.Difficulty, lobby.MissionLength, lobby.NumberOfCrewMembers, lobby.MaxPlayers, lobby.ExpansionPacks, lobby.Payload, lobby.Visibility, lobby.AfkPolicies, lobby.Version)
+	if err != nil {
 		return fmt.Errorf("unknown error when updating lobby: %v", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("lobby [%v] with version %d not found: %w", lobby.ID, lobby.Version, ErrVersionConflict)
+	}
+	lobby.Version++
+	return nil
+}
+
+func (tx *postgresTransaction) IncrementLobbyVersion(id uuid.UUID) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(increment_lobby_version_sql, schema_name, lobby_table_name), id); err != nil {
+		return fmt.Errorf("unknown error when incrementing version of lobby: %v", err)
+	}
 	return nil
 }
 
@@ -70,11 +130,124 @@ func (tx *postgresTransaction) GetLobbyById(id uuid.UUID) (*Lobby, error) {
 	return lobbies[0], nil
 }
 
-func (tx *postgresTransaction) GetAllLobbies() ([]*Lobby, error) {
+// GetLobbyByIdForUpdate locks the lobby until the end of the transaction, so checks against its players can't race
+func (tx *postgresTransaction) GetLobbyByIdForUpdate(id uuid.UUID) (*Lobby, error) {
 	var lobbies []*Lobby
-	if err := pgxscan.Select(context.Background(), tx.tx, &lobbies, fmt.Sprintf(select_lobby_sql, schema_name, lobby_table_name)); err != nil {
-		return nil, fmt.Errorf("error while selecting all lobbies: %v", err)
+	if err := pgxscan.Select(context.Background(), tx.tx, &lobbies, fmt.Sprintf(select_lobby_by_id_for_update_sql, schema_name, lobby_table_name), id); err != nil {
+		return nil, fmt.Errorf("error while selecting lobby with id %v for update: %v", id, err)
+	}
+
+	if len(lobbies) == 0 {
+		return nil, nil
+	}
+	return lobbies[0], nil
+}
+
+func (tx *postgresTransaction) GetLobbies(query *LobbyQuery) ([]*Lobby, *LobbyCursor, error) {
+	sql, args, err := buildLobbiesQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows []*lobbyRow
+	if err := pgxscan.Select(context.Background(), tx.tx, &rows, sql, args...); err != nil {
+		return nil, nil, fmt.Errorf("error while selecting lobbies: %v", err)
+	}
+
+	var next *LobbyCursor
+	if query.Limit > 0 && len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+		last := rows[len(rows)-1]
+		next = &LobbyCursor{Value: last.SortValue, ID: last.ID}
+	}
+
+	lobbies := make([]*Lobby, len(rows))
+	for index, row := range rows {
+		lobby := row.Lobby
+		lobbies[index] = &lobby
+	}
+	return lobbies, next, nil
+}
+
+// buildLobbiesQuery creates the select for a page of lobbies. One lobby more than the limit is loaded to know if there is a next page
+func buildLobbiesQuery(query *LobbyQuery) (string, []interface{}, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	// the free slots don't count the seats which are still held at the time of the query
+	freeSlots := ""
+	if query.FreeSlots > 0 || query.Sort == LobbySortFreeSlots {
+		args = append(args, query.Now)
+		freeSlots = "(" + fmt.Sprintf(free_slots_sql, schema_name, player_table_name, reconnect_token_table_name, len(args)) + ")"
+	}
+	sort, err := getLobbySort(query.Sort, freeSlots)
+	if err != nil {
+		return "", nil, err
+	}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.Visibility != "" {
+		addCondition("l.visibility = $%d", query.Visibility)
+	}
+	if query.Status != "" {
+		addCondition("l.status = $%d", query.Status)
+	}
+	if query.Difficulty != 0 {
+		addCondition("l.difficulty = $%d", query.Difficulty)
+	}
+	if query.MissionLength != 0 {
+		addCondition("l.mission_length = $%d", query.MissionLength)
+	}
+	if len(query.ExpansionPacks) > 0 {
+		addCondition("l.expansion_packs @> $%d", query.ExpansionPacks)
+	}
+	if query.FreeSlots > 0 {
+		addCondition(freeSlots+" >= $%d", query.FreeSlots)
+	}
+	if query.PasswordProtected != nil {
+		if *query.PasswordProtected {
+			conditions = append(conditions, "l.password <> ''")
+		} else {
+			conditions = append(conditions, "l.password = ''")
+		}
+	}
+
+	direction, comparator := "ASC", ">"
+	if query.Descending {
+		direction, comparator = "DESC", "<"
+	}
+	if query.Cursor != nil {
+		args = append(args, query.Cursor.Value, query.Cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, l.id) %s ($%d::%s, $%d)", sort.expression, comparator, len(args)-1, sort.valueType, len(args)))
 	}
 
-	return lobbies, nil
+	sql := fmt.Sprintf(select_lobbies_sql, sort.expression, schema_name, lobby_table_name)
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sql += fmt.Sprintf(" ORDER BY %s %s, l.id %s", sort.expression, direction, direction)
+	if query.Limit > 0 {
+		args = append(args, query.Limit+1)
+		sql += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	return sql, args, nil
+}
+
+// getLobbySort needs the expression of the free slots only to sort by them
+func getLobbySort(sort string, freeSlots string) (*lobbySort, error) {
+	switch sort {
+	case "", LobbySortCreatedAt:
+		return &lobbySort{expression: "l.created_at", valueType: "timestamp"}, nil
+	case LobbySortName:
+		return &lobbySort{expression: "l.name", valueType: "varchar"}, nil
+	case LobbySortDifficulty:
+		return &lobbySort{expression: "l.difficulty", valueType: "integer"}, nil
+	case LobbySortFreeSlots:
+		return &lobbySort{expression: freeSlots, valueType: "integer"}, nil
+	default:
+		return nil, fmt.Errorf("no lobby sort %s found", sort)
+	}
 }
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLobbiesQuery_FilterSortAndCursor(t *testing.T) {
+	protected := false
+	cursorId := uuid.New()
+	query := &LobbyQuery{Visibility: "PUBLIC", Difficulty: 2, ExpansionPacks: []string{"red"}, PasswordProtected: &protected, Sort: LobbySortDifficulty, Descending: true, Limit: 10, Cursor: &LobbyCursor{Value: "3", ID: cursorId}}
+
+	sql, args, err := buildLobbiesQuery(query)
+	assert.Nil(t, err)
+	assert.Contains(t, sql, "(l.difficulty)::text AS sort_value")
+	assert.Contains(t, sql, "WHERE l.visibility = $1 AND l.difficulty = $2 AND l.expansion_packs @> $3 AND l.password = '' AND (l.difficulty, l.id) < ($4::integer, $5)")
+	assert.Contains(t, sql, "ORDER BY l.difficulty DESC, l.id DESC LIMIT $6")
+	assert.Equal(t, []interface{}{"PUBLIC", 2, []string{"red"}, "3", cursorId, 11}, args)
+}
+
+func TestBuildLobbiesQuery_FreeSlotsWithoutHeldSeats(t *testing.T) {
+	now := time.Now()
+	query := &LobbyQuery{FreeSlots: 2, Sort: LobbySortFreeSlots, Now: now}
+
+	sql, args, err := buildLobbiesQuery(query)
+	assert.Nil(t, err)
+	assert.Contains(t, sql, "r.seat_held_until > $1)::integer) >= $2")
+	assert.Contains(t, sql, "r.seat_held_until > $1)::integer) ASC, l.id ASC")
+	assert.Equal(t, []interface{}{now, 2}, args)
+}
+
+func TestBuildLobbiesQuery_UnknownSort(t *testing.T) {
+	_, _, err := buildLobbiesQuery(&LobbyQuery{Sort: "owner"})
+	assert.NotNil(t, err)
+}
+
+func TestValidateLobbyCursor(t *testing.T) {
+	assert.Nil(t, ValidateLobbyCursor(LobbySortCreatedAt, &LobbyCursor{Value: "2023-05-01 12:30:15.123456"}))
+	assert.Nil(t, ValidateLobbyCursor(LobbySortCreatedAt, &LobbyCursor{Value: "2023-05-01 12:30:15"}))
+	assert.Nil(t, ValidateLobbyCursor(LobbySortDifficulty, &LobbyCursor{Value: "-3"}))
+	assert.Nil(t, ValidateLobbyCursor(LobbySortName, &LobbyCursor{Value: "Enterprise"}))
+
+	assert.NotNil(t, ValidateLobbyCursor("", &LobbyCursor{Value: "Enterprise"}))
+	assert.NotNil(t, ValidateLobbyCursor(LobbySortFreeSlots, &LobbyCursor{Value: "2023-05-01 12:30:15"}))
+	assert.NotNil(t, ValidateLobbyCursor(LobbySortDifficulty, &LobbyCursor{Value: "99999999999"}))
+	assert.NotNil(t, ValidateLobbyCursor(LobbySortName, &LobbyCursor{Value: "Enter\x00prise"}))
+	assert.NotNil(t, ValidateLobbyCursor("owner", &LobbyCursor{Value: "Kirk"}))
+}
+
+// testGetLobbyByIdForUpdate checks that a second transaction waits for the lock and then sees the committed change
+func testGetLobbyByIdForUpdate(t *testing.T, connection DB) {
+	lobby := createTestLobby(t, connection)
+
+	firstTx := startTestTransaction(t, connection)
+	lockedLobby, err := firstTx.GetLobbyByIdForUpdate(lobby.ID)
+	assert.Nil(t, err)
+
+	secondLoaded := make(chan *Lobby)
+	secondTx := startTestTransaction(t, connection)
+	defer secondTx.Rollback()
+	go func() {
+		lobby, err := secondTx.GetLobbyByIdForUpdate(lobby.ID)
+		assert.Nil(t, err)
+		secondLoaded <- lobby
+	}()
+
+	select {
+	case <-secondLoaded:
+		t.Fatal("second transaction got the lock while the first transaction held it")
+	case <-time.After(200 * time.Millisecond):
+	}
+	lockedLobby.Name = "Renamed Lobby"
+	assert.Nil(t, firstTx.UpdateLobby(lockedLobby))
+	assert.Nil(t, firstTx.Commit())
+	assert.Equal(t, "Renamed Lobby", (<-secondLoaded).Name)
+}
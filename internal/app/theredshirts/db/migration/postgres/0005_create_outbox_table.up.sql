@@ -0,0 +1,13 @@
+CREATE TABLE theredshirts_lobby.outbox (
+    id bigserial PRIMARY KEY NOT NULL,
+    lobby_id uuid NOT NULL,
+    sender_player_id uuid NOT NULL,
+    correlation_id varchar NOT NULL,
+    topic varchar NOT NULL,
+    payload json,
+    created_at timestamp NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt timestamp NOT NULL,
+    last_error varchar NOT NULL DEFAULT ''
+);
+CREATE INDEX outbox_lobby_idx ON theredshirts_lobby.outbox (lobby_id, id);
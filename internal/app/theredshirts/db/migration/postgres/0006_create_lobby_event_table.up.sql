@@ -0,0 +1,10 @@
+CREATE TABLE theredshirts_lobby.lobby_event (
+    id bigserial PRIMARY KEY NOT NULL,
+    lobby_id uuid NOT NULL,
+    topic varchar NOT NULL,
+    payload json,
+    snapshot json,
+    created_at timestamp NOT NULL
+);
+CREATE INDEX lobby_event_lobby_idx ON theredshirts_lobby.lobby_event (lobby_id, id);
+CREATE INDEX lobby_event_created_at_idx ON theredshirts_lobby.lobby_event (created_at);
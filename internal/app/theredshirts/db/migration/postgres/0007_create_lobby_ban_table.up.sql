@@ -0,0 +1,7 @@
+CREATE TABLE theredshirts_lobby.lobby_ban (
+    lobby_id uuid NOT NULL REFERENCES theredshirts_lobby.lobby(id) ON DELETE CASCADE,
+    player_id uuid NOT NULL,
+    reason varchar NOT NULL,
+    created_at timestamp NOT NULL,
+    PRIMARY KEY (lobby_id, player_id)
+);
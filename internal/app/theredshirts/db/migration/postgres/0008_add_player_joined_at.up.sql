@@ -0,0 +1 @@
+ALTER TABLE theredshirts_lobby.player ADD COLUMN joined_at timestamp NOT NULL DEFAULT now();
@@ -0,0 +1 @@
+ALTER TABLE theredshirts_lobby.player ADD COLUMN ready boolean NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+CREATE TABLE theredshirts_lobby.join_attempt (
+    lobby_id uuid NOT NULL REFERENCES theredshirts_lobby.lobby(id) ON DELETE CASCADE,
+    player_id uuid NOT NULL,
+    failed_attempts integer NOT NULL,
+    last_failed timestamp NOT NULL,
+    PRIMARY KEY (lobby_id, player_id)
+);
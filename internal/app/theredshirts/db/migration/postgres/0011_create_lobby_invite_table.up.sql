@@ -0,0 +1,9 @@
+CREATE TABLE theredshirts_lobby.lobby_invite (
+    code varchar PRIMARY KEY NOT NULL,
+    lobby_id uuid NOT NULL REFERENCES theredshirts_lobby.lobby(id) ON DELETE CASCADE,
+    created_at timestamp NOT NULL,
+    expires_at timestamp,
+    max_uses integer NOT NULL,
+    uses integer NOT NULL
+);
+CREATE INDEX lobby_invite_lobby_idx ON theredshirts_lobby.lobby_invite (lobby_id);
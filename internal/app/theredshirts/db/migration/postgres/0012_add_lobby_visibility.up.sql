@@ -0,0 +1,3 @@
+ALTER TABLE theredshirts_lobby.lobby ADD COLUMN visibility varchar NOT NULL DEFAULT 'PUBLIC';
+ALTER TABLE theredshirts_lobby.lobby ADD COLUMN created_at timestamp NOT NULL DEFAULT now();
+CREATE INDEX lobby_visibility_created_idx ON theredshirts_lobby.lobby (visibility, created_at, id);
@@ -0,0 +1,2 @@
+ALTER TABLE theredshirts_lobby.lobby ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE theredshirts_lobby.player ADD COLUMN version integer NOT NULL DEFAULT 1;
@@ -0,0 +1,12 @@
+CREATE TABLE theredshirts_lobby.idempotency_key (
+    player_id uuid NOT NULL,
+    key varchar NOT NULL,
+    request_hash varchar NOT NULL,
+    status_code integer NOT NULL,
+    content_type varchar NOT NULL,
+    body bytea NOT NULL,
+    created_at timestamp NOT NULL,
+    expires_at timestamp NOT NULL,
+    PRIMARY KEY (player_id, key)
+);
+CREATE INDEX idempotency_key_expires_at_idx ON theredshirts_lobby.idempotency_key (expires_at);
@@ -0,0 +1,10 @@
+CREATE TABLE theredshirts_lobby.lobby_audit (
+    id bigserial PRIMARY KEY NOT NULL,
+    lobby_id uuid NOT NULL,
+    topic varchar NOT NULL,
+    actor_id uuid NOT NULL,
+    correlation_id varchar NOT NULL,
+    payload json,
+    created_at timestamp NOT NULL
+);
+CREATE INDEX lobby_audit_lobby_idx ON theredshirts_lobby.lobby_audit (lobby_id, id);
@@ -0,0 +1 @@
+ALTER TABLE theredshirts_lobby.lobby ADD COLUMN afk_policies json;
@@ -0,0 +1,13 @@
+CREATE TABLE theredshirts_lobby.reconnect_token (
+    player_id uuid PRIMARY KEY NOT NULL,
+    lobby_id uuid NOT NULL REFERENCES theredshirts_lobby.lobby(id) ON DELETE CASCADE,
+    token_hash varchar NOT NULL,
+    name varchar NOT NULL,
+    spectator boolean NOT NULL,
+    payload json,
+    owner boolean NOT NULL,
+    seat_held boolean NOT NULL,
+    dropped_at timestamp,
+    expires_at timestamp
+);
+CREATE INDEX reconnect_token_lobby_idx ON theredshirts_lobby.reconnect_token (lobby_id, expires_at);
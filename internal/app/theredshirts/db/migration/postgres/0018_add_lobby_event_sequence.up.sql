@@ -0,0 +1,11 @@
+CREATE TABLE theredshirts_lobby.lobby_sequence (
+    lobby_id uuid PRIMARY KEY NOT NULL,
+    last_sequence bigint NOT NULL
+);
+ALTER TABLE theredshirts_lobby.lobby_event ADD COLUMN sequence bigint;
+UPDATE theredshirts_lobby.lobby_event SET sequence = id;
+ALTER TABLE theredshirts_lobby.lobby_event ALTER COLUMN sequence SET NOT NULL;
+INSERT INTO theredshirts_lobby.lobby_sequence(lobby_id, last_sequence) SELECT id, (SELECT coalesce(max(id), 0) FROM theredshirts_lobby.lobby_event) FROM theredshirts_lobby.lobby;
+INSERT INTO theredshirts_lobby.lobby_sequence(lobby_id, last_sequence) SELECT lobby_id, max(id) FROM theredshirts_lobby.lobby_event GROUP BY lobby_id ON CONFLICT (lobby_id) DO NOTHING;
+DROP INDEX theredshirts_lobby.lobby_event_lobby_idx;
+CREATE UNIQUE INDEX lobby_event_lobby_idx ON theredshirts_lobby.lobby_event (lobby_id, sequence);
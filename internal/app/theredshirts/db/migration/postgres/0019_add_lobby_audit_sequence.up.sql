@@ -0,0 +1,7 @@
+ALTER TABLE theredshirts_lobby.lobby_sequence ADD COLUMN last_audit_sequence bigint NOT NULL DEFAULT 0;
+ALTER TABLE theredshirts_lobby.lobby_audit ADD COLUMN sequence bigint;
+UPDATE theredshirts_lobby.lobby_audit a SET sequence = n.sequence FROM (SELECT id, row_number() OVER (PARTITION BY lobby_id ORDER BY id) AS sequence FROM theredshirts_lobby.lobby_audit) n WHERE a.id = n.id;
+ALTER TABLE theredshirts_lobby.lobby_audit ALTER COLUMN sequence SET NOT NULL;
+INSERT INTO theredshirts_lobby.lobby_sequence(lobby_id, last_sequence, last_audit_sequence) SELECT lobby_id, 0, max(sequence) FROM theredshirts_lobby.lobby_audit GROUP BY lobby_id ON CONFLICT (lobby_id) DO UPDATE SET last_audit_sequence = EXCLUDED.last_audit_sequence;
+DROP INDEX theredshirts_lobby.lobby_audit_lobby_idx;
+CREATE UNIQUE INDEX lobby_audit_lobby_idx ON theredshirts_lobby.lobby_audit (lobby_id, sequence);
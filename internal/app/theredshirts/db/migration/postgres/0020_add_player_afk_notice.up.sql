@@ -0,0 +1 @@
+ALTER TABLE theredshirts_lobby.player ADD COLUMN afk_notice varchar NOT NULL DEFAULT '';
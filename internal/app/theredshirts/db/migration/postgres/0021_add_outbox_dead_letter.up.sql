@@ -0,0 +1 @@
+ALTER TABLE theredshirts_lobby.outbox ADD COLUMN dead_letter boolean NOT NULL DEFAULT false;
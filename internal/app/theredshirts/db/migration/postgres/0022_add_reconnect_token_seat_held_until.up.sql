@@ -0,0 +1,3 @@
+ALTER TABLE theredshirts_lobby.reconnect_token ADD COLUMN seat_held_until timestamp;
+UPDATE theredshirts_lobby.reconnect_token SET seat_held_until = expires_at WHERE seat_held;
+ALTER TABLE theredshirts_lobby.reconnect_token DROP COLUMN seat_held;
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+const (
+	outbox_table_name                = "outbox"
+	create_outbox_message_sql        = "INSERT INTO %s.%s(lobby_id, sender_player_id, correlation_id, topic, payload, created_at, attempts, next_attempt, last_error) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	update_outbox_message_sql        = "UPDATE %s.%s SET attempts = $2, next_attempt = $3, last_error = $4, dead_letter = $5 WHERE id = $1"
+	delete_outbox_message_sql        = "DELETE FROM %s.%s WHERE id = $1"
+	select_outbox_message_due_sql    = "SELECT id, lobby_id, sender_player_id, correlation_id, topic, payload, created_at, attempts, next_attempt, last_error, dead_letter FROM %[1]s.%[2]s WHERE id IN (SELECT min(id) FROM %[1]s.%[2]s WHERE NOT dead_letter GROUP BY lobby_id) AND next_attempt <= $1 ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED"
+	select_outbox_message_sql        = "SELECT id, lobby_id, sender_player_id, correlation_id, topic, payload, created_at, attempts, next_attempt, last_error, dead_letter FROM %s.%s ORDER BY id"
+	select_outbox_message_failed_sql = "SELECT id, lobby_id, sender_player_id, correlation_id, topic, payload, created_at, attempts, next_attempt, last_error, dead_letter FROM %s.%s WHERE dead_letter ORDER BY id"
+)
+
+func (tx *postgresTransaction) CreateOutboxMessage(message *OutboxMessage) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_outbox_message_sql, schema_name, outbox_table_name), message.LobbyId, message.SenderPlayerId, message.CorrelationId, message.Topic, message.Payload, message.CreatedAt, message.Attempts, message.NextAttempt, message.LastError); err != nil {
+		return fmt.Errorf("unknown error when inserting outbox message: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) UpdateOutboxMessage(message *OutboxMessage) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_outbox_message_sql, schema_name, outbox_table_name), message.ID, message.Attempts, message.NextAttempt, message.LastError, message.DeadLetter); err != nil {
+		return fmt.Errorf("unknown error when updating outbox message: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteOutboxMessage(id int64) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_outbox_message_sql, schema_name, outbox_table_name), id); err != nil {
+		return fmt.Errorf("unknown error when deleting outbox message: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetDueOutboxMessages(now time.Time, limit int) ([]*OutboxMessage, error) {
+	var messages []*OutboxMessage
+	if err := pgxscan.Select(context.Background(), tx.tx, &messages, fmt.Sprintf(select_outbox_message_due_sql, schema_name, outbox_table_name), now, limit); err != nil {
+		return nil, fmt.Errorf("error while selecting due outbox messages: %v", err)
+	}
+	return messages, nil
+}
+
+func (tx *postgresTransaction) GetOutboxMessages() ([]*OutboxMessage, error) {
+	var messages []*OutboxMessage
+	if err := pgxscan.Select(context.Background(), tx.tx, &messages, fmt.Sprintf(select_outbox_message_sql, schema_name, outbox_table_name)); err != nil {
+		return nil, fmt.Errorf("error while selecting outbox messages: %v", err)
+	}
+	return messages, nil
+}
+
+func (tx *postgresTransaction) GetFailedOutboxMessages() ([]*OutboxMessage, error) {
+	var messages []*OutboxMessage
+	if err := pgxscan.Select(context.Background(), tx.tx, &messages, fmt.Sprintf(select_outbox_message_failed_sql, schema_name, outbox_table_name)); err != nil {
+		return nil, fmt.Errorf("error while selecting failed outbox messages: %v", err)
+	}
+	return messages, nil
+}
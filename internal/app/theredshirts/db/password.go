@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	select_plaintext_lobby_passwords_sql = "SELECT id, password FROM %s.%s WHERE password <> '' AND password NOT LIKE '$2_$%%'"
+	update_plaintext_lobby_password_sql  = "UPDATE %s.%s SET password = $2 WHERE id = $1 AND password = $3"
+)
+
+type plaintextLobbyPassword struct {
+	ID       uuid.UUID `db:"id"`
+	Password string    `db:"password"`
+}
+
+// hashPlaintextLobbyPasswords hashes the passwords which were stored before lobby passwords were hashed. It runs
+// after the migrations, once all passwords are hashed it doesn't find anything to do
+func hashPlaintextLobbyPasswords(dbPool *pgxpool.Pool) error {
+	cost, err := util.GetEnvIntWithFallback("PASSWORD_HASH_COST", bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("password hash cost is not a number: %v", err)
+	}
+
+	var passwords []*plaintextLobbyPassword
+	if err := pgxscan.Select(context.Background(), dbPool, &passwords, fmt.Sprintf(select_plaintext_lobby_passwords_sql, schema_name, lobby_table_name)); err != nil {
+		return fmt.Errorf("error while selecting plaintext passwords of lobbies: %v", err)
+	}
+
+	for _, password := range passwords {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password.Password), cost)
+		if err != nil {
+			return fmt.Errorf("error while hashing password of lobby [%v]: %v", password.ID, err)
+		}
+		if _, err := dbPool.Exec(context.Background(), fmt.Sprintf(update_plaintext_lobby_password_sql, schema_name, lobby_table_name), password.ID, string(hash), password.Password); err != nil {
+			return fmt.Errorf("error while updating password of lobby [%v]: %v", password.ID, err)
+		}
+	}
+	return nil
+}
@@ -14,14 +14,18 @@ import (
 
 const (
 	player_table_name                 = "player"
-	create_player_sql                 = "INSERT INTO %s.%s(id, name, lobby_id, last_refresh, spectator, payload) VALUES($1, $2, $3, $4, $5, $6)"
-	update_player_sql                 = "UPDATE %s.%s SET name = $2, lobby_id = $3, last_refresh = $4, spectator = $5, payload = $6 WHERE id = $1"
-	update_player_last_refresh_sql    = "UPDATE %s.%s SET last_refresh = $2 WHERE id = $1"
+	create_player_sql                 = "INSERT INTO %s.%s(id, name, lobby_id, last_refresh, joined_at, spectator, ready, payload) VALUES($1, $2, $3, $4, $5, $6, $7, $8)"
+	update_player_sql                 = "UPDATE %s.%s SET name = $2, lobby_id = $3, last_refresh = $4, spectator = $5, ready = $6, payload = $7, afk_notice = $9, version = version + 1 WHERE id = $1 AND version = $8"
+	update_player_last_refresh_sql    = "UPDATE %s.%s SET last_refresh = $2, afk_notice = '' WHERE id = $1"
+	update_players_last_refresh_sql   = "UPDATE %s.%s AS player SET last_refresh = refresh.last_refresh, afk_notice = '' FROM unnest($1::varchar[], $2::timestamp[]) AS refresh(id, last_refresh) WHERE player.id = refresh.id::uuid"
+	update_player_afk_notice_sql      = "UPDATE %s.%s SET afk_notice = $3 WHERE id = $1 AND last_refresh = $2"
+	reset_players_ready_sql           = "UPDATE %s.%s SET ready = false, version = version + 1 WHERE lobby_id = $1 AND ready = true RETURNING id"
 	delete_player_sql                 = "DELETE FROM %s.%s WHERE id = $1"
 	delete_player_in_lobby_sql        = "DELETE FROM %s.%s WHERE lobby_id = $1"
-	select_player_by_player_id_sql    = "SELECT id, name, lobby_id, last_refresh, spectator, payload FROM %s.%s WHERE id = $1"
-	select_player_by_lobby_id_sql     = "SELECT id, name, lobby_id, last_refresh, spectator, payload FROM %s.%s WHERE lobby_id = $1"
-	select_player_by_last_refresh_sql = "SELECT id, name, lobby_id, last_refresh, spectator, payload FROM %s.%s WHERE last_refresh < $1"
+	select_player_by_player_id_sql    = "SELECT id, name, lobby_id, last_refresh, joined_at, spectator, ready, payload, afk_notice, version FROM %s.%s WHERE id = $1"
+	select_player_by_lobby_id_sql     = "SELECT id, name, lobby_id, last_refresh, joined_at, spectator, ready, payload, afk_notice, version FROM %s.%s WHERE lobby_id = $1"
+	select_player_by_lobby_ids_sql    = "SELECT id, name, lobby_id, last_refresh, joined_at, spectator, ready, payload, afk_notice, version FROM %s.%s WHERE lobby_id = ANY($1::varchar[]::uuid[])"
+	select_player_by_last_refresh_sql = "SELECT id, name, lobby_id, last_refresh, joined_at, spectator, ready, payload, afk_notice, version FROM %s.%s WHERE last_refresh < $1"
 	select_player_count_by_lobby_sql  = "SELECT count(*) AS number_of_players FROM %s.%s WHERE lobby_id = $1 AND spectator = false"
 )
 
@@ -34,7 +38,7 @@ var (
 )
 
 func (tx *postgresTransaction) CreatePlayer(player *Player) error {
-	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_player_sql, schema_name, player_table_name), player.ID, player.Name, player.LobbyId, player.LastRefresh, player.Spectator, player.Payload); err != nil {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(create_player_sql, schema_name, player_table_name), player.ID, player.Name, player.LobbyId, player.LastRefresh, player.JoinedAt, player.Spectator, player.Ready, player.Payload); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
@@ -45,13 +49,20 @@ func (tx *postgresTransaction) CreatePlayer(player *Player) error {
 
 		return fmt.Errorf("unknown error when inserting player: %v", err)
 	}
+	player.Version = 1
 	return nil
 }
 
+// UpdatePlayer only updates the player if its version didn't change since it was loaded and increases the version
 func (tx *postgresTransaction) UpdatePlayer(player *Player) error {
-	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_player_sql, schema_name, player_table_name), player.ID, player.Name, player.LobbyId, player.LastRefresh, player.Spectator, player.Payload); err != nil {
+	tag, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_player_sql, schema_name, player_table_name), player.ID, player.Name, player.LobbyId, player.LastRefresh, player.Spectator, player.Ready, player.Payload, player.Version, player.AfkNotice)
+	if err != nil {
 		return fmt.Errorf("unknown error when updating player: %v", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("player [%v] with version %d not found: %w", player.ID, player.Version, ErrVersionConflict)
+	}
+	player.Version++
 	return nil
 }
 
@@ -62,6 +73,35 @@ func (tx *postgresTransaction) UpdatePlayerLastRefresh(playerId uuid.UUID, lastR
 	return nil
 }
 
+func (tx *postgresTransaction) UpdatePlayersLastRefresh(lastRefreshes map[uuid.UUID]time.Time) error {
+	playerIds := make([]string, 0, len(lastRefreshes))
+	refreshes := make([]time.Time, 0, len(lastRefreshes))
+	for playerId, lastRefresh := range lastRefreshes {
+		playerIds = append(playerIds, playerId.String())
+		refreshes = append(refreshes, lastRefresh)
+	}
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_players_last_refresh_sql, schema_name, player_table_name), playerIds, refreshes); err != nil {
+		return fmt.Errorf("unknown error when updating last refresh of players: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) UpdatePlayerAfkNotice(playerId uuid.UUID, lastRefresh time.Time, afkNotice string) (bool, error) {
+	tag, err := tx.tx.Exec(context.Background(), fmt.Sprintf(update_player_afk_notice_sql, schema_name, player_table_name), playerId, lastRefresh, afkNotice)
+	if err != nil {
+		return false, fmt.Errorf("unknown error when updating afk notice of player: %v", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (tx *postgresTransaction) ResetPlayersReady(lobbyId uuid.UUID) ([]uuid.UUID, error) {
+	var playerIds []uuid.UUID
+	if err := pgxscan.Select(context.Background(), tx.tx, &playerIds, fmt.Sprintf(reset_players_ready_sql, schema_name, player_table_name), lobbyId); err != nil {
+		return nil, fmt.Errorf("unknown error when resetting ready of players: %v", err)
+	}
+	return playerIds, nil
+}
+
 func (tx *postgresTransaction) DeletePlayer(id uuid.UUID) error {
 	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_player_sql, schema_name, player_table_name), id); err != nil {
 		return fmt.Errorf("unknown error when deliting player: %v", err)
@@ -102,6 +142,19 @@ func (tx *postgresTransaction) GetAllPlayersInLobby(lobbyId uuid.UUID) ([]*Playe
 	return players, nil
 }
 
+func (tx *postgresTransaction) GetAllPlayersInLobbies(lobbyIds []uuid.UUID) ([]*Player, error) {
+	ids := make([]string, len(lobbyIds))
+	for index, lobbyId := range lobbyIds {
+		ids[index] = lobbyId.String()
+	}
+	var players []*Player
+	if err := pgxscan.Select(context.Background(), tx.tx, &players, fmt.Sprintf(select_player_by_lobby_ids_sql, schema_name, player_table_name), ids); err != nil {
+		return nil, fmt.Errorf("error while selecting all players in lobbies: %v", err)
+	}
+
+	return players, nil
+}
+
 func (tx *postgresTransaction) GetPlayersLastRefresh(lastRefresh time.Time) ([]*Player, error) {
 	var players []*Player
 	if err := pgxscan.Select(context.Background(), tx.tx, &players, fmt.Sprintf(select_player_by_last_refresh_sql, schema_name, player_table_name), lastRefresh); err != nil {
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestPlayer(t *testing.T, connection DB, playerId uuid.UUID) *Player {
+	tx := startTestTransaction(t, connection)
+	defer tx.Rollback()
+	player, err := tx.GetPlayerById(playerId)
+	assert.Nil(t, err)
+	return player
+}
+
+// testUpdatePlayerAfkNotice checks that a refresh clears the notice and that a notice for an older refresh is ignored
+func testUpdatePlayerAfkNotice(t *testing.T, connection DB) {
+	lobby := createTestLobby(t, connection)
+	playerId := uuid.New()
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreatePlayer(&Player{ID: playerId, Name: "Player", LobbyId: lobby.ID, LastRefresh: time.Now().Add(-time.Minute), JoinedAt: time.Now()}))
+	assert.Nil(t, tx.Commit())
+	player := getTestPlayer(t, connection, playerId)
+
+	tx = startTestTransaction(t, connection)
+	noticed, err := tx.UpdatePlayerAfkNotice(player.ID, player.LastRefresh, "PLAYER_LAGGING")
+	assert.Nil(t, err)
+	assert.True(t, noticed)
+	assert.Nil(t, tx.Commit())
+	assert.Equal(t, "PLAYER_LAGGING", getTestPlayer(t, connection, player.ID).AfkNotice)
+
+	tx = startTestTransaction(t, connection)
+	assert.Nil(t, tx.UpdatePlayerLastRefresh(player.ID, time.Now()))
+	assert.Nil(t, tx.Commit())
+	assert.Empty(t, getTestPlayer(t, connection, player.ID).AfkNotice)
+
+	tx = startTestTransaction(t, connection)
+	defer tx.Rollback()
+	noticed, err = tx.UpdatePlayerAfkNotice(player.ID, player.LastRefresh, "PLAYER_LAGGING")
+	assert.Nil(t, err)
+	assert.False(t, noticed)
+}
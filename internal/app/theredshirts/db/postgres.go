@@ -5,6 +5,8 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/BeanCodeDe/TheRedShirts-Lobby/internal/app/theredshirts/util"
 	"github.com/golang-migrate/migrate/v4"
@@ -16,6 +18,10 @@ import (
 	_ "github.com/lib/pq"
 )
 
+const (
+	ping_timeout = 5 * time.Second
+)
+
 var (
 	//go:embed migration/postgres/*.up.sql
 	postgresMigrationFs embed.FS
@@ -23,7 +29,9 @@ var (
 
 type (
 	postgresConnection struct {
-		dbPool *pgxpool.Pool
+		dbPool           *pgxpool.Pool
+		leaderLocksMutex sync.Mutex
+		leaderLocks      map[*postgresLeaderLock]bool
 	}
 
 	postgresTransaction struct {
@@ -32,11 +40,35 @@ type (
 )
 
 func newPostgresConnection() (DB, error) {
+	url, migrationOptions, err := loadPostgresUrl()
+	if err != nil {
+		return nil, err
+	}
+
+	err = migratePostgresDatabase(url + migrationOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error while migrating database: %v", err)
+	}
+
+	dbPool, err := pgxpool.Connect(context.Background(), url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to database: %v", err)
+	}
+
+	if err := hashPlaintextLobbyPasswords(dbPool); err != nil {
+		dbPool.Close()
+		return nil, fmt.Errorf("error while migrating database: %v", err)
+	}
+	return &postgresConnection{dbPool: dbPool, leaderLocks: make(map[*postgresLeaderLock]bool)}, nil
+}
+
+// loadPostgresUrl returns the url of the database and the options which are only added for the migration
+func loadPostgresUrl() (string, string, error) {
 	user := util.GetEnvWithFallback("POSTGRES_USER", "postgres")
 	dbName := util.GetEnvWithFallback("POSTGRES_DB", "postgres")
 	password, err := util.GetEnv("POSTGRES_PASSWORD")
 	if err != nil {
-		return nil, fmt.Errorf("postgres password has to be set: %v", err)
+		return "", "", fmt.Errorf("postgres password has to be set: %v", err)
 	}
 	host := util.GetEnvWithFallback("POSTGRES_HOST", "postgres")
 	port, err := util.GetEnvIntWithFallback("POSTGRES_PORT", 5432)
@@ -44,26 +76,53 @@ func newPostgresConnection() (DB, error) {
 	migrationOptions := util.GetEnvWithFallback("POSTGRES_MIGRATION_OPTIONS", "&x-migrations-table=theredshirts-lobby")
 
 	if err != nil {
-		return nil, fmt.Errorf("port is not a number: %v", err)
+		return "", "", fmt.Errorf("port is not a number: %v", err)
 	}
 
-	url := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", user, password, host, port, dbName, options)
-	err = migratePostgresDatabase(url + migrationOptions)
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", user, password, host, port, dbName, options), migrationOptions, nil
+}
+
+func migratePostgres() error {
+	url, migrationOptions, err := loadPostgresUrl()
 	if err != nil {
-		return nil, fmt.Errorf("error while migrating database: %v", err)
+		return err
+	}
+	if err := migratePostgresDatabase(url + migrationOptions); err != nil {
+		return fmt.Errorf("error while migrating database: %v", err)
 	}
 
 	dbPool, err := pgxpool.Connect(context.Background(), url)
 	if err != nil {
-		return nil, fmt.Errorf("unable to connect to database: %v", err)
+		return fmt.Errorf("unable to connect to database: %v", err)
+	}
+	defer dbPool.Close()
+	if err := hashPlaintextLobbyPasswords(dbPool); err != nil {
+		return fmt.Errorf("error while migrating database: %v", err)
 	}
-	return &postgresConnection{dbPool: dbPool}, nil
+	return nil
 }
 
+// Close releases the leader locks first, the pool waits for their connections before it closes
 func (connection *postgresConnection) Close() {
+	connection.leaderLocksMutex.Lock()
+	leaderLocks := connection.leaderLocks
+	connection.leaderLocks = make(map[*postgresLeaderLock]bool)
+	connection.leaderLocksMutex.Unlock()
+	for lock := range leaderLocks {
+		lock.release()
+	}
 	connection.dbPool.Close()
 }
 
+func (connection *postgresConnection) Ping() error {
+	pingContext, cancel := context.WithTimeout(context.Background(), ping_timeout)
+	defer cancel()
+	if err := connection.dbPool.Ping(pingContext); err != nil {
+		return fmt.Errorf("error while pinging database: %v", err)
+	}
+	return nil
+}
+
 func migratePostgresDatabase(url string) error {
 	d, err := iofs.New(postgresMigrationFs, "migration/postgres")
 	if err != nil {
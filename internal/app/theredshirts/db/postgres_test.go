@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestPostgresConnection connects to the postgres configured by the POSTGRES_ environment variables.
+// The test is skipped if no postgres is configured
+func newTestPostgresConnection(t *testing.T) *postgresConnection {
+	if os.Getenv("POSTGRES_PASSWORD") == "" {
+		t.Skip("postgres is not configured, set POSTGRES_PASSWORD and the other POSTGRES_ variables to run this test")
+	}
+	connection, err := newPostgresConnection()
+	if err != nil {
+		t.Fatalf("error while connecting to postgres: %v", err)
+	}
+	t.Cleanup(connection.Close)
+	return connection.(*postgresConnection)
+}
+
+func TestPostgres_EventSequenceFollowsCommitOrder(t *testing.T) {
+	connection := newTestPostgresConnection(t)
+	lobbyId := uuid.New()
+
+	firstTx := startTestTransaction(t, connection)
+	first := &LobbyEvent{LobbyId: lobbyId, Topic: "FIRST", CreatedAt: time.Now()}
+	assert.Nil(t, firstTx.CreateLobbyEvent(first))
+
+	second := &LobbyEvent{LobbyId: lobbyId, Topic: "SECOND", CreatedAt: time.Now()}
+	secondCommitted := make(chan error)
+	secondTx := startTestTransaction(t, connection)
+	go func() {
+		if err := secondTx.CreateLobbyEvent(second); err != nil {
+			secondCommitted <- err
+			return
+		}
+		secondCommitted <- secondTx.Commit()
+	}()
+
+	select {
+	case <-secondCommitted:
+		t.Fatal("second event was committed while the first transaction was open")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.Nil(t, firstTx.Commit())
+	assert.Nil(t, <-secondCommitted)
+	assert.Equal(t, int64(1), first.Sequence)
+	assert.Equal(t, int64(2), second.Sequence)
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	events, err := readTx.GetLobbyEventsAfter(lobbyId, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "SECOND", events[0].Topic)
+}
+
+func TestPostgres_AuditSequenceFollowsCommitOrder(t *testing.T) {
+	connection := newTestPostgresConnection(t)
+	lobbyId := uuid.New()
+
+	firstTx := startTestTransaction(t, connection)
+	first := &LobbyAuditEntry{LobbyId: lobbyId, Topic: "FIRST", CreatedAt: time.Now()}
+	assert.Nil(t, firstTx.CreateLobbyAuditEntry(first))
+
+	second := &LobbyAuditEntry{LobbyId: lobbyId, Topic: "SECOND", CreatedAt: time.Now()}
+	secondCommitted := make(chan error)
+	secondTx := startTestTransaction(t, connection)
+	go func() {
+		if err := secondTx.CreateLobbyAuditEntry(second); err != nil {
+			secondCommitted <- err
+			return
+		}
+		secondCommitted <- secondTx.Commit()
+	}()
+
+	select {
+	case <-secondCommitted:
+		t.Fatal("second audit entry was committed while the first transaction was open")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.Nil(t, firstTx.Commit())
+	assert.Nil(t, <-secondCommitted)
+	assert.Equal(t, int64(1), first.Sequence)
+	assert.Equal(t, int64(2), second.Sequence)
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	entries, err := readTx.GetLobbyAuditEntriesAfter(lobbyId, 1, 10)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "SECOND", entries[0].Topic)
+}
+
+func TestPostgres_HashPlaintextLobbyPasswords(t *testing.T) {
+	connection := newTestPostgresConnection(t)
+	lobby := &Lobby{ID: uuid.New(), Name: "Some Lobby", Owner: uuid.New(), Password: "secret", MaxPlayers: 4}
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreateLobby(lobby))
+	assert.Nil(t, tx.Commit())
+
+	assert.Nil(t, hashPlaintextLobbyPasswords(connection.dbPool))
+	assert.Nil(t, hashPlaintextLobbyPasswords(connection.dbPool))
+
+	readTx := startTestTransaction(t, connection)
+	defer readTx.Rollback()
+	foundLobby, err := readTx.GetLobbyById(lobby.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, bcrypt.CompareHashAndPassword([]byte(foundLobby.Password), []byte("secret")))
+}
+
+func TestPostgres_UseInviteConcurrently(t *testing.T) {
+	connection := newTestPostgresConnection(t)
+	lobby := createTestLobby(t, connection)
+	invite := &Invite{Code: uuid.NewString()[:6], LobbyId: lobby.ID, CreatedAt: time.Now(), MaxUses: 1}
+	tx := startTestTransaction(t, connection)
+	assert.Nil(t, tx.CreateInvite(invite))
+	assert.Nil(t, tx.Commit())
+
+	firstTx := startTestTransaction(t, connection)
+	used, err := firstTx.UseInvite(invite.Code)
+	assert.Nil(t, err)
+	assert.True(t, used)
+
+	secondUsed := make(chan bool)
+	secondTx := startTestTransaction(t, connection)
+	defer secondTx.Rollback()
+	go func() {
+		used, err := secondTx.UseInvite(invite.Code)
+		assert.Nil(t, err)
+		secondUsed <- used
+	}()
+
+	assert.Nil(t, firstTx.Commit())
+	assert.False(t, <-secondUsed)
+}
+
+func TestPostgres_GetLobbyByIdForUpdate(t *testing.T) {
+	testGetLobbyByIdForUpdate(t, newTestPostgresConnection(t))
+}
+
+func TestPostgres_UpdatePlayerAfkNotice(t *testing.T) {
+	testUpdatePlayerAfkNotice(t, newTestPostgresConnection(t))
+}
+
+func TestPostgres_TryLeaderLock(t *testing.T) {
+	testTryLeaderLock(t, newTestPostgresConnection(t), newTestPostgresConnection(t))
+}
+
+func TestPostgres_LeaderLockLostWhenConnectionDies(t *testing.T) {
+	connection := newTestPostgresConnection(t)
+	otherConnection := newTestPostgresConnection(t)
+	name := uuid.NewString()
+	lock, err := connection.TryLeaderLock(name)
+	assert.Nil(t, err)
+	assert.NotNil(t, lock)
+
+	pid := lock.(*postgresLeaderLock).conn.Conn().PgConn().PID()
+	_, err = otherConnection.dbPool.Exec(context.Background(), "SELECT pg_terminate_backend($1)", pid)
+	assert.Nil(t, err)
+
+	assert.False(t, lock.IsHeld())
+	assert.Eventually(t, func() bool {
+		otherLock, err := otherConnection.TryLeaderLock(name)
+		if err != nil || otherLock == nil {
+			return false
+		}
+		otherLock.Release()
+		return true
+	}, 5*time.Second, 50*time.Millisecond)
+	lock.Release()
+}
+
+func TestPostgres_LeaderLockReleasedOnClose(t *testing.T) {
+	connection := newTestPostgresConnection(t)
+	otherConnection := newTestPostgresConnection(t)
+	name := uuid.NewString()
+	lock, err := connection.TryLeaderLock(name)
+	assert.Nil(t, err)
+	assert.NotNil(t, lock)
+
+	connection.Close()
+	assert.False(t, lock.IsHeld())
+	otherLock, err := otherConnection.TryLeaderLock(name)
+	assert.Nil(t, err)
+	assert.NotNil(t, otherLock)
+	otherLock.Release()
+}
+
+func TestPostgres_TryTransactionLock(t *testing.T) {
+	testTryTransactionLock(t, newTestPostgresConnection(t))
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/google/uuid"
+)
+
+const (
+	reconnect_token_table_name        = "reconnect_token"
+	upsert_reconnect_token_sql        = "INSERT INTO %s.%s(player_id, lobby_id, token_hash, name, spectator, payload, owner, seat_held_until, dropped_at, expires_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) ON CONFLICT (player_id) DO UPDATE SET lobby_id = $2, token_hash = $3, name = $4, spectator = $5, payload = $6, owner = $7, seat_held_until = $8, dropped_at = $9, expires_at = $10"
+	delete_reconnect_token_sql        = "DELETE FROM %s.%s WHERE player_id = $1"
+	delete_reconnect_token_before_sql = "DELETE FROM %s.%s WHERE expires_at < $1"
+	select_reconnect_token_sql        = "SELECT player_id, lobby_id, token_hash, name, spectator, payload, owner, seat_held_until, dropped_at, expires_at FROM %s.%s WHERE player_id = $1"
+	select_number_of_held_seats_sql   = "SELECT count(*) FROM %s.%s WHERE lobby_id = $1 AND seat_held_until > $2"
+)
+
+func (tx *postgresTransaction) UpsertReconnectToken(token *ReconnectToken) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(upsert_reconnect_token_sql, schema_name, reconnect_token_table_name), token.PlayerId, token.LobbyId, token.TokenHash, token.Name, token.Spectator, token.Payload, token.Owner, token.SeatHeldUntil, token.DroppedAt, token.ExpiresAt); err != nil {
+		return fmt.Errorf("unknown error when storing reconnect token: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteReconnectToken(playerId uuid.UUID) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_reconnect_token_sql, schema_name, reconnect_token_table_name), playerId); err != nil {
+		return fmt.Errorf("unknown error when deleting reconnect token: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) DeleteReconnectTokensBefore(expiresAt time.Time) error {
+	if _, err := tx.tx.Exec(context.Background(), fmt.Sprintf(delete_reconnect_token_before_sql, schema_name, reconnect_token_table_name), expiresAt); err != nil {
+		return fmt.Errorf("unknown error when deleting expired reconnect tokens: %v", err)
+	}
+	return nil
+}
+
+func (tx *postgresTransaction) GetReconnectToken(playerId uuid.UUID) (*ReconnectToken, error) {
+	var tokens []*ReconnectToken
+	if err := pgxscan.Select(context.Background(), tx.tx, &tokens, fmt.Sprintf(select_reconnect_token_sql, schema_name, reconnect_token_table_name), playerId); err != nil {
+		return nil, fmt.Errorf("error while selecting reconnect token of player [%v]: %v", playerId, err)
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return tokens[0], nil
+}
+
+func (tx *postgresTransaction) GetNumberOfHeldSeats(lobbyId uuid.UUID, now time.Time) (int, error) {
+	var count int
+	if err := tx.tx.QueryRow(context.Background(), fmt.Sprintf(select_number_of_held_seats_sql, schema_name, reconnect_token_table_name), lobbyId, now).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error while counting held seats of lobby [%v]: %v", lobbyId, err)
+	}
+	return count, nil
+}
@@ -1,10 +1,12 @@
 package util
 
 import (
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
 type Context struct {
 	CorrelationId string
+	PlayerId      uuid.UUID
 	Logger        *log.Entry
 }
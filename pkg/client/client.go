@@ -0,0 +1,177 @@
+// Package client calls the lobby api with the request and response types of the lobbyapi package
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/BeanCodeDe/TheRedShirts-Lobby/pkg/lobbyapi"
+	"github.com/google/uuid"
+)
+
+type (
+	// Client calls the lobby api as the player of the token
+	Client struct {
+		ServerUrl  string
+		Token      string
+		HTTPClient *http.Client
+	}
+
+	contextKey string
+
+	Lobby             = lobbyapi.Lobby
+	LobbyCreate       = lobbyapi.LobbyCreate
+	LobbyUpdate       = lobbyapi.LobbyUpdate
+	LobbyList         = lobbyapi.LobbyList
+	AfkPolicy         = lobbyapi.AfkPolicy
+	PlayerKick        = lobbyapi.PlayerKick
+	Ban               = lobbyapi.Ban
+	Player            = lobbyapi.Player
+	PlayerCreate      = lobbyapi.PlayerCreate
+	PlayerUpdate      = lobbyapi.PlayerUpdate
+	SimplePlayer      = lobbyapi.SimplePlayer
+	InviteCreate      = lobbyapi.InviteCreate
+	InviteJoin        = lobbyapi.InviteJoin
+	Invite            = lobbyapi.Invite
+	AuditEntry        = lobbyapi.AuditEntry
+	LobbyEvent        = lobbyapi.LobbyEvent
+	Health            = lobbyapi.Health
+	Problem           = lobbyapi.Problem
+	reconnectToken    = lobbyapi.ReconnectToken
+	playerReady       = lobbyapi.PlayerReady
+	lobbyUpdateStatus = lobbyapi.LobbyUpdateStatus
+	ownerTransfer     = lobbyapi.OwnerTransfer
+)
+
+const (
+	correlation_id_header   = "X-Correlation-ID"
+	idempotency_key_header  = "Idempotency-Key"
+	authorization_header    = "Authorization"
+	etag_header             = "ETag"
+	if_match_header         = "If-Match"
+	next_cursor_header      = "X-Next-Cursor"
+	content_type_header     = "Content-Type"
+	content_type_json       = "application/json; charset=utf-8"
+	bearer_prefix           = "Bearer "
+	default_request_timeout = 10 * time.Second
+
+	correlation_id_key  contextKey = "correlationId"
+	idempotency_key_key contextKey = "idempotencyKey"
+)
+
+// NewClient creates a client for the server, e.g. http://theredshirts-lobby:1203, which authenticates with the token
+func NewClient(serverUrl string, token string) *Client {
+	return &Client{ServerUrl: serverUrl, Token: token, HTTPClient: &http.Client{Timeout: default_request_timeout}}
+}
+
+// WithCorrelationId sends the correlation id with all requests of the context. Without it every request gets a new one
+func WithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlation_id_key, correlationId)
+}
+
+// WithIdempotencyKey lets the server replay the response, if the request is retried with the same context
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotency_key_key, key)
+}
+
+// send encodes the body as json and returns the response, if it has one of the expected status codes.
+// Otherwise the response is closed and returned as error
+func (client *Client) send(ctx context.Context, method string, path string, query url.Values, body interface{}, header http.Header, expectedStatus ...int) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("error while marshal request: %v", err)
+		}
+		reader = bytes.NewReader(jsonBody)
+	}
+
+	requestUrl := client.ServerUrl + path
+	if len(query) > 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestUrl, reader)
+	if err != nil {
+		return nil, fmt.Errorf("request %s %s could not be build: %v", method, path, err)
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	correlationId, ok := ctx.Value(correlation_id_key).(string)
+	if !ok {
+		correlationId = uuid.NewString()
+	}
+	req.Header.Set(correlation_id_header, correlationId)
+	if key, ok := ctx.Value(idempotency_key_key).(string); ok {
+		req.Header.Set(idempotency_key_header, key)
+	}
+	req.Header.Set(authorization_header, bearer_prefix+client.Token)
+	if body != nil {
+		req.Header.Set(content_type_header, content_type_json)
+	}
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request %s %s not possible: %v", method, path, err)
+	}
+	for _, status := range expectedStatus {
+		if resp.StatusCode == status {
+			return resp, nil
+		}
+	}
+	defer resp.Body.Close()
+	return nil, newError(resp, correlationId)
+}
+
+// call sends the request and decodes the response into result, if result is not nil
+func (client *Client) call(ctx context.Context, method string, path string, body interface{}, result interface{}, expectedStatus int) error {
+	resp, err := client.send(ctx, method, path, nil, body, nil, expectedStatus)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, result)
+}
+
+func decode(resp *http.Response, result interface{}) error {
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error while parsing response: %v", err)
+	}
+	return nil
+}
+
+// ifMatch only lets the server change the entity if it still has the version. Versions up to 0 match any version
+func ifMatch(version int) http.Header {
+	header := http.Header{}
+	if version > 0 {
+		header.Set(if_match_header, strconv.Quote(strconv.Itoa(version)))
+	}
+	return header
+}
+
+// getVersion reads the version of the ETag header, 0 if it is missing
+func getVersion(resp *http.Response) (int, error) {
+	etag := resp.Header.Get(etag_header)
+	if etag == "" {
+		return 0, nil
+	}
+	tag, err := strconv.Unquote(etag)
+	if err != nil {
+		return 0, fmt.Errorf("etag %s is not quoted: %v", etag, err)
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		return 0, fmt.Errorf("etag %s is no version: %v", etag, err)
+	}
+	return version, nil
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const test_token = "some-token"
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, test_token)
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set(content_type_header, content_type_json)
+	w.WriteHeader(status)
+	assert.Nil(t, json.NewEncoder(w).Encode(body))
+}
+
+func TestCreateLobby_SendsLobbyWithCorrelationId(t *testing.T) {
+	lobby := &LobbyCreate{ID: uuid.New(), Name: "Some Lobby", Owner: &Player{ID: uuid.New(), Name: "Owner"}, MaxPlayers: 4}
+	correlationId := uuid.NewString()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, fmt.Sprintf("/lobby/%s", lobby.ID), r.URL.Path)
+		assert.Equal(t, bearer_prefix+test_token, r.Header.Get(authorization_header))
+		assert.Equal(t, correlationId, r.Header.Get(correlation_id_header))
+		receivedLobby := new(LobbyCreate)
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(receivedLobby))
+		assert.Equal(t, lobby.Name, receivedLobby.Name)
+		assert.Equal(t, lobby.Owner.ID, receivedLobby.Owner.ID)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	assert.Nil(t, client.CreateLobby(WithCorrelationId(context.Background(), correlationId), lobby))
+}
+
+func TestGetLobby_ReturnsVersion(t *testing.T) {
+	lobbyId := uuid.New()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get(correlation_id_header))
+		w.Header().Set(etag_header, `"3"`)
+		writeJSON(t, w, http.StatusOK, &Lobby{ID: lobbyId, Name: "Some Lobby"})
+	})
+
+	lobby, version, err := client.GetLobby(context.Background(), lobbyId)
+	assert.Nil(t, err)
+	assert.Equal(t, "Some Lobby", lobby.Name)
+	assert.Equal(t, 3, version)
+}
+
+func TestUpdateLobbyStatus_SendsVersion(t *testing.T) {
+	lobbyId := uuid.New()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, fmt.Sprintf("/lobby/%s/status", lobbyId), r.URL.Path)
+		assert.Equal(t, `"3"`, r.Header.Get(if_match_header))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	assert.Nil(t, client.UpdateLobbyStatus(context.Background(), lobbyId, "STARTING", 3))
+}
+
+func TestGetLobbies_SendsFilterAndReturnsCursor(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "OPEN", r.URL.Query().Get("status"))
+		assert.Equal(t, []string{"first", "second"}, r.URL.Query()["expansion_pack"])
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		assert.False(t, r.URL.Query().Has("difficulty"))
+		w.Header().Set(next_cursor_header, "next")
+		writeJSON(t, w, http.StatusOK, []*Lobby{{ID: uuid.New()}})
+	})
+
+	lobbies, cursor, err := client.GetLobbies(context.Background(), &LobbyList{Status: "OPEN", ExpansionPacks: []string{"first", "second"}, Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, lobbies, 1)
+	assert.Equal(t, "next", cursor)
+}
+
+func TestError_IsTypedByStatus(t *testing.T) {
+	correlationId := uuid.NewString()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusNotFound, &Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "lobby not found", CorrelationId: correlationId})
+	})
+
+	_, _, err := client.GetLobby(WithCorrelationId(context.Background(), correlationId), uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+	var clientError *Error
+	assert.True(t, errors.As(err, &clientError))
+	assert.Equal(t, http.StatusNotFound, clientError.StatusCode)
+	assert.Equal(t, correlationId, clientError.CorrelationId)
+	assert.Equal(t, "lobby not found", clientError.Problem.Detail)
+	assert.EqualError(t, err, "not found (404): lobby not found")
+}
+
+func TestError_WithoutProblem(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	err := client.DeleteLobby(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrServer)
+	var clientError *Error
+	assert.True(t, errors.As(err, &clientError))
+	assert.Nil(t, clientError.Problem)
+}
+
+func TestCheckReadiness_NotReady(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusServiceUnavailable, &Health{Status: "DOWN", Checks: map[string]string{"database": "DOWN"}})
+	})
+
+	health, err := client.CheckReadiness(context.Background())
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.Equal(t, "DOWN", health.Checks["database"])
+}
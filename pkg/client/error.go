@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// Error is returned for every response with an unexpected status. It wraps the error of the status, so it can be checked with errors.Is
+	Error struct {
+		StatusCode    int
+		CorrelationId string
+		Problem       *Problem
+	}
+)
+
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrForbidden           = errors.New("forbidden")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrGone                = errors.New("gone")
+	ErrPreconditionFailed  = errors.New("precondition failed")
+	ErrUnprocessableEntity = errors.New("unprocessable entity")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrServiceUnavailable  = errors.New("service unavailable")
+	ErrServer              = errors.New("server error")
+	ErrUnexpectedStatus    = errors.New("unexpected status")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:          ErrBadRequest,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
+	http.StatusGone:                ErrGone,
+	http.StatusPreconditionFailed:  ErrPreconditionFailed,
+	http.StatusUnprocessableEntity: ErrUnprocessableEntity,
+	http.StatusTooManyRequests:     ErrTooManyRequests,
+	http.StatusServiceUnavailable:  ErrServiceUnavailable,
+}
+
+// newError reads the problem of the response. Responses without problem, e.g. of a proxy, still get an error of their status
+func newError(resp *http.Response, correlationId string) *Error {
+	clientError := &Error{StatusCode: resp.StatusCode, CorrelationId: correlationId}
+	problem := new(Problem)
+	if err := json.NewDecoder(resp.Body).Decode(problem); err == nil && problem.Status != 0 {
+		clientError.Problem = problem
+	}
+	return clientError
+}
+
+func (clientError *Error) Error() string {
+	if clientError.Problem != nil && clientError.Problem.Detail != "" {
+		return fmt.Sprintf("%v (%d): %s", clientError.Unwrap(), clientError.StatusCode, clientError.Problem.Detail)
+	}
+	return fmt.Sprintf("%v (%d)", clientError.Unwrap(), clientError.StatusCode)
+}
+
+func (clientError *Error) Unwrap() error {
+	if err, ok := statusErrors[clientError.StatusCode]; ok {
+		return err
+	}
+	if clientError.StatusCode >= http.StatusInternalServerError {
+		return ErrServer
+	}
+	return ErrUnexpectedStatus
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	events_path          = lobby_path + "/events"
+	last_event_id_header = "Last-Event-ID"
+	accept_header        = "Accept"
+	event_stream_type    = "text/event-stream"
+	sse_data_prefix      = "data: "
+)
+
+// StreamLobbyEvents passes all events of the lobby after lastEventId to handle, a negative lastEventId only streams new events.
+// It blocks until the context is done, the server closes the stream, e.g. after the lobby was deleted, or handle returns an error.
+// The timeout of the http client doesn't apply to the stream
+func (client *Client) StreamLobbyEvents(ctx context.Context, lobbyId uuid.UUID, lastEventId int64, handle func(event *LobbyEvent) error) error {
+	header := http.Header{}
+	header.Set(accept_header, event_stream_type)
+	if lastEventId >= 0 {
+		header.Set(last_event_id_header, strconv.FormatInt(lastEventId, 10))
+	}
+	httpClient := *client.HTTPClient
+	httpClient.Timeout = 0
+	streamClient := &Client{ServerUrl: client.ServerUrl, Token: client.Token, HTTPClient: &httpClient}
+	resp, err := streamClient.send(ctx, http.MethodGet, fmt.Sprintf(events_path, lobbyId), nil, nil, header, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, sse_data_prefix) {
+			continue
+		}
+		event := new(LobbyEvent)
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, sse_data_prefix)), event); err != nil {
+			return fmt.Errorf("error while parsing event: %v", err)
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error while reading event stream: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSSEEvent(t *testing.T, w http.ResponseWriter, event *LobbyEvent) {
+	data, err := json.Marshal(event)
+	assert.Nil(t, err)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Topic, data)
+	w.(http.Flusher).Flush()
+}
+
+func TestStreamLobbyEvents_UntilServerCloses(t *testing.T) {
+	lobbyId := uuid.New()
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, fmt.Sprintf("/lobby/%s/events", lobbyId), r.URL.Path)
+		assert.Equal(t, "0", r.Header.Get(last_event_id_header))
+		w.Header().Set(content_type_header, event_stream_type)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ": keep alive\n\n")
+		writeSSEEvent(t, w, &LobbyEvent{ID: 1, LobbyId: lobbyId, Topic: "PLAYER_JOINS_LOBBY", Lobby: &Lobby{ID: lobbyId}})
+		writeSSEEvent(t, w, &LobbyEvent{ID: 2, LobbyId: lobbyId, Topic: "LOBBY_DELETED"})
+	})
+
+	events := make([]*LobbyEvent, 0)
+	err := client.StreamLobbyEvents(context.Background(), lobbyId, 0, func(event *LobbyEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "PLAYER_JOINS_LOBBY", events[0].Topic)
+	assert.Equal(t, lobbyId, events[0].Lobby.ID)
+	assert.Nil(t, events[1].Lobby)
+}
+
+func TestStreamLobbyEvents_EndsWithErrorOfHandler(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get(last_event_id_header))
+		w.WriteHeader(http.StatusOK)
+		writeSSEEvent(t, w, &LobbyEvent{ID: 1, Topic: "PLAYER_JOINS_LOBBY"})
+		<-r.Context().Done()
+	})
+
+	handlerErr := errors.New("stop")
+	err := client.StreamLobbyEvents(context.Background(), uuid.New(), -1, func(event *LobbyEvent) error {
+		return handlerErr
+	})
+	assert.ErrorIs(t, err, handlerErr)
+}
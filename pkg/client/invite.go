@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+)
+
+const (
+	invite_path        = lobby_path + "/invite"
+	revoke_invite_path = invite_path + "/%s"
+	join_path          = "/join/%s"
+)
+
+func (client *Client) CreateInvite(ctx context.Context, invite *InviteCreate) (*Invite, error) {
+	createdInvite := new(Invite)
+	if err := client.call(ctx, http.MethodPost, fmt.Sprintf(invite_path, invite.LobbyId), invite, createdInvite, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return createdInvite, nil
+}
+
+func (client *Client) GetInvites(ctx context.Context, lobbyId uuid.UUID) ([]*Invite, error) {
+	invites := make([]*Invite, 0)
+	if err := client.call(ctx, http.MethodGet, fmt.Sprintf(invite_path, lobbyId), nil, &invites, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (client *Client) RevokeInvite(ctx context.Context, lobbyId uuid.UUID, code string) error {
+	return client.call(ctx, http.MethodDelete, fmt.Sprintf(revoke_invite_path, lobbyId, url.PathEscape(code)), nil, nil, http.StatusNoContent)
+}
+
+// JoinWithInvite joins the player of the token to the lobby of the invite
+func (client *Client) JoinWithInvite(ctx context.Context, join *InviteJoin) (*SimplePlayer, error) {
+	player := new(SimplePlayer)
+	if err := client.call(ctx, http.MethodPost, fmt.Sprintf(join_path, url.PathEscape(join.Code)), join, player, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return player, nil
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	lobby_root_path = "/lobby"
+	lobby_path      = lobby_root_path + "/%s"
+	status_path     = lobby_path + "/status"
+	kick_path       = lobby_path + "/player/%s"
+	ban_path        = lobby_path + "/ban"
+	owner_path      = lobby_path + "/owner"
+	history_path    = lobby_path + "/history"
+)
+
+// CreateLobbyId returns a new id for a lobby
+func (client *Client) CreateLobbyId(ctx context.Context) (uuid.UUID, error) {
+	resp, err := client.send(ctx, http.MethodPost, lobby_root_path, nil, nil, nil, http.StatusCreated)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error while reading lobby id: %v", err)
+	}
+	lobbyId, err := uuid.Parse(string(body))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("lobby id %s is no uuid: %v", body, err)
+	}
+	return lobbyId, nil
+}
+
+// CreateLobby creates the lobby with its id. The owner has to be the player of the token
+func (client *Client) CreateLobby(ctx context.Context, lobby *LobbyCreate) error {
+	return client.call(ctx, http.MethodPut, fmt.Sprintf(lobby_path, lobby.ID), lobby, nil, http.StatusCreated)
+}
+
+// GetLobby returns the lobby and its version, which can be used to update it. The lobby is nil if the server has no content
+func (client *Client) GetLobby(ctx context.Context, lobbyId uuid.UUID) (*Lobby, int, error) {
+	resp, err := client.send(ctx, http.MethodGet, fmt.Sprintf(lobby_path, lobbyId), nil, nil, nil, http.StatusOK, http.StatusNoContent)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, 0, nil
+	}
+	lobby := new(Lobby)
+	if err := decode(resp, lobby); err != nil {
+		return nil, 0, err
+	}
+	version, err := getVersion(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return lobby, version, nil
+}
+
+// GetLobbies returns a page of public lobbies and the cursor of the next page, which is empty on the last page
+func (client *Client) GetLobbies(ctx context.Context, list *LobbyList) ([]*Lobby, string, error) {
+	resp, err := client.send(ctx, http.MethodGet, lobby_root_path, lobbyListQuery(list), nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	lobbies := make([]*Lobby, 0)
+	if err := decode(resp, &lobbies); err != nil {
+		return nil, "", err
+	}
+	return lobbies, resp.Header.Get(next_cursor_header), nil
+}
+
+// UpdateLobby changes the settings of the lobby. A version greater than 0 fails with ErrPreconditionFailed, if the lobby was changed in between
+func (client *Client) UpdateLobby(ctx context.Context, lobby *LobbyUpdate, version int) error {
+	resp, err := client.send(ctx, http.MethodPatch, fmt.Sprintf(lobby_path, lobby.ID), nil, lobby, ifMatch(version), http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// UpdateLobbyStatus changes the status of the lobby. A version greater than 0 fails with ErrPreconditionFailed, if the lobby was changed in between
+func (client *Client) UpdateLobbyStatus(ctx context.Context, lobbyId uuid.UUID, status string, version int) error {
+	resp, err := client.send(ctx, http.MethodPatch, fmt.Sprintf(status_path, lobbyId), nil, &lobbyUpdateStatus{ID: lobbyId, Status: status}, ifMatch(version), http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (client *Client) DeleteLobby(ctx context.Context, lobbyId uuid.UUID) error {
+	return client.call(ctx, http.MethodDelete, fmt.Sprintf(lobby_path, lobbyId), nil, nil, http.StatusOK)
+}
+
+func (client *Client) KickPlayer(ctx context.Context, kick *PlayerKick) error {
+	return client.call(ctx, http.MethodDelete, fmt.Sprintf(kick_path, kick.LobbyId, kick.ID), kick, nil, http.StatusNoContent)
+}
+
+func (client *Client) GetBans(ctx context.Context, lobbyId uuid.UUID) ([]*Ban, error) {
+	bans := make([]*Ban, 0)
+	if err := client.call(ctx, http.MethodGet, fmt.Sprintf(ban_path, lobbyId), nil, &bans, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+func (client *Client) TransferOwnership(ctx context.Context, lobbyId uuid.UUID, playerId uuid.UUID) error {
+	return client.call(ctx, http.MethodPost, fmt.Sprintf(owner_path, lobbyId), &ownerTransfer{LobbyId: lobbyId, PlayerId: playerId}, nil, http.StatusOK)
+}
+
+// GetLobbyHistory returns a page of the audit log of the lobby and the cursor of the next page, which is empty on the last page
+func (client *Client) GetLobbyHistory(ctx context.Context, lobbyId uuid.UUID, cursor string, limit int) ([]*AuditEntry, string, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := client.send(ctx, http.MethodGet, fmt.Sprintf(history_path, lobbyId), query, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	entries := make([]*AuditEntry, 0)
+	if err := decode(resp, &entries); err != nil {
+		return nil, "", err
+	}
+	return entries, resp.Header.Get(next_cursor_header), nil
+}
+
+func lobbyListQuery(list *LobbyList) url.Values {
+	query := url.Values{}
+	if list == nil {
+		return query
+	}
+	setQuery(query, "status", list.Status)
+	setQueryInt(query, "difficulty", list.Difficulty)
+	setQueryInt(query, "mission_length", list.MissionLength)
+	for _, expansionPack := range list.ExpansionPacks {
+		query.Add("expansion_pack", expansionPack)
+	}
+	setQueryInt(query, "free_slots", list.FreeSlots)
+	setQuery(query, "password_protected", list.PasswordProtected)
+	setQuery(query, "sort", list.Sort)
+	setQuery(query, "order", list.Order)
+	setQueryInt(query, "limit", list.Limit)
+	setQuery(query, "cursor", list.Cursor)
+	return query
+}
+
+func setQuery(query url.Values, key string, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setQueryInt(query url.Values, key string, value int) {
+	if value != 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
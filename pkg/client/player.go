@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	player_path       = "/player/%s"
+	last_refresh_path = player_path + "/last-refresh"
+	ready_path        = player_path + "/ready"
+	reconnect_path    = player_path + "/reconnect"
+)
+
+// JoinLobby joins the player of the token to the lobby and returns the token to reconnect after the player was dropped
+func (client *Client) JoinLobby(ctx context.Context, player *PlayerCreate) (string, error) {
+	token := new(reconnectToken)
+	if err := client.call(ctx, http.MethodPut, fmt.Sprintf(player_path, player.ID), player, token, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return token.ReconnectToken, nil
+}
+
+// ReconnectPlayer rejoins the player with the token of the last join or reconnect and returns the new token
+func (client *Client) ReconnectPlayer(ctx context.Context, playerId uuid.UUID, token string) (string, error) {
+	newToken := new(reconnectToken)
+	if err := client.call(ctx, http.MethodPost, fmt.Sprintf(reconnect_path, playerId), &reconnectToken{ReconnectToken: token}, newToken, http.StatusOK); err != nil {
+		return "", err
+	}
+	return newToken.ReconnectToken, nil
+}
+
+// GetPlayer returns the player and its version, which can be used to update it
+func (client *Client) GetPlayer(ctx context.Context, playerId uuid.UUID) (*SimplePlayer, int, error) {
+	resp, err := client.send(ctx, http.MethodGet, fmt.Sprintf(player_path, playerId), nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	player := new(SimplePlayer)
+	if err := decode(resp, player); err != nil {
+		return nil, 0, err
+	}
+	version, err := getVersion(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+	return player, version, nil
+}
+
+// UpdatePlayer changes the player. A version greater than 0 fails with ErrPreconditionFailed, if the player was changed in between
+func (client *Client) UpdatePlayer(ctx context.Context, player *PlayerUpdate, version int) error {
+	resp, err := client.send(ctx, http.MethodPatch, fmt.Sprintf(player_path, player.ID), nil, player, ifMatch(version), http.StatusCreated)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (client *Client) UpdatePlayerLastRefresh(ctx context.Context, playerId uuid.UUID) error {
+	return client.call(ctx, http.MethodPatch, fmt.Sprintf(last_refresh_path, playerId), nil, nil, http.StatusOK)
+}
+
+func (client *Client) UpdatePlayerReady(ctx context.Context, playerId uuid.UUID, ready bool) error {
+	return client.call(ctx, http.MethodPut, fmt.Sprintf(ready_path, playerId), &playerReady{ID: playerId, Ready: ready}, nil, http.StatusOK)
+}
+
+// LeaveLobby removes the player of the token from their lobby
+func (client *Client) LeaveLobby(ctx context.Context, playerId uuid.UUID) error {
+	return client.call(ctx, http.MethodDelete, fmt.Sprintf(player_path, playerId), nil, nil, http.StatusNoContent)
+}
+
+// StartHeartbeat refreshes the player every interval, so they are not removed as afk. Failed refreshes are passed to onError,
+// which may be nil. The heartbeat ends when the context is done, stop is called or the player is not found anymore
+func (client *Client) StartHeartbeat(ctx context.Context, playerId uuid.UUID, interval time.Duration, onError func(error)) (stop func()) {
+	heartbeatContext, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			err := client.UpdatePlayerLastRefresh(heartbeatContext, playerId)
+			if err != nil && heartbeatContext.Err() == nil && onError != nil {
+				onError(err)
+			}
+			if errors.Is(err, ErrNotFound) {
+				return
+			}
+			select {
+			case <-heartbeatContext.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
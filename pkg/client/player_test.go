@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinLobby_ReturnsReconnectToken(t *testing.T) {
+	player := &PlayerCreate{ID: uuid.New(), Name: "Player", LobbyId: uuid.New()}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, fmt.Sprintf("/player/%s", player.ID), r.URL.Path)
+		assert.Equal(t, "some-key", r.Header.Get(idempotency_key_header))
+		writeJSON(t, w, http.StatusCreated, &reconnectToken{ReconnectToken: "token"})
+	})
+
+	token, err := client.JoinLobby(WithIdempotencyKey(context.Background(), "some-key"), player)
+	assert.Nil(t, err)
+	assert.Equal(t, "token", token)
+}
+
+func TestStartHeartbeat_RefreshesUntilStopped(t *testing.T) {
+	playerId := uuid.New()
+	var refreshes atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		assert.Equal(t, fmt.Sprintf("/player/%s/last-refresh", playerId), r.URL.Path)
+		refreshes.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	stop := client.StartHeartbeat(context.Background(), playerId, 10*time.Millisecond, func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	})
+	assert.Eventually(t, func() bool { return refreshes.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	stop()
+
+	stopped := refreshes.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, refreshes.Load())
+}
+
+func TestStartHeartbeat_EndsWhenPlayerNotFound(t *testing.T) {
+	var refreshes atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		refreshes.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	errs := make(chan error, 10)
+	stop := client.StartHeartbeat(context.Background(), uuid.New(), 10*time.Millisecond, func(err error) { errs <- err })
+	defer stop()
+
+	assert.ErrorIs(t, <-errs, ErrNotFound)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(1), refreshes.Load())
+}
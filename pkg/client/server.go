@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+const (
+	ping_path         = "/server/ping"
+	health_live_path  = "/server/health/live"
+	health_ready_path = "/server/health/ready"
+)
+
+func (client *Client) Ping(ctx context.Context) error {
+	return client.call(ctx, http.MethodGet, ping_path, nil, nil, http.StatusNoContent)
+}
+
+func (client *Client) CheckLiveness(ctx context.Context) (*Health, error) {
+	health := new(Health)
+	if err := client.call(ctx, http.MethodGet, health_live_path, nil, health, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
+
+// CheckReadiness returns the health of the dependencies. If the server is not ready, the health is returned together with ErrServiceUnavailable
+func (client *Client) CheckReadiness(ctx context.Context) (*Health, error) {
+	resp, err := client.send(ctx, http.MethodGet, health_ready_path, nil, nil, nil, http.StatusOK, http.StatusServiceUnavailable)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	health := new(Health)
+	if err := decode(resp, health); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return health, &Error{StatusCode: resp.StatusCode, CorrelationId: resp.Request.Header.Get(correlation_id_header)}
+	}
+	return health, nil
+}
@@ -0,0 +1,2 @@
+// Package lobbyapi contains the request and response bodies of the lobby api. They are shared by the server and the client
+package lobbyapi
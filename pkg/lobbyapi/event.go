@@ -0,0 +1,16 @@
+package lobbyapi
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type LobbyEvent struct {
+	ID        int64                  `json:"id"`
+	LobbyId   uuid.UUID              `json:"lobby_id"`
+	Topic     string                 `json:"topic"`
+	Payload   map[string]interface{} `json:"payload"`
+	Lobby     *Lobby                 `json:"lobby"`
+	CreatedAt time.Time              `json:"created_at"`
+}
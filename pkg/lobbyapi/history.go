@@ -0,0 +1,16 @@
+package lobbyapi
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditEntry struct {
+	ID            int64                  `json:"id"`
+	Topic         string                 `json:"topic"`
+	ActorId       *uuid.UUID             `json:"actor_id,omitempty"`
+	CorrelationId string                 `json:"correlation_id"`
+	Payload       map[string]interface{} `json:"payload"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
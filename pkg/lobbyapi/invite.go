@@ -0,0 +1,32 @@
+package lobbyapi
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	InviteCreate struct {
+		LobbyId          uuid.UUID `param:"lobbyId" validate:"required"`
+		ExpiresInSeconds int       `json:"expires_in_seconds" validate:"min=0"`
+		MaxUses          int       `json:"max_uses" validate:"min=0"`
+	}
+
+	InviteJoin struct {
+		Code      string                 `param:"code" validate:"required"`
+		PlayerId  uuid.UUID              `json:"player_id" validate:"required"`
+		Name      string                 `json:"name" validate:"required"`
+		Spectator bool                   `json:"spectator"`
+		Payload   map[string]interface{} `json:"payload"`
+	}
+
+	Invite struct {
+		Code      string     `json:"code"`
+		LobbyId   uuid.UUID  `json:"lobby_id"`
+		CreatedAt time.Time  `json:"created_at"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty"`
+		MaxUses   int        `json:"max_uses"`
+		Uses      int        `json:"uses"`
+	}
+)
@@ -0,0 +1,98 @@
+package lobbyapi
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	LobbyCreate struct {
+		ID                  uuid.UUID              `param:"lobbyId" validate:"required"`
+		Name                string                 `json:"name" validate:"required"`
+		Owner               *Player                `json:"owner" validate:"required"`
+		Password            string                 `json:"password"`
+		Visibility          string                 `json:"visibility" validate:"omitempty,oneof=PUBLIC UNLISTED PRIVATE"`
+		AfkPolicies         map[string]*AfkPolicy  `json:"afk_policies" validate:"omitempty,dive,keys,oneof=OPEN STARTING PLAYING FINISHED ABORTED,endkeys,required"`
+		Difficulty          int                    `json:"difficulty" validate:"required"`
+		MissionLength       int                    `json:"mission_length" validate:"required"`
+		NumberOfCrewMembers int                    `json:"number_of_crew_members" validate:"required"`
+		MaxPlayers          int                    `json:"max_players" validate:"required"`
+		ExpansionPacks      []string               `json:"expansion_packs"`
+		Payload             map[string]interface{} `json:"payload"`
+	}
+
+	LobbyUpdate struct {
+		ID                  uuid.UUID              `param:"lobbyId" validate:"required"`
+		Name                string                 `json:"name" validate:"required"`
+		Status              string                 `json:"status" validate:"omitempty,oneof=OPEN STARTING PLAYING FINISHED ABORTED"`
+		Password            string                 `json:"password"`
+		Visibility          string                 `json:"visibility" validate:"omitempty,oneof=PUBLIC UNLISTED PRIVATE"`
+		AfkPolicies         map[string]*AfkPolicy  `json:"afk_policies" validate:"omitempty,dive,keys,oneof=OPEN STARTING PLAYING FINISHED ABORTED,endkeys,required"`
+		Difficulty          int                    `json:"difficulty" validate:"required"`
+		MissionLength       int                    `json:"mission_length" validate:"required"`
+		NumberOfCrewMembers int                    `json:"number_of_crew_members" validate:"required"`
+		MaxPlayers          int                    `json:"max_players" validate:"required"`
+		ExpansionPacks      []string               `json:"expansion_packs"`
+		Payload             map[string]interface{} `json:"payload"`
+	}
+
+	AfkPolicy struct {
+		WarningSeconds      int `json:"warning_seconds" validate:"min=0"`
+		RemovalSeconds      int `json:"removal_seconds" validate:"min=0"`
+		ReservedSeatSeconds int `json:"reserved_seat_seconds" validate:"min=0"`
+	}
+
+	LobbyUpdateStatus struct {
+		ID     uuid.UUID `param:"lobbyId" validate:"required"`
+		Status string    `json:"status" validate:"required,oneof=OPEN STARTING PLAYING FINISHED ABORTED"`
+	}
+
+	LobbyList struct {
+		Status            string   `query:"status" validate:"omitempty,oneof=OPEN STARTING PLAYING FINISHED ABORTED"`
+		Difficulty        int      `query:"difficulty"`
+		MissionLength     int      `query:"mission_length"`
+		ExpansionPacks    []string `query:"expansion_pack"`
+		FreeSlots         int      `query:"free_slots" validate:"min=0"`
+		PasswordProtected string   `query:"password_protected" validate:"omitempty,oneof=true false"`
+		Sort              string   `query:"sort" validate:"omitempty,oneof=created_at name difficulty free_slots"`
+		Order             string   `query:"order" validate:"omitempty,oneof=asc desc"`
+		Limit             int      `query:"limit" validate:"min=0,max=100"`
+		Cursor            string   `query:"cursor"`
+	}
+
+	PlayerKick struct {
+		LobbyId uuid.UUID `param:"lobbyId" validate:"required"`
+		ID      uuid.UUID `param:"playerId" validate:"required"`
+		Reason  string    `json:"reason"`
+		Ban     bool      `json:"ban"`
+	}
+
+	OwnerTransfer struct {
+		LobbyId  uuid.UUID `param:"lobbyId" validate:"required"`
+		PlayerId uuid.UUID `json:"player_id" validate:"required"`
+	}
+
+	Ban struct {
+		PlayerId  uuid.UUID `json:"player_id"`
+		Reason    string    `json:"reason"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	Lobby struct {
+		ID                  uuid.UUID              `json:"id"`
+		Name                string                 `json:"name"`
+		Status              string                 `json:"status"`
+		Visibility          string                 `json:"visibility"`
+		AfkPolicies         map[string]*AfkPolicy  `json:"afk_policies,omitempty"`
+		PasswordProtected   bool                   `json:"password_protected"`
+		Owner               *Player                `json:"owner"`
+		Difficulty          int                    `json:"difficulty"`
+		MissionLength       int                    `json:"mission_length"`
+		NumberOfCrewMembers int                    `json:"number_of_crew_members" `
+		MaxPlayers          int                    `json:"max_players" `
+		ExpansionPacks      []string               `json:"expansion_packs" `
+		Players             []*Player              `json:"players"`
+		Payload             map[string]interface{} `json:"payload"`
+	}
+)
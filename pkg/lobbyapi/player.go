@@ -0,0 +1,47 @@
+package lobbyapi
+
+import (
+	"github.com/google/uuid"
+)
+
+type (
+	PlayerCreate struct {
+		ID        uuid.UUID              `param:"playerId" validate:"required"`
+		Name      string                 `json:"name" validate:"required"`
+		LobbyId   uuid.UUID              `json:"lobby_id" validate:"required"`
+		Password  string                 `json:"password"`
+		Spectator bool                   `json:"spectator"`
+		Payload   map[string]interface{} `json:"payload"`
+	}
+
+	PlayerUpdate struct {
+		ID        uuid.UUID              `param:"playerId" validate:"required"`
+		Name      string                 `json:"name" validate:"required"`
+		Spectator bool                   `json:"spectator"`
+		Payload   map[string]interface{} `json:"payload"`
+	}
+
+	PlayerReady struct {
+		ID    uuid.UUID `param:"playerId" validate:"required"`
+		Ready bool      `json:"ready"`
+	}
+
+	ReconnectToken struct {
+		ReconnectToken string `json:"reconnect_token"`
+	}
+
+	Player struct {
+		ID        uuid.UUID              `json:"id" validate:"required"`
+		Name      string                 `json:"name" validate:"required"`
+		Spectator bool                   `json:"spectator"`
+		Ready     bool                   `json:"ready"`
+		Payload   map[string]interface{} `json:"payload"`
+	}
+
+	SimplePlayer struct {
+		ID        uuid.UUID `json:"id"`
+		Name      string    `json:"name"`
+		Spectator bool      `json:"spectator"`
+		LobbyId   uuid.UUID `json:"lobby_id"`
+	}
+)
@@ -0,0 +1,11 @@
+package lobbyapi
+
+// Problem is the error body of RFC 7807
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	CorrelationId string `json:"correlation_id,omitempty"`
+}
@@ -0,0 +1,7 @@
+package lobbyapi
+
+// Health is UP when all checks are UP
+type Health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}